@@ -12,7 +12,7 @@ import (
 
 type Config struct {
 	TelegramToken string
-	GeminiApiKey  string
+	GeminiApiKeys []string
 	WebAppURL     string
 	Debug         bool
 	HTTPTimeout   time.Duration
@@ -20,13 +20,79 @@ type Config struct {
 	MaxRetries    int
 	RetryDelay    time.Duration
 
+	// Servidor da Bot API: o oficial ou um telegram-bot-api próprio. Em modo
+	// local (--local), getFile retorna caminhos do disco do servidor
+	TelegramAPIURL   string
+	TelegramAPILocal bool
+
+	// Recebimento de atualizações: "polling" (getUpdates) ou "webhook"
+	TelegramMode          string
+	TelegramWebhookURL    string // URL pública do servidor HTTP, sem o caminho (ex: https://bot.exemplo.com)
+	TelegramWebhookPath   string
+	TelegramWebhookSecret string // Valor esperado no cabeçalho X-Telegram-Bot-Api-Secret-Token
+
 	// Configurações de retenção de mensagens
 	MessageRetention time.Duration
 	CleanupInterval  time.Duration
 
+	// Configurações de agendamentos
+	DefaultTimezone       string
+	ScheduleCheckInterval time.Duration
+
 	// Seleção do serviço de IA
 	AIService string
 
+	// Entrega das respostas: "preview" (prévia + Mini App), "full" (texto completo no chat)
+	// ou "auto" (texto completo até DeliveryAutoMaxLength caracteres, prévia acima disso)
+	DefaultDeliveryMode   string
+	DeliveryAutoMaxLength int
+
+	// Padrão dos grupos sem configuração própria (/group): modo de conversa
+	// ("user" ou "shared"), gatilhos, prefixo de comando e palavras-chave
+	GroupConversationMode string
+	GroupTriggers         []string
+	GroupCommandPrefix    string
+	GroupKeywords         []string
+
+	// Envios ao Telegram: limite global de mensagens por segundo e tentativas
+	// após erros temporários (429 com retry_after e 5xx)
+	TelegramRateLimit  int
+	TelegramMaxRetries int
+
+	// Broadcasts: mensagens por segundo, abaixo do limite global para não atrasar as respostas
+	BroadcastRateLimit int
+
+	// Limite global de chamadas simultâneas aos provedores de IA
+	MaxConcurrentAI int
+
+	// Modo inline: espera após a última digitação antes de consultar a IA e
+	// tempo em que a resposta de uma mesma consulta é reaproveitada
+	InlineDebounce time.Duration
+	InlineCacheTTL time.Duration
+
+	// Validade dos botões inline: cliques em botões mais antigos são recusados e
+	// a linha do botão é removida. Os botões de avaliação das respostas não expiram
+	CallbackTTL time.Duration
+
+	// Número máximo de tentativas de um job de pergunta antes de ir para o estado dead
+	JobMaxAttempts int
+
+	// Pool de chaves de API: estratégia de escolha ("round_robin" ou "least_limited")
+	// e tempo que uma chave fica afastada após erro de cota (429)
+	KeyPoolStrategy string
+	KeyCooldown     time.Duration
+
+	// IDs dos usuários do Telegram com acesso aos comandos de administração
+	AdminUserIDs []int64
+
+	// Token exigido nas rotas /api/admin (cabeçalho Authorization: Bearer <token>).
+	// Se vazio, a API administrativa fica desabilitada
+	AdminAPIToken string
+
+	// Política de acesso: "open" (qualquer usuário não banido) ou "allowlist"
+	// (apenas usuários e grupos liberados). Pode ser alterada em tempo de execução com /access
+	AccessMode string
+
 	// Configurações do Gemini
 	GeminiModel           string
 	GeminiTemperature     float64
@@ -34,8 +100,14 @@ type Config struct {
 	GeminiTopP            float64
 	GeminiMaxOutputTokens int
 
+	// Configurações do serviço fake (AI_SERVICE=fake), para desenvolvimento e testes
+	FakeAIMode        string // "echo" ou "script"
+	FakeAIScript      string // Caminho do roteiro JSON usado no modo "script"
+	FakeAILatency     time.Duration
+	FakeAIFailureRate float64 // Probabilidade (0 a 1) de uma resposta falhar
+
 	// Azure OpenAI Configuration
-	AzureOpenAIKey         string
+	AzureOpenAIKeys        []string
 	AzureOpenAIEndpoint    string
 	AzureOpenAIModel       string
 	AzureOpenAIMaxTokens   int
@@ -58,13 +130,17 @@ func LoadConfig() *Config {
 	// Obtém o endereço do servidor (padrão: "localhost:8080")
 	serverAddr := getEnvWithDefault("SERVER_ADDR", "localhost:8080")
 
+	// Obtém o intervalo de verificação dos agendamentos (padrão: 30 segundos)
+	scheduleCheckSeconds := getEnvAsInt("SCHEDULE_CHECK_INTERVAL_SECONDS", 30)
+	scheduleCheckInterval := time.Duration(scheduleCheckSeconds) * time.Second
+
 	// Azure OpenAI configuration
 	maxTokens := getEnvAsInt("AZURE_OPENAI_MAX_TOKENS", 4096)
 	temperature := getEnvAsFloat("AZURE_OPENAI_TEMPERATURE", 1.0)
 
 	return &Config{
 		TelegramToken:    os.Getenv("TELEGRAM_BOT_TOKEN"),
-		GeminiApiKey:     os.Getenv("GEMINI_API_KEY"),
+		GeminiApiKeys:    getEnvAsList("GEMINI_API_KEYS", os.Getenv("GEMINI_API_KEY")),
 		WebAppURL:        os.Getenv("WEBAPP_URL"),
 		HTTPTimeout:      30 * time.Second,
 		ServerAddr:       serverAddr,
@@ -73,9 +149,58 @@ func LoadConfig() *Config {
 		MessageRetention: messageRetention,
 		CleanupInterval:  cleanupInterval,
 
+		// Servidor da Bot API (padrão: servidor oficial do Telegram)
+		TelegramAPIURL:   strings.TrimRight(getEnvWithDefault("TELEGRAM_API_URL", "https://api.telegram.org"), "/"),
+		TelegramAPILocal: getEnvAsBool("TELEGRAM_API_LOCAL", false),
+
+		// Recebimento de atualizações (padrão: long polling)
+		TelegramMode:          strings.ToLower(getEnvWithDefault("TELEGRAM_MODE", "polling")),
+		TelegramWebhookURL:    strings.TrimRight(os.Getenv("TELEGRAM_WEBHOOK_URL"), "/"),
+		TelegramWebhookPath:   getEnvWithDefault("TELEGRAM_WEBHOOK_PATH", "/telegram/webhook"),
+		TelegramWebhookSecret: os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+
+		// Configurações de agendamentos
+		DefaultTimezone:       getEnvWithDefault("DEFAULT_TIMEZONE", "America/Sao_Paulo"),
+		ScheduleCheckInterval: scheduleCheckInterval,
+
 		// Seleção do serviço de IA (padrão: google)
 		AIService: strings.ToLower(getEnvWithDefault("AI_SERVICE", "google")),
 
+		// Entrega das respostas (padrão: prévia com botão para o Mini App)
+		DefaultDeliveryMode:   strings.ToLower(getEnvWithDefault("DEFAULT_DELIVERY_MODE", "preview")),
+		DeliveryAutoMaxLength: getEnvAsInt("DELIVERY_AUTO_MAX_LENGTH", 4096),
+
+		// Grupos (padrão: histórico individual, responde a menções e a respostas ao bot)
+		GroupConversationMode: strings.ToLower(getEnvWithDefault("GROUP_CONVERSATION_MODE", "user")),
+		GroupTriggers:         getEnvAsList("GROUP_TRIGGERS", "mention,reply"),
+		GroupCommandPrefix:    getEnvWithDefault("GROUP_COMMAND_PREFIX", "!ai"),
+		GroupKeywords:         getEnvAsList("GROUP_KEYWORDS", ""),
+
+		// Envios ao Telegram (padrão: 30 mensagens por segundo, 3 novas tentativas)
+		TelegramRateLimit:  getEnvAsInt("TELEGRAM_RATE_LIMIT", 30),
+		TelegramMaxRetries: getEnvAsInt("TELEGRAM_MAX_RETRIES", 3),
+
+		// Broadcasts (padrão: 10 mensagens por segundo)
+		BroadcastRateLimit: getEnvAsInt("BROADCAST_RATE_LIMIT", 10),
+
+		MaxConcurrentAI: getEnvAsInt("MAX_CONCURRENT_AI", 4),
+		JobMaxAttempts:  getEnvAsInt("JOB_MAX_ATTEMPTS", 3),
+
+		CallbackTTL: time.Duration(getEnvAsInt("CALLBACK_TTL_HOURS", 48)) * time.Hour,
+
+		// Modo inline (padrão: 700 ms de debounce, cache de 60 minutos)
+		InlineDebounce: time.Duration(getEnvAsInt("INLINE_DEBOUNCE_MS", 700)) * time.Millisecond,
+		InlineCacheTTL: time.Duration(getEnvAsInt("INLINE_CACHE_TTL_MINUTES", 60)) * time.Minute,
+
+		// Pool de chaves (padrão: round robin, 60 segundos de cooldown)
+		KeyPoolStrategy: strings.ToLower(getEnvWithDefault("KEY_POOL_STRATEGY", "round_robin")),
+		KeyCooldown:     time.Duration(getEnvAsInt("KEY_COOLDOWN_SECONDS", 60)) * time.Second,
+
+		AdminUserIDs:  getEnvAsInt64List("ADMIN_USER_IDS"),
+		AdminAPIToken: os.Getenv("ADMIN_API_TOKEN"),
+
+		AccessMode: strings.ToLower(getEnvWithDefault("ACCESS_MODE", "open")),
+
 		// Configurações do Gemini
 		GeminiModel:           getEnvWithDefault("GEMINI_MODEL", "gemini-2.5-pro-exp-03-25"),
 		GeminiTemperature:     getEnvAsFloat("GEMINI_TEMPERATURE", 1.0),
@@ -83,8 +208,14 @@ func LoadConfig() *Config {
 		GeminiTopP:            getEnvAsFloat("GEMINI_TOP_P", 0.95),
 		GeminiMaxOutputTokens: getEnvAsInt("GEMINI_MAX_OUTPUT_TOKENS", 65536),
 
+		// Configurações do serviço fake
+		FakeAIMode:        strings.ToLower(getEnvWithDefault("FAKE_AI_MODE", "echo")),
+		FakeAIScript:      getEnvWithDefault("FAKE_AI_SCRIPT", "fake_ai_script.json"),
+		FakeAILatency:     time.Duration(getEnvAsInt("FAKE_AI_LATENCY_MS", 0)) * time.Millisecond,
+		FakeAIFailureRate: getEnvAsFloat("FAKE_AI_FAILURE_RATE", 0),
+
 		// Azure OpenAI settings
-		AzureOpenAIKey:         os.Getenv("AZURE_OPENAI_API_KEY"),
+		AzureOpenAIKeys:        getEnvAsList("AZURE_OPENAI_API_KEYS", os.Getenv("AZURE_OPENAI_API_KEY")),
 		AzureOpenAIEndpoint:    getEnvWithDefault("AZURE_OPENAI_ENDPOINT", "https://models.inference.ai.azure.com"),
 		AzureOpenAIModel:       getEnvWithDefault("AZURE_OPENAI_MODEL", "gpt-4"),
 		AzureOpenAIMaxTokens:   maxTokens,
@@ -126,6 +257,56 @@ func getEnvAsFloat(name string, defaultValue float64) float64 {
 	return value
 }
 
+// getEnvAsBool obtém uma variável de ambiente booleana ("true", "1", "false"...),
+// usando o valor padrão caso a variável não exista ou seja inválida
+func getEnvAsBool(name string, defaultValue bool) bool {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Aviso: valor inválido para %s, usando padrão (%t): %v", name, defaultValue, err)
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsInt64List lê uma lista de inteiros separados por vírgula, ignorando valores inválidos
+func getEnvAsInt64List(name string) []int64 {
+	var values []int64
+	for _, value := range getEnvAsList(name, "") {
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Printf("Valor inválido em %s: %s", name, value)
+			continue
+		}
+		values = append(values, number)
+	}
+
+	return values
+}
+
+// getEnvAsList obtém uma variável de ambiente com valores separados por vírgula,
+// usando fallback (também separado por vírgula) caso a variável não exista
+func getEnvAsList(name string, fallback string) []string {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		valueStr = fallback
+	}
+
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 // getEnvWithDefault obtém uma variável de ambiente ou retorna o valor padrão
 // caso a variável não exista
 func getEnvWithDefault(name string, defaultValue string) string {
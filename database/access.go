@@ -0,0 +1,229 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// GetBotSetting retorna uma configuração global alterada pelos administradores.
+// Retorna uma string vazia se ela nunca foi definida
+func (d *Database) GetBotSetting(key string) (string, error) {
+	var value string
+	err := d.db.QueryRow("SELECT value FROM bot_settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar configuração %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetBotSetting grava uma configuração global
+func (d *Database) SetBotSetting(key, value string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO bot_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`,
+		key, value,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar configuração %s: %w", key, err)
+	}
+	return nil
+}
+
+// HasAccessEntry indica se o ID está na lista do tipo informado
+func (d *Database) HasAccessEntry(kind string, id int64) (bool, error) {
+	var count int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM access_list WHERE kind = ? AND id = ?", kind, id).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("erro ao consultar lista de acesso: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AddAccessEntry inclui um ID na lista de acesso, substituindo a entrada anterior
+func (d *Database) AddAccessEntry(entry models.AccessEntry) error {
+	_, err := d.db.Exec(
+		"INSERT OR REPLACE INTO access_list (kind, id, note, added_by, created_at) VALUES (?, ?, ?, ?, ?)",
+		entry.Kind, entry.ID, entry.Note, entry.AddedBy, dbTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao incluir na lista de acesso: %w", err)
+	}
+	return nil
+}
+
+// RemoveAccessEntry retira um ID da lista de acesso. Retorna false se ele não estava na lista
+func (d *Database) RemoveAccessEntry(kind string, id int64) (bool, error) {
+	result, err := d.db.Exec("DELETE FROM access_list WHERE kind = ? AND id = ?", kind, id)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover da lista de acesso: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover da lista de acesso: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ListAccessEntries lista as entradas de um tipo, das mais recentes para as mais antigas
+func (d *Database) ListAccessEntries(kind string) ([]models.AccessEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT kind, id, note, added_by, created_at
+		FROM access_list
+		WHERE kind = ?
+		ORDER BY created_at DESC`,
+		kind,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar acesso: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AccessEntry{}
+	for rows.Next() {
+		var entry models.AccessEntry
+		var note sql.NullString
+		var addedBy sql.NullInt64
+		if err := rows.Scan(&entry.Kind, &entry.ID, &note, &addedBy, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler lista de acesso: %w", err)
+		}
+		entry.Note = note.String
+		entry.AddedBy = addedBy.Int64
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CreateInvite cria um código de convite. maxUses zero permite usos ilimitados
+func (d *Database) CreateInvite(code string, maxUses int, createdBy int64) error {
+	_, err := d.db.Exec(
+		"INSERT INTO invite_codes (code, max_uses, created_by, created_at) VALUES (?, ?, ?, ?)",
+		code, maxUses, createdBy, dbTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao criar convite: %w", err)
+	}
+	return nil
+}
+
+// RedeemInvite consome um uso do convite e libera o usuário. Retorna false se
+// o convite não existir, estiver revogado ou já tiver esgotado os usos
+func (d *Database) RedeemInvite(code string, userID int64) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE invite_codes SET uses = uses + 1
+		WHERE code = ? AND revoked = false AND (max_uses = 0 OR uses < max_uses)`,
+		code,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao resgatar convite: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT OR REPLACE INTO access_list (kind, id, note, created_at) VALUES (?, ?, ?, ?)",
+		models.AccessUser, userID, "convite "+code, dbTime(time.Now()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao liberar usuário do convite: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return true, nil
+}
+
+// RevokeInvite impede novos resgates do convite. Retorna false se ele não existir
+func (d *Database) RevokeInvite(code string) (bool, error) {
+	result, err := d.db.Exec("UPDATE invite_codes SET revoked = true WHERE code = ?", code)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar convite: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar convite: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ListInvites lista os convites, dos mais recentes para os mais antigos
+func (d *Database) ListInvites() ([]models.InviteCode, error) {
+	rows, err := d.db.Query(`
+		SELECT code, max_uses, uses, created_by, revoked, created_at
+		FROM invite_codes
+		ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar convites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := []models.InviteCode{}
+	for rows.Next() {
+		var invite models.InviteCode
+		var createdBy sql.NullInt64
+		err := rows.Scan(&invite.Code, &invite.MaxUses, &invite.Uses, &createdBy, &invite.Revoked, &invite.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler convite: %w", err)
+		}
+		invite.CreatedBy = createdBy.Int64
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// RecordAudit grava uma entrada no log de auditoria de acesso
+func (d *Database) RecordAudit(entry models.AuditEntry) error {
+	_, err := d.db.Exec(
+		"INSERT INTO access_audit (actor_id, source, action, target_id, detail, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		entry.ActorID, entry.Source, entry.Action, entry.TargetID, entry.Detail, dbTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar auditoria: %w", err)
+	}
+	return nil
+}
+
+// ListAudit lista as entradas mais recentes do log de auditoria
+func (d *Database) ListAudit(limit int) ([]models.AuditEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT id, actor_id, source, action, target_id, detail, created_at
+		FROM access_audit
+		ORDER BY id DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar auditoria: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorID, targetID sql.NullInt64
+		var detail sql.NullString
+		err := rows.Scan(&entry.ID, &actorID, &entry.Source, &entry.Action, &targetID, &detail, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler auditoria: %w", err)
+		}
+		entry.ActorID = actorID.Int64
+		entry.TargetID = targetID.Int64
+		entry.Detail = detail.String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,283 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// botUsersQuery lista os usuários que já conversaram com o bot: donos de
+// históricos e autores de mensagens em conversas compartilhadas de grupos
+const botUsersQuery = `
+	SELECT user_id FROM chat_history WHERE user_id > 0
+	UNION
+	SELECT author_id FROM chat_messages WHERE author_id IS NOT NULL`
+
+// broadcastRecipientsQuery são os usuários do bot que não foram marcados como
+// inativos nem banidos
+const broadcastRecipientsQuery = `
+	SELECT user_id FROM (` + botUsersQuery + `)
+	WHERE user_id NOT IN (SELECT user_id FROM inactive_users)
+	AND user_id NOT IN (SELECT id FROM access_list WHERE kind = 'banned')`
+
+// broadcastColumns inclui os contadores calculados a partir das entregas
+const broadcastColumns = `
+	b.id, b.text, b.button_text, b.button_url, b.created_by, b.status, b.created_at, b.finished_at,
+	COUNT(d.user_id),
+	COALESCE(SUM(CASE WHEN d.status = 'delivered' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN d.status = 'blocked' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN d.status = 'failed' THEN 1 ELSE 0 END), 0)`
+
+// CreateBroadcast registra um broadcast ainda sem destinatários, no estado draft
+func (d *Database) CreateBroadcast(broadcast *models.Broadcast) (int64, error) {
+	result, err := d.db.Exec(
+		"INSERT INTO broadcasts (text, button_text, button_url, created_by, status, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		broadcast.Text, broadcast.ButtonText, broadcast.ButtonURL, broadcast.CreatedBy, models.BroadcastDraft, dbTime(time.Now()),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar broadcast: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// CountBroadcastRecipients conta os usuários que receberiam um broadcast criado agora
+func (d *Database) CountBroadcastRecipients() (int, error) {
+	var count int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM (" + broadcastRecipientsQuery + ")").Scan(&count); err != nil {
+		return 0, fmt.Errorf("erro ao contar destinatários: %w", err)
+	}
+	return count, nil
+}
+
+// QueueBroadcast libera um broadcast em draft para envio, registrando uma
+// entrega pendente para cada destinatário atual. Retorna false se o broadcast
+// não existir ou já tiver saído do draft
+func (d *Database) QueueBroadcast(id int64) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE broadcasts SET status = ? WHERE id = ? AND status = ?", models.BroadcastPending, id, models.BroadcastDraft)
+	if err != nil {
+		return false, fmt.Errorf("erro ao liberar broadcast %d: %w", id, err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO broadcast_deliveries (broadcast_id, user_id, status, updated_at) SELECT ?, user_id, ?, ? FROM ("+broadcastRecipientsQuery+")",
+		id, models.DeliveryPending, dbTime(time.Now()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao registrar destinatários do broadcast %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return true, nil
+}
+
+// CancelBroadcast interrompe um broadcast que ainda não terminou. As entregas
+// pendentes permanecem registradas como pending
+func (d *Database) CancelBroadcast(id int64) (bool, error) {
+	result, err := d.db.Exec(
+		"UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ? AND status IN (?, ?, ?)",
+		models.BroadcastCancelled, dbTime(time.Now()), id,
+		models.BroadcastDraft, models.BroadcastPending, models.BroadcastRunning,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao cancelar broadcast %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao cancelar broadcast %d: %w", id, err)
+	}
+	return affected > 0, nil
+}
+
+// SetBroadcastStatus altera o estado do broadcast. Os estados finais registram o horário de término
+func (d *Database) SetBroadcastStatus(id int64, status string) error {
+	var finishedAt interface{}
+	if status == models.BroadcastDone || status == models.BroadcastCancelled {
+		finishedAt = dbTime(time.Now())
+	}
+
+	_, err := d.db.Exec("UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ?", status, finishedAt, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar broadcast %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetBroadcast busca um broadcast com os contadores de entrega. Retorna nil se ele não existir
+func (d *Database) GetBroadcast(id int64) (*models.Broadcast, error) {
+	rows, err := d.db.Query(`
+		SELECT `+broadcastColumns+`
+		FROM broadcasts b
+		LEFT JOIN broadcast_deliveries d ON d.broadcast_id = b.id
+		WHERE b.id = ?
+		GROUP BY b.id`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar broadcast %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	broadcasts, err := scanBroadcasts(rows)
+	if err != nil || len(broadcasts) == 0 {
+		return nil, err
+	}
+	return &broadcasts[0], nil
+}
+
+// ListBroadcasts lista os broadcasts mais recentes com os contadores de entrega
+func (d *Database) ListBroadcasts(limit int) ([]models.Broadcast, error) {
+	rows, err := d.db.Query(`
+		SELECT `+broadcastColumns+`
+		FROM broadcasts b
+		LEFT JOIN broadcast_deliveries d ON d.broadcast_id = b.id
+		GROUP BY b.id
+		ORDER BY b.id DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBroadcasts(rows)
+}
+
+// NextBroadcast retorna o broadcast mais antigo aguardando ou em envio, ou nil se não houver
+func (d *Database) NextBroadcast() (*models.Broadcast, error) {
+	var id int64
+	err := d.db.QueryRow(
+		"SELECT id FROM broadcasts WHERE status IN (?, ?) ORDER BY id ASC LIMIT 1",
+		models.BroadcastPending, models.BroadcastRunning,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar próximo broadcast: %w", err)
+	}
+	return d.GetBroadcast(id)
+}
+
+// PendingDeliveries retorna até limit destinatários que ainda não receberam o broadcast
+func (d *Database) PendingDeliveries(broadcastID int64, limit int) ([]int64, error) {
+	rows, err := d.db.Query(
+		"SELECT user_id FROM broadcast_deliveries WHERE broadcast_id = ? AND status = ? ORDER BY user_id LIMIT ?",
+		broadcastID, models.DeliveryPending, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar entregas pendentes: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("erro ao ler entrega pendente: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// RecordDelivery registra o resultado do envio do broadcast a um usuário
+func (d *Database) RecordDelivery(broadcastID, userID int64, status, deliveryError string) error {
+	_, err := d.db.Exec(
+		"UPDATE broadcast_deliveries SET status = ?, error = ?, updated_at = ? WHERE broadcast_id = ? AND user_id = ?",
+		status, deliveryError, dbTime(time.Now()), broadcastID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar entrega do broadcast %d para %d: %w", broadcastID, userID, err)
+	}
+	return nil
+}
+
+// ListDeliveries lista as entregas do broadcast. Com status vazio, lista todas
+func (d *Database) ListDeliveries(broadcastID int64, status string) ([]models.BroadcastDelivery, error) {
+	query := "SELECT user_id, status, error, updated_at FROM broadcast_deliveries WHERE broadcast_id = ?"
+	args := []interface{}{broadcastID}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY user_id"
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar entregas do broadcast %d: %w", broadcastID, err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.BroadcastDelivery{}
+	for rows.Next() {
+		var delivery models.BroadcastDelivery
+		var deliveryError sql.NullString
+		if err := rows.Scan(&delivery.UserID, &delivery.Status, &deliveryError, &delivery.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler entrega: %w", err)
+		}
+		delivery.Error = deliveryError.String
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkUserInactive exclui o usuário dos próximos broadcasts, por exemplo após ele bloquear o bot
+func (d *Database) MarkUserInactive(userID int64, reason string) error {
+	_, err := d.db.Exec(
+		"INSERT OR REPLACE INTO inactive_users (user_id, reason, created_at) VALUES (?, ?, ?)",
+		userID, reason, dbTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao marcar usuário %d como inativo: %w", userID, err)
+	}
+	return nil
+}
+
+// ReactivateUser volta a incluir o usuário nos broadcasts quando ele fala com o bot de novo
+func (d *Database) ReactivateUser(userID int64) error {
+	if _, err := d.db.Exec("DELETE FROM inactive_users WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("erro ao reativar usuário %d: %w", userID, err)
+	}
+	return nil
+}
+
+func scanBroadcasts(rows *sql.Rows) ([]models.Broadcast, error) {
+	broadcasts := []models.Broadcast{}
+	for rows.Next() {
+		var broadcast models.Broadcast
+		var buttonText, buttonURL sql.NullString
+		var createdBy sql.NullInt64
+		var finishedAt sql.NullTime
+		err := rows.Scan(
+			&broadcast.ID, &broadcast.Text, &buttonText, &buttonURL, &createdBy, &broadcast.Status,
+			&broadcast.CreatedAt, &finishedAt,
+			&broadcast.Total, &broadcast.Delivered, &broadcast.Blocked, &broadcast.Failed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler broadcast: %w", err)
+		}
+		broadcast.ButtonText = buttonText.String
+		broadcast.ButtonURL = buttonURL.String
+		broadcast.CreatedBy = createdBy.Int64
+		if finishedAt.Valid {
+			broadcast.FinishedAt = &finishedAt.Time
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+	return broadcasts, rows.Err()
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"testing"
+
+	"bot-ai/models"
+)
+
+func TestBroadcastRecipientsSkipBannedUsers(t *testing.T) {
+	db, err := NewDatabase(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, userID := range []int64{1, 2, 3} {
+		if _, err := db.CreateNewChat(userID, models.Topic{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AddAccessEntry(models.AccessEntry{Kind: models.AccessBanned, ID: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := db.CountBroadcastRecipients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("%d destinatários, esperado 2", count)
+	}
+
+	id, err := db.CreateBroadcast(&models.Broadcast{Text: "aviso"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := db.QueueBroadcast(id); err != nil || !ok {
+		t.Fatalf("QueueBroadcast = %v, %v", ok, err)
+	}
+	pending, err := db.PendingDeliveries(id, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range pending {
+		if userID == 2 {
+			t.Errorf("usuário banido entre as entregas: %v", pending)
+		}
+	}
+}
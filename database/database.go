@@ -23,6 +23,11 @@ func NewDatabase(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("erro ao abrir banco de dados: %w", err)
 	}
 
+	// Um banco em memória existe apenas na conexão que o criou (usado em testes)
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %w", err)
 	}
@@ -31,6 +36,10 @@ func NewDatabase(dbPath string) (*Database, error) {
 		return nil, err
 	}
 
+	if err := migrateTables(db); err != nil {
+		return nil, err
+	}
+
 	return &Database{db: db}, nil
 }
 
@@ -40,6 +49,9 @@ func createTables(db *sql.DB) error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			hash TEXT UNIQUE,
 			content TEXT,
+			provider TEXT,
+			model TEXT,
+			persona TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE TABLE IF NOT EXISTS chat_history (
@@ -47,6 +59,8 @@ func createTables(db *sql.DB) error {
 			user_id INTEGER NOT NULL,
 			is_active BOOLEAN DEFAULT true,
 			preview_message TEXT,
+			topic_chat_id INTEGER NOT NULL DEFAULT 0,
+			thread_id INTEGER NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
@@ -56,14 +70,161 @@ func createTables(db *sql.DB) error {
 			role TEXT NOT NULL,
 			content TEXT NOT NULL,
 			hash TEXT,
+			author_id INTEGER,
+			author_name TEXT,
+			superseded BOOLEAN NOT NULL DEFAULT false,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (chat_history_id) REFERENCES chat_history(id),
 			FOREIGN KEY (hash) REFERENCES messages(hash)
 		)`,
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			conversation_id INTEGER NOT NULL DEFAULT 0,
+			thread_id INTEGER NOT NULL DEFAULT 0,
+			replace_turn_id INTEGER NOT NULL DEFAULT 0,
+			message TEXT NOT NULL,
+			question TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status, updated_at)`,
+		`CREATE TABLE IF NOT EXISTS group_settings (
+			chat_id INTEGER PRIMARY KEY,
+			conversation_mode TEXT,
+			triggers TEXT,
+			command_prefix TEXT,
+			keywords TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS telegram_messages (
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			hash TEXT NOT NULL,
+			question_id INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chat_id, message_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS question_turns (
+			chat_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			chat_message_id INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chat_id, message_id),
+			FOREIGN KEY (chat_message_id) REFERENCES chat_messages(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS bot_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS access_list (
+			kind TEXT NOT NULL,
+			id INTEGER NOT NULL,
+			note TEXT,
+			added_by INTEGER,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (kind, id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS invite_codes (
+			code TEXT PRIMARY KEY,
+			max_uses INTEGER NOT NULL DEFAULT 1,
+			uses INTEGER NOT NULL DEFAULT 0,
+			created_by INTEGER,
+			revoked BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS access_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor_id INTEGER,
+			source TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id INTEGER,
+			detail TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS broadcasts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			button_text TEXT,
+			button_url TEXT,
+			created_by INTEGER,
+			status TEXT NOT NULL DEFAULT 'draft',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+			broadcast_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			error TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (broadcast_id, user_id),
+			FOREIGN KEY (broadcast_id) REFERENCES broadcasts(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS inactive_users (
+			user_id INTEGER PRIMARY KEY,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS answer_feedback (
+			hash TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			rating INTEGER NOT NULL,
+			comment TEXT,
+			prompt_chat_id INTEGER NOT NULL DEFAULT 0,
+			prompt_message_id INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hash, user_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_answer_feedback_prompt ON answer_feedback(prompt_chat_id, prompt_message_id)`,
+		`CREATE TABLE IF NOT EXISTS inline_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			result_id TEXT NOT NULL,
+			query TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS schedules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			user_name TEXT,
+			chat_id INTEGER NOT NULL,
+			chat_type TEXT NOT NULL,
+			thread_id INTEGER NOT NULL DEFAULT 0,
+			kind TEXT NOT NULL,
+			time_of_day TEXT,
+			timezone TEXT NOT NULL,
+			locale TEXT,
+			prompt TEXT NOT NULL,
+			next_run_at TIMESTAMP NOT NULL,
+			last_run_at TIMESTAMP,
+			is_active BOOLEAN DEFAULT true,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS user_settings (
+			user_id INTEGER PRIMARY KEY,
+			timezone TEXT,
+			language TEXT,
+			delivery_mode TEXT,
+			answer_length TEXT,
+			temperature TEXT,
+			persona TEXT,
+			auto_new_chat_hours INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
 		`CREATE INDEX IF NOT EXISTS idx_chat_history_user_id ON chat_history(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_chat_history_is_active ON chat_history(is_active)`,
 		`CREATE INDEX IF NOT EXISTS idx_chat_messages_chat_history_id ON chat_messages(chat_history_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_chat_messages_hash ON chat_messages(hash)`,
+		`CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules(is_active, next_run_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_broadcasts_status ON broadcasts(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_broadcast_deliveries_status ON broadcast_deliveries(broadcast_id, status)`,
 	}
 
 	for _, query := range queries {
@@ -75,15 +236,90 @@ func createTables(db *sql.DB) error {
 	return nil
 }
 
+// migrateTables adiciona colunas novas a tabelas criadas por versões anteriores
+func migrateTables(db *sql.DB) error {
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"chat_history", "title", "TEXT"},
+		{"user_settings", "language", "TEXT"},
+		{"schedules", "locale", "TEXT"},
+		{"user_settings", "delivery_mode", "TEXT"},
+		{"chat_messages", "author_id", "INTEGER"},
+		{"chat_messages", "author_name", "TEXT"},
+		{"jobs", "conversation_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"chat_history", "topic_chat_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"chat_history", "thread_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"jobs", "thread_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"schedules", "thread_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"chat_messages", "superseded", "BOOLEAN NOT NULL DEFAULT false"},
+		{"telegram_messages", "question_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"user_settings", "answer_length", "TEXT"},
+		{"user_settings", "temperature", "TEXT"},
+		{"user_settings", "persona", "TEXT"},
+		{"user_settings", "auto_new_chat_hours", "INTEGER NOT NULL DEFAULT 0"},
+		{"messages", "provider", "TEXT"},
+		{"messages", "model", "TEXT"},
+		{"messages", "persona", "TEXT"},
+		{"jobs", "replace_turn_id", "INTEGER NOT NULL DEFAULT 0"},
+	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumnIfMissing executa ALTER TABLE apenas se a coluna ainda não existir
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("erro ao ler estrutura da tabela %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("erro ao ler coluna da tabela %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao ler estrutura da tabela %s: %w", table, err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("erro ao adicionar coluna %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
+
 // SaveMessage salva uma mensagem normal
 func (d *Database) SaveMessage(content string) (string, error) {
+	return d.SaveAnswer(content, models.AnswerSource{})
+}
+
+// SaveAnswer salva uma resposta da IA junto com o provedor, o modelo e a
+// persona que a geraram, usados depois para agregar as avaliações
+func (d *Database) SaveAnswer(content string, source models.AnswerSource) (string, error) {
 	hasher := sha256.New()
 	hasher.Write([]byte(content + time.Now().String()))
 	hash := hex.EncodeToString(hasher.Sum(nil))[:8]
 
 	_, err := d.db.Exec(
-		"INSERT INTO messages (hash, content) VALUES (?, ?)",
-		hash, content,
+		"INSERT INTO messages (hash, content, provider, model, persona) VALUES (?, ?, ?, ?, ?)",
+		hash, content, source.Provider, source.Model, source.Persona,
 	)
 	if err != nil {
 		return "", fmt.Errorf("erro ao salvar mensagem: %w", err)
@@ -105,15 +341,15 @@ func (d *Database) GetMessage(hash string) (*models.Message, error) {
 	return &msg, nil
 }
 
-// GetActiveChat recupera o chat ativo de um usuário
-func (d *Database) GetActiveChat(userID int64) (*models.ChatHistory, error) {
+// GetActiveChat recupera o chat ativo de um usuário no tópico informado
+func (d *Database) GetActiveChat(userID int64, topic models.Topic) (*models.ChatHistory, error) {
 	var chat models.ChatHistory
 	err := d.db.QueryRow(`
 		SELECT id, user_id, is_active, created_at, updated_at 
 		FROM chat_history 
-		WHERE user_id = ? AND is_active = true
+		WHERE user_id = ? AND topic_chat_id = ? AND thread_id = ? AND is_active = true
 		ORDER BY created_at DESC LIMIT 1`,
-		userID,
+		userID, topic.ChatID, topic.ThreadID,
 	).Scan(&chat.ID, &chat.UserID, &chat.IsActive, &chat.CreatedAt, &chat.UpdatedAt)
 
 	if err == sql.ErrNoRows {
@@ -125,8 +361,9 @@ func (d *Database) GetActiveChat(userID int64) (*models.ChatHistory, error) {
 	return &chat, nil
 }
 
-// CreateNewChat cria um novo chat para o usuário e desativa os anteriores
-func (d *Database) CreateNewChat(userID int64) (*models.ChatHistory, error) {
+// CreateNewChat cria um novo chat para o usuário no tópico informado e desativa
+// os anteriores do mesmo tópico
+func (d *Database) CreateNewChat(userID int64, topic models.Topic) (*models.ChatHistory, error) {
 	tx, err := d.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
@@ -134,16 +371,19 @@ func (d *Database) CreateNewChat(userID int64) (*models.ChatHistory, error) {
 	defer tx.Rollback()
 
 	// Desativa chats anteriores
-	_, err = tx.Exec("UPDATE chat_history SET is_active = false WHERE user_id = ?", userID)
+	_, err = tx.Exec(
+		"UPDATE chat_history SET is_active = false WHERE user_id = ? AND topic_chat_id = ? AND thread_id = ?",
+		userID, topic.ChatID, topic.ThreadID,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao desativar chats anteriores: %w", err)
 	}
 
 	// Cria novo chat
 	result, err := tx.Exec(`
-		INSERT INTO chat_history (user_id, is_active, created_at, updated_at) 
-		VALUES (?, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
-		userID,
+		INSERT INTO chat_history (user_id, topic_chat_id, thread_id, is_active, created_at, updated_at) 
+		VALUES (?, ?, ?, true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		userID, topic.ChatID, topic.ThreadID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao criar novo chat: %w", err)
@@ -169,6 +409,17 @@ func (d *Database) CreateNewChat(userID int64) (*models.ChatHistory, error) {
 
 // AddMessageToChat adiciona uma mensagem ao histórico do chat
 func (d *Database) AddMessageToChat(chatID int64, role, content string) error {
+	return d.AddAuthoredMessageToChat(chatID, role, content, 0, "")
+}
+
+// AddAuthoredMessageToChat adiciona uma mensagem registrando quem a enviou,
+// usado nas conversas compartilhadas de grupo. authorID 0 indica autor não registrado
+func (d *Database) AddAuthoredMessageToChat(chatID int64, role, content string, authorID int64, authorName string) error {
+	var author interface{}
+	if authorID != 0 {
+		author = authorID
+	}
+
 	tx, err := d.db.Begin()
 	if err != nil {
 		return fmt.Errorf("erro ao iniciar transação: %w", err)
@@ -193,9 +444,9 @@ func (d *Database) AddMessageToChat(chatID int64, role, content string) error {
 
 	// Salva na tabela chat_messages
 	_, err = tx.Exec(`
-		INSERT INTO chat_messages (chat_history_id, role, content, hash) 
-		VALUES (?, ?, ?, ?)`,
-		chatID, role, content, hash,
+		INSERT INTO chat_messages (chat_history_id, role, content, hash, author_id, author_name) 
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		chatID, role, content, hash, author, authorName,
 	)
 	if err != nil {
 		return fmt.Errorf("erro ao adicionar mensagem ao chat: %w", err)
@@ -203,10 +454,7 @@ func (d *Database) AddMessageToChat(chatID int64, role, content string) error {
 
 	// Atualiza o preview e timestamp do chat se for mensagem do usuário
 	if role == "user" {
-		preview := content
-		if len(preview) > 50 {
-			preview = preview[:47] + "..."
-		}
+		preview := TruncateText(content, 50)
 		_, err = tx.Exec(
 			"UPDATE chat_history SET preview_message = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 			preview, chatID,
@@ -262,7 +510,7 @@ func (d *Database) AddMessageToChatWithExistingHash(chatID int64, role, content,
 // GetChatMessages recupera todas as mensagens de um chat
 func (d *Database) GetChatMessages(chatID int64) ([]models.ChatMessage, error) {
 	rows, err := d.db.Query(`
-		SELECT id, chat_history_id, role, content, created_at 
+		SELECT id, chat_history_id, role, content, author_id, author_name, superseded, created_at 
 		FROM chat_messages 
 		WHERE chat_history_id = ? 
 		ORDER BY created_at ASC`,
@@ -276,20 +524,89 @@ func (d *Database) GetChatMessages(chatID int64) ([]models.ChatMessage, error) {
 	var messages []models.ChatMessage
 	for rows.Next() {
 		var msg models.ChatMessage
-		err := rows.Scan(&msg.ID, &msg.ChatHistoryID, &msg.Role, &msg.Content, &msg.CreatedAt)
+		var authorID sql.NullInt64
+		var authorName sql.NullString
+		err := rows.Scan(&msg.ID, &msg.ChatHistoryID, &msg.Role, &msg.Content, &authorID, &authorName, &msg.Superseded, &msg.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao ler mensagem do chat: %w", err)
 		}
+		msg.AuthorID = authorID.Int64
+		msg.AuthorName = authorName.String
 		messages = append(messages, msg)
 	}
 
 	return messages, nil
 }
 
+// GetChatTurn recupera uma mensagem do histórico pelo ID. Retorna nil se não existir
+func (d *Database) GetChatTurn(turnID int64) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	err := d.db.QueryRow(
+		"SELECT id, chat_history_id, role, content, superseded, created_at FROM chat_messages WHERE id = ?",
+		turnID,
+	).Scan(&msg.ID, &msg.ChatHistoryID, &msg.Role, &msg.Content, &msg.Superseded, &msg.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mensagem do chat: %w", err)
+	}
+	return &msg, nil
+}
+
+// IsLatestQuestion indica se o turno é a pergunta mais recente do seu chat
+func (d *Database) IsLatestQuestion(turnID int64) (bool, error) {
+	var later int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM chat_messages cm
+		JOIN chat_messages t ON t.chat_history_id = cm.chat_history_id
+		WHERE t.id = ? AND cm.role = 'user' AND cm.id > t.id`,
+		turnID,
+	).Scan(&later)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar pergunta mais recente: %w", err)
+	}
+	return later == 0, nil
+}
+
+// ReplaceQuestion reescreve uma pergunta do histórico e marca como substituídas
+// as respostas dadas a ela, que continuam salvas mas não vão mais ao modelo
+func (d *Database) ReplaceQuestion(turnID int64, content string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chatID int64
+	if err := tx.QueryRow("SELECT chat_history_id FROM chat_messages WHERE id = ?", turnID).Scan(&chatID); err != nil {
+		return fmt.Errorf("erro ao buscar pergunta: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE chat_messages SET content = ? WHERE id = ?", content, turnID); err != nil {
+		return fmt.Errorf("erro ao reescrever pergunta: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE chat_messages SET superseded = true WHERE chat_history_id = ? AND role = 'assistant' AND id > ?",
+		chatID, turnID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao substituir respostas anteriores: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return nil
+}
+
 // ListUserChats lista todos os chats de um usuário
 func (d *Database) ListUserChats(userID int64) ([]models.ChatHistory, error) {
 	rows, err := d.db.Query(`
-		SELECT id, user_id, is_active, preview_message, created_at, updated_at 
+		SELECT id, user_id, is_active, preview_message, title, created_at, updated_at 
 		FROM chat_history 
 		WHERE user_id = ? 
 		ORDER BY updated_at DESC`,
@@ -303,8 +620,8 @@ func (d *Database) ListUserChats(userID int64) ([]models.ChatHistory, error) {
 	var chats []models.ChatHistory
 	for rows.Next() {
 		var chat models.ChatHistory
-		var previewMessage sql.NullString // Usar sql.NullString para tratar valores NULL
-		err := rows.Scan(&chat.ID, &chat.UserID, &chat.IsActive, &previewMessage, &chat.CreatedAt, &chat.UpdatedAt)
+		var previewMessage, title sql.NullString // Usar sql.NullString para tratar valores NULL
+		err := rows.Scan(&chat.ID, &chat.UserID, &chat.IsActive, &previewMessage, &title, &chat.CreatedAt, &chat.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao ler chat: %w", err)
 		}
@@ -314,6 +631,7 @@ func (d *Database) ListUserChats(userID int64) ([]models.ChatHistory, error) {
 		} else {
 			chat.PreviewMessage = ""
 		}
+		chat.Title = title.String
 		chats = append(chats, chat)
 	}
 
@@ -356,6 +674,16 @@ func (d *Database) ScheduleCleanup(cleanupInterval, retentionPeriod time.Duratio
 			if count > 0 {
 				log.Printf("Limpeza automática: removidas %d mensagens antigas", count)
 			}
+
+			jobs, err := d.CleanupFinishedJobs(retentionPeriod)
+			if err != nil {
+				log.Printf("Erro durante limpeza automática de jobs: %v", err)
+				continue
+			}
+
+			if jobs > 0 {
+				log.Printf("Limpeza automática: removidos %d jobs concluídos", jobs)
+			}
 		}
 	}()
 
@@ -406,15 +734,18 @@ func (d *Database) GetMessagesByUser(userID int64) ([]models.Message, error) {
 }
 
 // NewChat atualiza o status dos chats existentes do usuário
-func (d *Database) NewChat(userID int64) error {
+func (d *Database) NewChat(userID int64, topic models.Topic) error {
 	tx, err := d.db.Begin()
 	if err != nil {
 		return fmt.Errorf("erro ao iniciar transação: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Desativa todos os chats do usuário, mas mantém o preview_message
-	_, err = tx.Exec("UPDATE chat_history SET is_active = false WHERE user_id = ?", userID)
+	// Desativa os chats do usuário no tópico, mas mantém o preview_message
+	_, err = tx.Exec(
+		"UPDATE chat_history SET is_active = false WHERE user_id = ? AND topic_chat_id = ? AND thread_id = ?",
+		userID, topic.ChatID, topic.ThreadID,
+	)
 	if err != nil {
 		return fmt.Errorf("erro ao desativar chats anteriores: %w", err)
 	}
@@ -452,14 +783,15 @@ func (d *Database) GetChatMessagesByHash(hash string) ([]models.ChatMessage, err
 // GetChatByMessageHash busca um chat a partir do hash de uma mensagem
 func (d *Database) GetChatByMessageHash(hash string) (*models.ChatHistory, error) {
 	var chat models.ChatHistory
+	var previewMessage, title sql.NullString
 	err := d.db.QueryRow(`
-		SELECT ch.id, ch.user_id, ch.is_active, ch.preview_message, ch.created_at, ch.updated_at
+		SELECT ch.id, ch.user_id, ch.is_active, ch.preview_message, ch.title, ch.created_at, ch.updated_at
 		FROM chat_history ch
 		JOIN chat_messages cm ON cm.chat_history_id = ch.id
 		WHERE cm.hash = ?
 		LIMIT 1`,
 		hash,
-	).Scan(&chat.ID, &chat.UserID, &chat.IsActive, &chat.PreviewMessage, &chat.CreatedAt, &chat.UpdatedAt)
+	).Scan(&chat.ID, &chat.UserID, &chat.IsActive, &previewMessage, &title, &chat.CreatedAt, &chat.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -467,6 +799,55 @@ func (d *Database) GetChatByMessageHash(hash string) (*models.ChatHistory, error
 		}
 		return nil, fmt.Errorf("erro ao buscar chat: %w", err)
 	}
+	chat.PreviewMessage = previewMessage.String
+	chat.Title = title.String
+
+	return &chat, nil
+}
+
+// GetChat busca um chat pelo ID. Retorna nil se o chat não existir
+func (d *Database) GetChat(chatID int64) (*models.ChatHistory, error) {
+	var chat models.ChatHistory
+	var previewMessage, title sql.NullString
+	err := d.db.QueryRow(`
+		SELECT id, user_id, is_active, preview_message, title, created_at, updated_at
+		FROM chat_history
+		WHERE id = ?`,
+		chatID,
+	).Scan(&chat.ID, &chat.UserID, &chat.IsActive, &previewMessage, &title, &chat.CreatedAt, &chat.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chat: %w", err)
+	}
+	chat.PreviewMessage = previewMessage.String
+	chat.Title = title.String
 
 	return &chat, nil
 }
+
+// SetChatTitle define o título de um chat. Um título vazio remove o título atual
+func (d *Database) SetChatTitle(chatID int64, title string) error {
+	var value interface{}
+	if title != "" {
+		value = TruncateText(title, 100)
+	}
+
+	_, err := d.db.Exec("UPDATE chat_history SET title = ? WHERE id = ?", value, chatID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar título do chat: %w", err)
+	}
+	return nil
+}
+
+// TruncateText limita o texto a maxRunes caracteres, sem cortar caracteres
+// multibyte ao meio, adicionando reticências quando o texto é cortado
+func TruncateText(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes-3]) + "..."
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// SaveFeedback registra a avaliação do usuário para a resposta. Uma nova
+// avaliação da mesma resposta substitui a anterior, mantendo o comentário
+func (d *Database) SaveFeedback(feedback *models.AnswerFeedback) error {
+	now := dbTime(time.Now())
+	_, err := d.db.Exec(`
+		INSERT INTO answer_feedback (hash, user_id, rating, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(hash, user_id) DO UPDATE SET
+			rating = excluded.rating,
+			updated_at = excluded.updated_at`,
+		feedback.Hash, feedback.UserID, feedback.Rating, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar avaliação da resposta %s: %w", feedback.Hash, err)
+	}
+	return nil
+}
+
+// GetFeedback busca a avaliação do usuário para a resposta. Retorna nil se ele não avaliou
+func (d *Database) GetFeedback(hash string, userID int64) (*models.AnswerFeedback, error) {
+	feedback, err := scanFeedback(d.db.QueryRow(
+		"SELECT "+feedbackColumns+" FROM answer_feedback WHERE hash = ? AND user_id = ?",
+		hash, userID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar avaliação da resposta %s: %w", hash, err)
+	}
+	return feedback, nil
+}
+
+// SetFeedbackPrompt registra a mensagem do bot que pediu o comentário da avaliação
+func (d *Database) SetFeedbackPrompt(hash string, userID, chatID int64, messageID int) error {
+	_, err := d.db.Exec(
+		"UPDATE answer_feedback SET prompt_chat_id = ?, prompt_message_id = ? WHERE hash = ? AND user_id = ?",
+		chatID, messageID, hash, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar pedido de comentário da resposta %s: %w", hash, err)
+	}
+	return nil
+}
+
+// FeedbackForPrompt busca a avaliação cujo pedido de comentário é a mensagem
+// informada. Retorna nil se a mensagem não for um pedido de comentário
+func (d *Database) FeedbackForPrompt(chatID int64, messageID int) (*models.AnswerFeedback, error) {
+	feedback, err := scanFeedback(d.db.QueryRow(
+		"SELECT "+feedbackColumns+" FROM answer_feedback WHERE prompt_chat_id = ? AND prompt_message_id = ?",
+		chatID, messageID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar avaliação do pedido de comentário %d: %w", messageID, err)
+	}
+	return feedback, nil
+}
+
+// SetFeedbackComment salva o comentário que acompanha a avaliação
+func (d *Database) SetFeedbackComment(hash string, userID int64, comment string) error {
+	_, err := d.db.Exec(
+		"UPDATE answer_feedback SET comment = ?, updated_at = ? WHERE hash = ? AND user_id = ?",
+		comment, dbTime(time.Now()), hash, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar comentário da resposta %s: %w", hash, err)
+	}
+	return nil
+}
+
+// FeedbackOverall agrega todas as avaliações registradas
+func (d *Database) FeedbackOverall() (models.FeedbackSummary, error) {
+	summaries, err := d.summarizeFeedback("'', '', ''", "")
+	if err != nil || len(summaries) == 0 {
+		return models.FeedbackSummary{}, err
+	}
+	return summaries[0], nil
+}
+
+// FeedbackByModel agrega as avaliações pelo provedor e pelo modelo que geraram a resposta
+func (d *Database) FeedbackByModel() ([]models.FeedbackSummary, error) {
+	return d.summarizeFeedback("m.provider, m.model, ''", "GROUP BY m.provider, m.model")
+}
+
+// FeedbackByPersona agrega as avaliações pela persona usada na resposta
+func (d *Database) FeedbackByPersona() ([]models.FeedbackSummary, error) {
+	return d.summarizeFeedback("'', '', m.persona", "GROUP BY m.persona")
+}
+
+// summarizeFeedback conta as avaliações positivas e negativas, junto com a
+// origem gravada em cada resposta. columns e groupBy nunca vêm de entrada do usuário
+func (d *Database) summarizeFeedback(columns, groupBy string) ([]models.FeedbackSummary, error) {
+	rows, err := d.db.Query(fmt.Sprintf(`
+		SELECT %s,
+			COALESCE(SUM(CASE WHEN f.rating > 0 THEN 1 ELSE 0 END), 0) AS up,
+			COALESCE(SUM(CASE WHEN f.rating < 0 THEN 1 ELSE 0 END), 0) AS down
+		FROM answer_feedback f
+		LEFT JOIN messages m ON m.hash = f.hash
+		%s
+		ORDER BY up + down DESC`, columns, groupBy),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agregar avaliações: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []models.FeedbackSummary{}
+	for rows.Next() {
+		var summary models.FeedbackSummary
+		var provider, model, persona sql.NullString
+		if err := rows.Scan(&provider, &model, &persona, &summary.Up, &summary.Down); err != nil {
+			return nil, fmt.Errorf("erro ao ler agregação de avaliações: %w", err)
+		}
+		summary.Provider = provider.String
+		summary.Model = model.String
+		summary.Persona = persona.String
+		summary.Total = summary.Up + summary.Down
+		if summary.Total > 0 {
+			summary.Satisfaction = float64(summary.Up) / float64(summary.Total)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// WorstRatedAnswers lista as respostas com pelo menos uma avaliação negativa,
+// da pior para a melhor, com os comentários recebidos
+func (d *Database) WorstRatedAnswers(limit int) ([]models.RatedAnswer, error) {
+	rows, err := d.db.Query(`
+		SELECT f.hash, COALESCE(m.content, ''),
+			COALESCE(m.provider, ''), COALESCE(m.model, ''), COALESCE(m.persona, ''),
+			SUM(CASE WHEN f.rating > 0 THEN 1 ELSE 0 END) AS up,
+			SUM(CASE WHEN f.rating < 0 THEN 1 ELSE 0 END) AS down
+		FROM answer_feedback f
+		LEFT JOIN messages m ON m.hash = f.hash
+		GROUP BY f.hash
+		HAVING down > 0
+		ORDER BY up - down ASC, down DESC, MAX(f.updated_at) DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar respostas mal avaliadas: %w", err)
+	}
+
+	answers := []models.RatedAnswer{}
+	for rows.Next() {
+		var answer models.RatedAnswer
+		if err := rows.Scan(&answer.Hash, &answer.Content, &answer.Provider, &answer.Model, &answer.Persona, &answer.Up, &answer.Down); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("erro ao ler resposta avaliada: %w", err)
+		}
+		answer.Score = answer.Up - answer.Down
+		answers = append(answers, answer)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar respostas mal avaliadas: %w", err)
+	}
+
+	for i := range answers {
+		comments, err := d.feedbackComments(answers[i].Hash)
+		if err != nil {
+			return nil, err
+		}
+		answers[i].Comments = comments
+	}
+	return answers, nil
+}
+
+// feedbackComments lista os comentários deixados nas avaliações da resposta
+func (d *Database) feedbackComments(hash string) ([]string, error) {
+	rows, err := d.db.Query(
+		"SELECT comment FROM answer_feedback WHERE hash = ? AND comment IS NOT NULL AND comment != '' ORDER BY updated_at",
+		hash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar comentários da resposta %s: %w", hash, err)
+	}
+	defer rows.Close()
+
+	comments := []string{}
+	for rows.Next() {
+		var comment string
+		if err := rows.Scan(&comment); err != nil {
+			return nil, fmt.Errorf("erro ao ler comentário: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+const feedbackColumns = "hash, user_id, rating, comment, prompt_chat_id, prompt_message_id, created_at, updated_at"
+
+// scanFeedback lê uma avaliação. Retorna nil, sem erro, se não houver linha
+func scanFeedback(row *sql.Row) (*models.AnswerFeedback, error) {
+	var feedback models.AnswerFeedback
+	var comment sql.NullString
+	err := row.Scan(
+		&feedback.Hash, &feedback.UserID, &feedback.Rating, &comment,
+		&feedback.PromptChatID, &feedback.PromptMessageID, &feedback.CreatedAt, &feedback.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	feedback.Comment = comment.String
+	return &feedback, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"bot-ai/models"
+)
+
+// GetGroupSettings recupera a política salva de um grupo. Grupos sem
+// configuração recebem valores vazios, completados pelo padrão do bot
+func (d *Database) GetGroupSettings(chatID int64) (*models.GroupSettings, error) {
+	settings := &models.GroupSettings{ChatID: chatID}
+	var mode, triggers, prefix, keywords sql.NullString
+	err := d.db.QueryRow(
+		"SELECT conversation_mode, triggers, command_prefix, keywords FROM group_settings WHERE chat_id = ?",
+		chatID,
+	).Scan(&mode, &triggers, &prefix, &keywords)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar configurações do grupo: %w", err)
+	}
+
+	// Listas salvas vazias são uma escolha do grupo (ex: nenhuma palavra-chave)
+	// e não devem cair no padrão do bot
+	settings.ConversationMode = mode.String
+	settings.Triggers = append([]string{}, splitList(triggers.String)...)
+	settings.CommandPrefix = prefix.String
+	settings.Keywords = append([]string{}, splitList(keywords.String)...)
+	return settings, nil
+}
+
+// SaveGroupSettings grava a política completa de um grupo
+func (d *Database) SaveGroupSettings(settings *models.GroupSettings) error {
+	_, err := d.db.Exec(`
+		INSERT INTO group_settings (chat_id, conversation_mode, triggers, command_prefix, keywords, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			conversation_mode = excluded.conversation_mode,
+			triggers = excluded.triggers,
+			command_prefix = excluded.command_prefix,
+			keywords = excluded.keywords,
+			updated_at = CURRENT_TIMESTAMP`,
+		settings.ChatID, settings.ConversationMode, strings.Join(settings.Triggers, ","),
+		settings.CommandPrefix, strings.Join(settings.Keywords, ","),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar configurações do grupo: %w", err)
+	}
+	return nil
+}
+
+// splitList separa uma lista salva como texto separado por vírgulas
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// MigrateChat move os registros de um grupo para o supergrupo em que ele foi
+// convertido: configurações, liberação de acesso, agendamentos, jobs pendentes
+// e o histórico compartilhado, que pertence ao ID do chat
+func (d *Database) MigrateChat(oldID, newID int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"UPDATE OR REPLACE group_settings SET chat_id = ? WHERE chat_id = ?",
+		"UPDATE schedules SET chat_id = ?, chat_type = 'supergroup' WHERE chat_id = ?",
+		"UPDATE jobs SET chat_id = ? WHERE chat_id = ?",
+		"UPDATE jobs SET conversation_id = ? WHERE conversation_id = ?",
+		"UPDATE chat_history SET user_id = ? WHERE user_id = ?",
+		"UPDATE chat_history SET topic_chat_id = ? WHERE topic_chat_id = ?",
+		"UPDATE OR REPLACE access_list SET id = ? WHERE kind = 'group' AND id = ?",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, newID, oldID); err != nil {
+			return fmt.Errorf("erro ao migrar chat %d para %d: %w", oldID, newID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return nil
+}
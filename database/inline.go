@@ -0,0 +1,57 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// RecordInlineResult registra um resultado do modo inline escolhido por um usuário
+func (d *Database) RecordInlineResult(userID int64, resultID, query string) error {
+	_, err := d.db.Exec(
+		"INSERT INTO inline_results (user_id, result_id, query, created_at) VALUES (?, ?, ?, ?)",
+		userID, resultID, query, dbTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar resultado inline: %w", err)
+	}
+	return nil
+}
+
+// GetInlineUsage resume o uso do modo inline desde o instante informado
+func (d *Database) GetInlineUsage(since time.Time) (*models.InlineUsage, error) {
+	usage := &models.InlineUsage{TopQueries: []models.InlineQueryStat{}}
+
+	err := d.db.QueryRow(
+		"SELECT COUNT(*), COUNT(DISTINCT user_id) FROM inline_results WHERE created_at >= ?",
+		dbTime(since),
+	).Scan(&usage.Chosen, &usage.Users)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar resultados inline: %w", err)
+	}
+
+	rows, err := d.db.Query(`
+		SELECT LOWER(query), COUNT(*) AS total
+		FROM inline_results
+		WHERE created_at >= ?
+		GROUP BY LOWER(query)
+		ORDER BY total DESC
+		LIMIT 10`,
+		dbTime(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar consultas inline mais usadas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat models.InlineQueryStat
+		if err := rows.Scan(&stat.Query, &stat.Count); err != nil {
+			return nil, fmt.Errorf("erro ao ler consulta inline: %w", err)
+		}
+		usage.TopQueries = append(usage.TopQueries, stat)
+	}
+
+	return usage, rows.Err()
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"bot-ai/models"
+)
+
+// jobColumns lista as colunas lidas por scanJobs, na mesma ordem
+const jobColumns = `id, user_id, chat_id, conversation_id, thread_id, replace_turn_id, message, question, status, attempts, last_error, created_at, updated_at`
+
+// CreateJob registra uma nova pergunta com status pending e retorna o ID gerado
+func (d *Database) CreateJob(job *models.Job) (int64, error) {
+	now := dbTime(time.Now())
+	result, err := d.db.Exec(`
+		INSERT INTO jobs (user_id, chat_id, conversation_id, thread_id, replace_turn_id, message, question, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.UserID, job.ChatID, job.ConversationID, job.ThreadID, job.ReplaceTurnID, job.Message, job.Question, models.JobPending, now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao registrar job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do job: %w", err)
+	}
+
+	return id, nil
+}
+
+// MarkJobRunning marca o início de uma tentativa e incrementa o contador de tentativas
+func (d *Database) MarkJobRunning(id int64) error {
+	_, err := d.db.Exec(
+		"UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ?",
+		models.JobRunning, dbTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar job %d: %w", id, err)
+	}
+	return nil
+}
+
+// MarkJobDone marca o job como respondido com sucesso
+func (d *Database) MarkJobDone(id int64) error {
+	_, err := d.db.Exec(
+		"UPDATE jobs SET status = ?, last_error = NULL, updated_at = ? WHERE id = ?",
+		models.JobDone, dbTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao concluir job %d: %w", id, err)
+	}
+	return nil
+}
+
+// MarkJobFailed registra a falha de uma tentativa. O status deve ser
+// models.JobFailed (nova tentativa) ou models.JobDead (tentativas esgotadas)
+func (d *Database) MarkJobFailed(id int64, status, lastError string) error {
+	_, err := d.db.Exec(
+		"UPDATE jobs SET status = ?, last_error = ?, updated_at = ? WHERE id = ?",
+		status, lastError, dbTime(time.Now()), id,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar falha do job %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetUnfinishedJobs recupera, em ordem de chegada, os jobs que não foram
+// concluídos nem descartados, como os interrompidos por uma reinicialização
+func (d *Database) GetUnfinishedJobs() ([]models.Job, error) {
+	return d.ListJobs([]string{models.JobPending, models.JobRunning, models.JobFailed}, time.Time{})
+}
+
+// ListJobs lista os jobs com um dos status informados. Se updatedBefore não
+// for zero, retorna apenas os jobs sem atualização desde então
+func (d *Database) ListJobs(statuses []string, updatedBefore time.Time) ([]models.Job, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE status IN (` + placeholders + `)`
+	args := make([]interface{}, 0, len(statuses)+1)
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	if !updatedBefore.IsZero() {
+		query += " AND updated_at < ?"
+		args = append(args, dbTime(updatedBefore))
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanJobs(rows)
+}
+
+// CleanupFinishedJobs remove os jobs concluídos ou descartados mais antigos que o período especificado
+func (d *Database) CleanupFinishedJobs(retentionPeriod time.Duration) (int64, error) {
+	result, err := d.db.Exec(
+		"DELETE FROM jobs WHERE status IN (?, ?) AND updated_at < ?",
+		models.JobDone, models.JobDead, dbTime(time.Now().Add(-retentionPeriod)),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao limpar jobs antigos: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter contagem de jobs removidos: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+func scanJobs(rows *sql.Rows) ([]models.Job, error) {
+	var jobs []models.Job
+	for rows.Next() {
+		var job models.Job
+		var lastError sql.NullString
+		err := rows.Scan(
+			&job.ID, &job.UserID, &job.ChatID, &job.ConversationID, &job.ThreadID, &job.ReplaceTurnID, &job.Message, &job.Question,
+			&job.Status, &job.Attempts, &lastError, &job.CreatedAt, &job.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler job: %w", err)
+		}
+		job.LastError = lastError.String
+
+		// Jobs criados antes das conversas de grupo pertencem sempre ao usuário
+		if job.ConversationID == 0 {
+			job.ConversationID = job.UserID
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
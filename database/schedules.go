@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// scheduleColumns lista as colunas lidas por scanSchedule, na mesma ordem
+const scheduleColumns = `id, user_id, user_name, chat_id, chat_type, thread_id, kind, time_of_day, timezone,
+	locale, prompt, next_run_at, last_run_at, is_active, created_at`
+
+// CreateSchedule salva um novo agendamento e retorna o ID gerado
+func (d *Database) CreateSchedule(schedule *models.Schedule) (int64, error) {
+	result, err := d.db.Exec(`
+		INSERT INTO schedules (user_id, user_name, chat_id, chat_type, thread_id, kind, time_of_day, timezone, locale, prompt, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		schedule.UserID, schedule.UserName, schedule.ChatID, schedule.ChatType, schedule.ThreadID, schedule.Kind,
+		schedule.TimeOfDay, schedule.Timezone, schedule.Locale, schedule.Prompt, dbTime(schedule.NextRunAt),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao criar agendamento: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do agendamento: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListUserSchedules lista os agendamentos ativos de um usuário
+func (d *Database) ListUserSchedules(userID int64) ([]models.Schedule, error) {
+	rows, err := d.db.Query(`
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE user_id = ? AND is_active = true
+		ORDER BY next_run_at ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar agendamentos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSchedules(rows)
+}
+
+// GetDueSchedules recupera os agendamentos ativos cuja próxima execução já passou
+func (d *Database) GetDueSchedules(now time.Time) ([]models.Schedule, error) {
+	rows, err := d.db.Query(`
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE is_active = true AND next_run_at <= ?
+		ORDER BY next_run_at ASC`,
+		dbTime(now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar agendamentos pendentes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSchedules(rows)
+}
+
+// MarkScheduleRun registra uma execução do agendamento. Se nextRunAt for nil,
+// o agendamento é desativado (agendamentos únicos)
+func (d *Database) MarkScheduleRun(id int64, ranAt time.Time, nextRunAt *time.Time) error {
+	var err error
+	if nextRunAt == nil {
+		_, err = d.db.Exec(
+			"UPDATE schedules SET last_run_at = ?, is_active = false WHERE id = ?",
+			dbTime(ranAt), id,
+		)
+	} else {
+		_, err = d.db.Exec(
+			"UPDATE schedules SET last_run_at = ?, next_run_at = ? WHERE id = ?",
+			dbTime(ranAt), dbTime(*nextRunAt), id,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar agendamento %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteSchedule desativa um agendamento do usuário. Retorna false se o
+// agendamento não existir ou pertencer a outro usuário
+func (d *Database) DeleteSchedule(userID, id int64) (bool, error) {
+	result, err := d.db.Exec(
+		"UPDATE schedules SET is_active = false WHERE id = ? AND user_id = ? AND is_active = true",
+		id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover agendamento: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao obter contagem de agendamentos removidos: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func scanSchedules(rows *sql.Rows) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	for rows.Next() {
+		var schedule models.Schedule
+		var userName, timeOfDay, locale sql.NullString
+		var lastRunAt sql.NullTime
+		err := rows.Scan(
+			&schedule.ID, &schedule.UserID, &userName, &schedule.ChatID, &schedule.ChatType, &schedule.ThreadID,
+			&schedule.Kind, &timeOfDay, &schedule.Timezone, &locale, &schedule.Prompt,
+			&schedule.NextRunAt, &lastRunAt, &schedule.IsActive, &schedule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler agendamento: %w", err)
+		}
+		schedule.UserName = userName.String
+		schedule.TimeOfDay = timeOfDay.String
+		schedule.Locale = locale.String
+		if lastRunAt.Valid {
+			schedule.LastRunAt = &lastRunAt.Time
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// dbTime normaliza horários para UTC com precisão de segundos, garantindo
+// que as comparações de texto feitas pelo SQLite respeitem a ordem cronológica
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
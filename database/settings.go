@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"bot-ai/models"
+)
+
+// GetUserSettings recupera as preferências do usuário. Usuários sem
+// preferências salvas recebem uma configuração vazia
+func (d *Database) GetUserSettings(userID int64) (*models.UserSettings, error) {
+	settings := &models.UserSettings{UserID: userID}
+	var timezone, language, deliveryMode, answerLength, temperature, persona sql.NullString
+	err := d.db.QueryRow(
+		"SELECT timezone, language, delivery_mode, answer_length, temperature, persona, auto_new_chat_hours FROM user_settings WHERE user_id = ?",
+		userID,
+	).Scan(&timezone, &language, &deliveryMode, &answerLength, &temperature, &persona, &settings.AutoNewChatHours)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar preferências do usuário: %w", err)
+	}
+
+	settings.Timezone = timezone.String
+	settings.Language = language.String
+	settings.DeliveryMode = deliveryMode.String
+	settings.AnswerLength = answerLength.String
+	settings.Temperature = temperature.String
+	settings.Persona = persona.String
+	return settings, nil
+}
+
+// SetUserTimezone salva o fuso horário do usuário
+func (d *Database) SetUserTimezone(userID int64, timezone string) error {
+	return d.setUserSetting(userID, "timezone", timezone)
+}
+
+// SetUserLanguage salva o idioma escolhido pelo usuário
+func (d *Database) SetUserLanguage(userID int64, language string) error {
+	return d.setUserSetting(userID, "language", language)
+}
+
+// SetUserDeliveryMode salva como o usuário prefere receber as respostas
+func (d *Database) SetUserDeliveryMode(userID int64, mode string) error {
+	return d.setUserSetting(userID, "delivery_mode", mode)
+}
+
+// SetUserAnswerLength salva o tamanho de resposta preferido pelo usuário
+func (d *Database) SetUserAnswerLength(userID int64, length string) error {
+	return d.setUserSetting(userID, "answer_length", length)
+}
+
+// SetUserTemperature salva a predefinição de temperatura do usuário. Vazio volta à do provedor
+func (d *Database) SetUserTemperature(userID int64, preset string) error {
+	return d.setUserSetting(userID, "temperature", preset)
+}
+
+// SetUserPersona salva a persona padrão do usuário
+func (d *Database) SetUserPersona(userID int64, persona string) error {
+	return d.setUserSetting(userID, "persona", persona)
+}
+
+// SetUserAutoNewChat salva após quantas horas sem mensagens o usuário começa
+// um chat novo automaticamente. Zero desativa
+func (d *Database) SetUserAutoNewChat(userID int64, hours int) error {
+	return d.setUserSetting(userID, "auto_new_chat_hours", hours)
+}
+
+// setUserSetting grava uma única coluna de user_settings, criando a linha se necessário.
+// column nunca vem de entrada do usuário
+func (d *Database) setUserSetting(userID int64, column string, value interface{}) error {
+	_, err := d.db.Exec(fmt.Sprintf(`
+		INSERT INTO user_settings (user_id, %[1]s, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET %[1]s = excluded.%[1]s, updated_at = CURRENT_TIMESTAMP`, column),
+		userID, value,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar preferência %s do usuário: %w", column, err)
+	}
+	return nil
+}
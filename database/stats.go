@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"bot-ai/models"
+)
+
+// GetBotStats calcula os totais de uso do bot. since marca o início do dia
+// usado nos contadores diários
+func (d *Database) GetBotStats(since time.Time) (*models.BotStats, error) {
+	stats := &models.BotStats{}
+	since = dbTime(since)
+
+	err := d.db.QueryRow("SELECT COUNT(*) FROM (" + botUsersQuery + ")").Scan(&stats.Users)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar usuários: %w", err)
+	}
+
+	// Em conversas compartilhadas o histórico pertence ao grupo (ID negativo)
+	// e o usuário fica registrado como autor de cada mensagem
+	err = d.db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT COALESCE(cm.author_id, ch.user_id) AS user_id
+			FROM chat_messages cm
+			JOIN chat_history ch ON ch.id = cm.chat_history_id
+			WHERE cm.role = 'user' AND cm.created_at >= ?
+			GROUP BY user_id
+		)`,
+		since,
+	).Scan(&stats.ActiveToday)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar usuários ativos: %w", err)
+	}
+
+	err = d.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT CASE
+			WHEN user_id < 0 THEN user_id
+			WHEN topic_chat_id <> 0 THEN topic_chat_id
+		END)
+		FROM chat_history`,
+	).Scan(&stats.Conversations, &stats.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar conversas: %w", err)
+	}
+
+	err = d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN role = 'user' THEN 1 ELSE 0 END), 0)
+		FROM chat_messages
+		WHERE created_at >= ?`,
+		since,
+	).Scan(&stats.MessagesToday, &stats.QuestionsToday)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar mensagens: %w", err)
+	}
+
+	err = d.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0)
+		FROM jobs
+		WHERE updated_at >= ?`,
+		models.JobFailed, models.JobDead, since,
+	).Scan(&stats.FailedToday, &stats.DeadToday)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar falhas: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetUserStats calcula o uso do bot por um usuário. since marca o início do
+// dia usado em QuestionsToday
+func (d *Database) GetUserStats(userID int64, since time.Time) (*models.UserStats, error) {
+	stats := &models.UserStats{UserID: userID}
+
+	err := d.db.QueryRow("SELECT COUNT(*) FROM chat_history WHERE user_id = ?", userID).Scan(&stats.Conversations)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar conversas do usuário: %w", err)
+	}
+
+	err = d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN cm.created_at >= ? THEN 1 ELSE 0 END), 0)
+		FROM chat_messages cm
+		JOIN chat_history ch ON ch.id = cm.chat_history_id
+		WHERE cm.role = 'user' AND COALESCE(cm.author_id, ch.user_id) = ?`,
+		dbTime(since), userID,
+	).Scan(&stats.Questions, &stats.QuestionsToday)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar perguntas do usuário: %w", err)
+	}
+
+	var lastActive time.Time
+	err = d.db.QueryRow(`
+		SELECT cm.created_at
+		FROM chat_messages cm
+		JOIN chat_history ch ON ch.id = cm.chat_history_id
+		WHERE cm.role = 'user' AND COALESCE(cm.author_id, ch.user_id) = ?
+		ORDER BY cm.created_at DESC
+		LIMIT 1`,
+		userID,
+	).Scan(&lastActive)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("erro ao buscar última atividade do usuário: %w", err)
+	}
+	if err == nil {
+		stats.LastActiveAt = &lastActive
+	}
+
+	return stats, nil
+}
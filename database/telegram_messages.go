@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SaveTelegramMessage associa uma mensagem enviada pelo bot no Telegram ao hash
+// da resposta que ela contém e à mensagem com a pergunta respondida
+func (d *Database) SaveTelegramMessage(chatID int64, messageID int, hash string, questionID int) error {
+	_, err := d.db.Exec(
+		"INSERT OR REPLACE INTO telegram_messages (chat_id, message_id, hash, question_id) VALUES (?, ?, ?, ?)",
+		chatID, messageID, hash, questionID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar mensagem do Telegram: %w", err)
+	}
+	return nil
+}
+
+// DeleteTelegramMessage remove o registro de uma mensagem apagada pelo bot
+func (d *Database) DeleteTelegramMessage(chatID int64, messageID int) error {
+	_, err := d.db.Exec("DELETE FROM telegram_messages WHERE chat_id = ? AND message_id = ?", chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover mensagem do Telegram: %w", err)
+	}
+	return nil
+}
+
+// GetTelegramMessageHash retorna o hash da resposta enviada em uma mensagem do
+// Telegram. Retorna uma string vazia se a mensagem não for conhecida
+func (d *Database) GetTelegramMessageHash(chatID int64, messageID int) (string, error) {
+	var hash string
+	err := d.db.QueryRow(
+		"SELECT hash FROM telegram_messages WHERE chat_id = ? AND message_id = ?",
+		chatID, messageID,
+	).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar mensagem do Telegram: %w", err)
+	}
+	return hash, nil
+}
+
+// GetAnswerMessages lista, em ordem, as mensagens do bot que responderam a uma pergunta
+func (d *Database) GetAnswerMessages(chatID int64, questionID int) ([]int, error) {
+	rows, err := d.db.Query(
+		"SELECT message_id FROM telegram_messages WHERE chat_id = ? AND question_id = ? ORDER BY message_id",
+		chatID, questionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar respostas da pergunta: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao ler resposta da pergunta: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SaveQuestionTurn associa a mensagem do Telegram com a pergunta ao turno do
+// histórico que a registrou, localizado a partir do hash da resposta
+func (d *Database) SaveQuestionTurn(chatID int64, messageID int, hash string) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO question_turns (chat_id, message_id, chat_message_id)
+		SELECT ?, ?, q.id
+		FROM chat_messages a
+		JOIN chat_messages q ON q.chat_history_id = a.chat_history_id AND q.role = 'user' AND q.id < a.id
+		WHERE a.hash = ?
+		ORDER BY q.id DESC
+		LIMIT 1`,
+		chatID, messageID, hash,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar turno da pergunta: %w", err)
+	}
+	return nil
+}
+
+// GetQuestionTurn retorna o turno do histórico de uma pergunta enviada no
+// Telegram. Retorna zero se a pergunta não for conhecida
+func (d *Database) GetQuestionTurn(chatID int64, messageID int) (int64, error) {
+	var turnID int64
+	err := d.db.QueryRow(
+		"SELECT chat_message_id FROM question_turns WHERE chat_id = ? AND message_id = ?",
+		chatID, messageID,
+	).Scan(&turnID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar turno da pergunta: %w", err)
+	}
+	return turnID, nil
+}
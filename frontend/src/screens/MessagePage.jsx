@@ -3,6 +3,7 @@ import { useParams, useNavigate } from 'react-router-dom';
 import { useConfig } from '@/hooks/useConfig';
 import { Card, CardContent, CardHeader, CardTitle, CardDescription, CardFooter } from "@/components/ui/card";
 import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
 import { Tooltip, TooltipContent, TooltipProvider, TooltipTrigger } from "@/components/ui/tooltip";
 import { 
   Copy, 
@@ -16,7 +17,11 @@ import {
   MenuIcon,
   MessageSquarePlus,
   MessageCircle,
-  History
+  History,
+  Pencil,
+  ThumbsUp,
+  ThumbsDown,
+  Send
 } from "lucide-react";
 import {
   Sheet,
@@ -140,6 +145,9 @@ export default function MessagePage() {
   const [showHistory, setShowHistory] = useState(false);
   const [newMessage, setNewMessage] = useState('');
   const [currentChatId, setCurrentChatId] = useState(null);
+  const [feedback, setFeedback] = useState(null);
+  const [feedbackComment, setFeedbackComment] = useState('');
+  const [commentSaved, setCommentSaved] = useState(false);
 
   // Função para buscar histórico de mensagens
   const fetchMessageHistory = async () => {
@@ -170,6 +178,39 @@ export default function MessagePage() {
     }
   };
 
+  // Função para renomear um chat
+  const renameChat = async (chat) => {
+    const title = window.prompt('Novo título da conversa', chat.title || chat.preview_message || '');
+    if (title === null) {
+      return;
+    }
+
+    try {
+      const headers = {
+        'Content-Type': 'application/json'
+      };
+
+      if (tg?.initData) {
+        headers['X-Telegram-Init-Data'] = tg.initData;
+      }
+
+      const response = await fetch(`${config.apiUrl}/api/chat/${chat.id}`, {
+        method: 'PATCH',
+        headers,
+        mode: 'cors',
+        body: JSON.stringify({ title })
+      });
+
+      if (!response.ok) {
+        throw new Error('Erro ao renomear conversa');
+      }
+
+      await fetchMessageHistory();
+    } catch (error) {
+      console.error('Erro ao renomear conversa:', error);
+    }
+  };
+
   // Função para criar novo chat
   const createNewChat = async () => {
     try {
@@ -308,6 +349,7 @@ export default function MessagePage() {
 
       const data = await response.json();
       setMessage(data);
+      fetchFeedback(messageHash);
       
       // Ativa animação de entrada após carregar o conteúdo
       setTimeout(() => {
@@ -322,6 +364,70 @@ export default function MessagePage() {
     }
   };
 
+  // Função para buscar a avaliação que o usuário já deu para a resposta
+  const fetchFeedback = async (messageHash) => {
+    try {
+      const headers = {
+        'Content-Type': 'application/json'
+      };
+
+      if (tg?.initData) {
+        headers['X-Telegram-Init-Data'] = tg.initData;
+      }
+
+      const response = await fetch(`${config.apiUrl}/api/feedback/${messageHash}`, {
+        headers,
+        mode: 'cors'
+      });
+
+      if (!response.ok) {
+        throw new Error('Erro ao carregar avaliação');
+      }
+
+      const data = await response.json();
+      setFeedback(data);
+      setFeedbackComment(data?.comment || '');
+    } catch (error) {
+      console.error('Erro ao buscar avaliação:', error);
+    }
+  };
+
+  // Função para avaliar a resposta (1 = útil, -1 = não útil), com comentário opcional
+  const submitFeedback = async (rating, comment) => {
+    try {
+      const headers = {
+        'Content-Type': 'application/json'
+      };
+
+      if (tg?.initData) {
+        headers['X-Telegram-Init-Data'] = tg.initData;
+      }
+
+      const body = { rating };
+      if (comment !== undefined) {
+        body.comment = comment;
+      }
+
+      const response = await fetch(`${config.apiUrl}/api/feedback/${message.hash}`, {
+        method: 'POST',
+        headers,
+        mode: 'cors',
+        body: JSON.stringify(body)
+      });
+
+      if (!response.ok) {
+        throw new Error('Erro ao salvar avaliação');
+      }
+
+      const data = await response.json();
+      setFeedback(data);
+      setCommentSaved(comment !== undefined);
+      tg?.HapticFeedback?.impactOccurred('light');
+    } catch (error) {
+      console.error('Erro ao salvar avaliação:', error);
+    }
+  };
+
   // Carrega o histórico completo quando o usuário clica em "Ver Histórico"
   const handleShowHistory = async () => {
     // Se já temos mensagens do chat carregadas, apenas alterne a visualização
@@ -477,20 +583,29 @@ export default function MessagePage() {
         <div className="flex-1 overflow-y-auto">
           <div className="space-y-2 p-4">
             {messageHistory.map((chat) => (
-              <Button
-                key={chat.id}
-                variant={chat.id === currentChatId ? 'secondary' : 'ghost'}
-                className="w-full justify-start"
-                onClick={() => {
-                  navigate(`/chat/${chat.id}`);
-                  setIsSheetOpen(false);
-                }}
-              >
-                <MessageCircle className="mr-2 h-4 w-4" />
-                <div className="truncate text-left">
-                  {chat.preview_message || "Nova conversa"}
-                </div>
-              </Button>
+              <div key={chat.id} className="flex items-center gap-1">
+                <Button
+                  variant={chat.id === currentChatId ? 'secondary' : 'ghost'}
+                  className="flex-1 justify-start min-w-0"
+                  onClick={() => {
+                    navigate(`/chat/${chat.id}`);
+                    setIsSheetOpen(false);
+                  }}
+                >
+                  <MessageCircle className="mr-2 h-4 w-4" />
+                  <div className="truncate text-left">
+                    {chat.title || chat.preview_message || "Nova conversa"}
+                  </div>
+                </Button>
+                <Button
+                  variant="ghost"
+                  size="icon"
+                  className="h-8 w-8 shrink-0"
+                  onClick={() => renameChat(chat)}
+                >
+                  <Pencil className="h-3 w-3" />
+                </Button>
+              </div>
             ))}
           </div>
         </div>
@@ -537,20 +652,29 @@ export default function MessagePage() {
                       ))
                     ) : messageHistory.length > 0 ? (
                       messageHistory.map((chat) => (
-                        <Button
-                          key={chat.id}
-                          variant={chat.id === currentChatId ? 'secondary' : 'ghost'}
-                          className="w-full justify-start"
-                          onClick={() => {
-                            navigate(`/chat/${chat.id}`);
-                            setIsSheetOpen(false);
-                          }}
-                        >
-                          <MessageCircle className="mr-2 h-4 w-4" />
-                          <div className="truncate text-left">
-                            {chat.preview_message || "Nova conversa"}
-                          </div>
-                        </Button>
+                        <div key={chat.id} className="flex items-center gap-1">
+                          <Button
+                            variant={chat.id === currentChatId ? 'secondary' : 'ghost'}
+                            className="flex-1 justify-start min-w-0"
+                            onClick={() => {
+                              navigate(`/chat/${chat.id}`);
+                              setIsSheetOpen(false);
+                            }}
+                          >
+                            <MessageCircle className="mr-2 h-4 w-4" />
+                            <div className="truncate text-left">
+                              {chat.title || chat.preview_message || "Nova conversa"}
+                            </div>
+                          </Button>
+                          <Button
+                            variant="ghost"
+                            size="icon"
+                            className="h-8 w-8 shrink-0"
+                            onClick={() => renameChat(chat)}
+                          >
+                            <Pencil className="h-3 w-3" />
+                          </Button>
+                        </div>
                       ))
                     ) : (
                       <div className="text-center py-4 text-muted-foreground">
@@ -758,6 +882,55 @@ export default function MessagePage() {
                           {message.content}
                         </ReactMarkdown>
                       </div>
+
+                      {/* Avaliação da resposta */}
+                      <div className="not-prose mt-6 border-t pt-4 space-y-3">
+                        <div className="flex items-center gap-2">
+                          <span className="text-sm text-muted-foreground mr-1">Esta resposta foi útil?</span>
+                          <Button
+                            variant={feedback?.rating === 1 ? "default" : "outline"}
+                            size="sm"
+                            onClick={() => submitFeedback(1)}
+                            className="h-8 transition-all duration-200"
+                            aria-label="Resposta útil"
+                          >
+                            <ThumbsUp className="h-4 w-4" />
+                          </Button>
+                          <Button
+                            variant={feedback?.rating === -1 ? "default" : "outline"}
+                            size="sm"
+                            onClick={() => submitFeedback(-1)}
+                            className="h-8 transition-all duration-200"
+                            aria-label="Resposta não útil"
+                          >
+                            <ThumbsDown className="h-4 w-4" />
+                          </Button>
+                        </div>
+
+                        {feedback && (
+                          <div className="flex items-center gap-2">
+                            <Input
+                              value={feedbackComment}
+                              onChange={(e) => {
+                                setFeedbackComment(e.target.value);
+                                setCommentSaved(false);
+                              }}
+                              maxLength={1000}
+                              placeholder="Conte o que achou da resposta (opcional)"
+                              className="h-8 text-sm"
+                            />
+                            <Button
+                              variant="outline"
+                              size="sm"
+                              onClick={() => submitFeedback(feedback.rating, feedbackComment)}
+                              className="h-8 transition-all duration-200"
+                              aria-label="Enviar comentário"
+                            >
+                              {commentSaved ? <Check className="h-4 w-4" /> : <Send className="h-4 w-4" />}
+                            </Button>
+                          </div>
+                        )}
+                      </div>
                     </div>
                   ) : (
                     // Mostra o histórico completo do chat com mensagens alinhadas em lados diferentes
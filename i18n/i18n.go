@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DefaultLocale é usado quando o idioma do usuário não é suportado
+const DefaultLocale = "pt-BR"
+
+// Locale descreve um idioma suportado pelo bot
+type Locale struct {
+	Code string // Código usado internamente e no banco (ex: "pt-BR")
+	Name string // Nome do idioma no próprio idioma (ex: "Português")
+	Flag string
+}
+
+// Supported lista os idiomas disponíveis, na ordem exibida ao usuário
+var Supported = []Locale{
+	{Code: "pt-BR", Name: "Português (Brasil)", Flag: "🇧🇷"},
+	{Code: "en", Name: "English", Flag: "🇺🇸"},
+	{Code: "es", Name: "Español", Flag: "🇪🇸"},
+}
+
+// Resolve converte um language_code do Telegram (IETF, ex: "pt-br", "en-US")
+// em um dos idiomas suportados, usando DefaultLocale como fallback
+func Resolve(languageCode string) string {
+	code := strings.ToLower(strings.TrimSpace(languageCode))
+	if code == "" {
+		return DefaultLocale
+	}
+
+	// Correspondência exata primeiro (ex: "pt-br"), depois pelo idioma base (ex: "pt" de "pt-pt")
+	for _, locale := range Supported {
+		if strings.ToLower(locale.Code) == code {
+			return locale.Code
+		}
+	}
+
+	base, _, _ := strings.Cut(code, "-")
+	for _, locale := range Supported {
+		localeBase, _, _ := strings.Cut(strings.ToLower(locale.Code), "-")
+		if localeBase == base {
+			return locale.Code
+		}
+	}
+
+	return DefaultLocale
+}
+
+// IsSupported informa se o código corresponde exatamente a um idioma suportado
+func IsSupported(code string) bool {
+	for _, locale := range Supported {
+		if locale.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// T retorna a mensagem traduzida para a chave informada, formatada com args.
+// Se a chave não existir no idioma, usa o texto de DefaultLocale
+func T(locale, key string, args ...interface{}) string {
+	text, ok := catalog[locale][key]
+	if !ok {
+		text, ok = catalog[DefaultLocale][key]
+		if !ok {
+			log.Printf("Chave de tradução não encontrada: %s", key)
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
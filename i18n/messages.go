@@ -0,0 +1,661 @@
+package i18n
+
+// catalog contém as mensagens exibidas ao usuário, por idioma e chave.
+// Textos usados com parse_mode MarkdownV2 ou HTML estão marcados; os argumentos
+// devem ser escapados no formato correspondente
+var catalog = map[string]map[string]string{
+	"pt-BR": {
+		"error.generic":              "Desculpe, ocorreu um erro ao processar sua mensagem. Tente novamente mais tarde.",
+		"newchat.started":            "✨ Novo chat iniciado! Pode começar a conversar.",
+		"queue.position":             "⏳ Sua mensagem está na fila (posição #%d). Responderei assim que possível.",
+		"job.lost":                   "⚠️ Não consegui concluir a resposta para \"%s\" antes de uma reinicialização. Por favor, envie a pergunta novamente.",
+		"inline.title":               "Resposta do Orbi AI",
+		"inline.message":             "❓ %s\n\n%s",
+		"inline.pending_title":       "⏳ Ainda pensando…",
+		"inline.pending_description": "Toque para perguntar direto no chat com o bot",
+		"inline.pending_message":     "❓ %s\n\nPergunte ao bot para ver a resposta.",
+		"inline.open_bot":            "💬 Abrir o bot",
+		"callback.expired":           "⌛ Este botão expirou. Envie o comando novamente.",
+		"response.header":            "Resposta para %s:\n\n%s", // HTML
+		"button.full_answer":         "📝 Ver Resposta Completa",
+		"button.open_answer":         "🔍 Toque aqui para abrir a resposta",
+		"button.history":             "📱 Abrir histórico",
+		"start.found":                "📝 *Resposta encontrada\\!*\n\n%s\n\n _Toque no botão abaixo para ver a resposta completa_", // MarkdownV2
+		"welcome.name":               "usuário",
+		"welcome.text": "Olá, %s! 👋\n\nEu sou o Orbi AI, seu assistente virtual. Pode me fazer perguntas sobre qualquer assunto!\n\n" +
+			"Comandos disponíveis:\n" +
+			"/newchat - Inicia uma nova conversa\n" +
+			"/schedule - Agenda prompts e lembretes\n" +
+			"/schedules - Lista seus agendamentos\n" +
+			"/language - Altera o idioma do bot\n" +
+			"/help - Lista todos os comandos",
+		"format.datetime": "02/01/2006 15:04",
+
+		"schedule.usage": "Uso:\n" +
+			"/schedule daily 08:00 <prompt> - todos os dias no horário indicado\n" +
+			"/schedule once 14:30 <prompt> - uma vez, na próxima ocorrência do horário\n" +
+			"/schedule once 2025-12-24 20:00 <prompt> - uma vez, na data indicada\n" +
+			"/schedule in 30m <prompt> - uma vez, daqui a um intervalo (ex: 45m, 2h)\n\n" +
+			"/schedules - lista seus agendamentos\n" +
+			"/unschedule <id> - remove um agendamento\n" +
+			"/timezone <fuso> - define seu fuso horário (ex: America/Sao_Paulo)",
+		"schedule.incomplete":       "Agendamento incompleto",
+		"schedule.date_past":        "A data informada já passou",
+		"schedule.invalid_interval": "Intervalo inválido: %s (use, por exemplo, 30m ou 2h)",
+		"schedule.unknown_kind":     "Tipo de agendamento desconhecido: %s",
+		"schedule.missing_prompt":   "Informe o prompt a ser executado",
+		"schedule.invalid_time":     "Horário inválido: %s (use o formato HH:MM)",
+		"schedule.created":          "⏰ Agendamento #%d criado!\nPróxima execução: %s (%s)",
+		"schedule.none":             "Você não tem agendamentos ativos.",
+		"schedule.list_header":      "⏰ Seus agendamentos:\n",
+		"schedule.once":             "uma vez",
+		"schedule.daily":            "diário às %s",
+		"schedule.list_item":        "\n#%d - %s - próxima: %s\n%s\n",
+		"schedule.unschedule_usage": "Informe o número do agendamento. Ex: /unschedule 3",
+		"schedule.not_found":        "Agendamento #%d não encontrado.",
+		"schedule.removed":          "🗑 Agendamento #%d removido.",
+
+		"timezone.current": "🌍 Seu fuso horário: %s\nPara alterar: /timezone America/Sao_Paulo",
+		"timezone.set":     "🌍 Fuso horário definido para %s.\nAgendamentos já existentes mantêm o fuso em que foram criados.",
+		"timezone.unknown": "Fuso horário desconhecido: %s\nUse um nome IANA, como America/Sao_Paulo ou Europe/Lisbon.",
+
+		"language.current": "🌐 Idioma atual: %s\n\nIdiomas disponíveis:\n%s\nPara alterar: /language en",
+		"language.set":     "🌐 Idioma alterado para %s.",
+		"language.unknown": "Idioma não suportado: %s\n\nIdiomas disponíveis:\n%s",
+
+		"help.header":         "📖 Comandos disponíveis:",
+		"command.unknown":     "Comando desconhecido: /%s\nUse /help para ver os comandos disponíveis.",
+		"command.not_allowed": "O comando /%s não está disponível aqui.",
+		"command.start":       "Mostra a mensagem de boas-vindas",
+		"command.help":        "Lista os comandos disponíveis",
+		"command.newchat":     "Inicia uma nova conversa",
+		"command.language":    "Altera o idioma do bot",
+		"command.schedule":    "Agenda prompts e lembretes",
+		"command.schedules":   "Lista seus agendamentos",
+		"command.unschedule":  "Remove um agendamento",
+		"command.timezone":    "Define seu fuso horário",
+
+		"command.delivery":      "Escolhe como as respostas são entregues",
+		"delivery.current":      "📨 Modo de entrega atual: %s\n\nModos disponíveis:\n%s",
+		"delivery.set":          "📨 Modo de entrega alterado para: %s",
+		"delivery.unknown":      "Modo de entrega desconhecido: %s\n\nModos disponíveis:\n%s",
+		"delivery.mode.preview": "prévia com botão para o app",
+		"delivery.mode.full":    "resposta completa no chat",
+		"delivery.mode.auto":    "automático (completa se for curta)",
+
+		"command.group":         "Configura o bot neste grupo",
+		"group.settings":        "👥 Configurações do grupo\n\nModo de conversa: %s\nGatilhos: %s\nPrefixo: %s\nPalavras-chave: %s",
+		"group.usage":           "Para alterar (apenas administradores):\n/group mode user|shared\n/group triggers mention,reply,prefix,keyword\n/group prefix !ai\n/group keywords bot,ajuda",
+		"group.not_admin":       "Apenas administradores do grupo podem alterar as configurações.",
+		"group.updated":         "✅ Configurações do grupo atualizadas.",
+		"group.mode.user":       "histórico individual por participante",
+		"group.mode.shared":     "histórico compartilhado pelo grupo",
+		"group.invalid_mode":    "Modo de conversa desconhecido: %s\nUse user ou shared.",
+		"group.invalid_trigger": "Gatilho desconhecido: %s\nGatilhos disponíveis: %s",
+		"group.none":            "nenhum",
+
+		"command.access":              "Gerencia a política de acesso ao bot",
+		"command.invite":              "Cria e gerencia convites de acesso",
+		"command.ban":                 "Bane um usuário do bot",
+		"command.unban":               "Remove o banimento de um usuário",
+		"access.denied":               "🔒 Este bot é de acesso restrito. Peça um convite a um administrador.",
+		"access.banned":               "🚫 Você foi bloqueado e não pode usar este bot.",
+		"access.summary":              "🔐 Acesso ao bot\n\nModo: %s",
+		"access.mode.open":            "aberto a todos, exceto banidos",
+		"access.mode.allowlist":       "apenas usuários e grupos liberados",
+		"access.list.user":            "Usuários liberados (%d):",
+		"access.list.group":           "Grupos liberados (%d):",
+		"access.list.banned":          "Usuários banidos (%d):",
+		"access.usage":                "Uso:\n/access\n/access mode open|allowlist\n/access allow <id>\n/access remove <id>\n/access allowgroup [id]\n/access removegroup [id]",
+		"access.invalid_mode":         "Modo de acesso desconhecido: %s\nUse open ou allowlist.",
+		"access.mode_updated":         "✅ Modo de acesso alterado: %s",
+		"access.unchanged":            "Nada a alterar para %d.",
+		"access.updated.allow_user":   "✅ Usuário %d liberado.",
+		"access.updated.remove_user":  "✅ Usuário %d removido da lista de acesso.",
+		"access.updated.allow_group":  "✅ Grupo %d liberado.",
+		"access.updated.remove_group": "✅ Grupo %d removido da lista de acesso.",
+		"access.updated.ban":          "🚫 Usuário %d banido.",
+		"access.updated.unban":        "✅ Banimento do usuário %d removido.",
+		"ban.usage":                   "Uso: /ban <id> [motivo], ou responda a uma mensagem do usuário com /ban [motivo]",
+		"ban.unban_usage":             "Uso: /unban <id>",
+		"invite.usage":                "Uso:\n/invite [usos] (0 = ilimitado)\n/invite list\n/invite revoke <código>",
+		"invite.created":              "🎟️ Convite criado (usos: %[2]s):\n%[1]s",
+		"invite.unlimited":            "ilimitados",
+		"invite.list_header":          "🎟️ Convites:",
+		"invite.empty":                "Nenhum convite criado.",
+		"invite.revoked":              "Convite %s revogado.",
+		"invite.revoked_flag":         "revogado",
+		"invite.not_found":            "Convite não encontrado: %s",
+		"invite.invalid":              "Este convite é inválido, foi revogado ou já foi usado.",
+		"invite.redeemed":             "✅ Convite aceito! Você já pode usar o bot.",
+		"invite.already":              "Você já tem acesso ao bot.",
+
+		"command.stats":              "Mostra as estatísticas de uso do bot",
+		"command.user":               "Mostra o uso e a situação de um usuário",
+		"command.maintenance":        "Liga ou desliga o modo de manutenção",
+		"command.provider":           "Mostra o provedor de IA e a saúde das chaves",
+		"stats.summary":              "📊 Estatísticas\n\nUsuários: %d (%d ativos hoje)\nConversas: %d (%d grupos)\nPerguntas hoje: %d (%d mensagens)\nErros do provedor hoje: %d aguardando nova tentativa, %d descartadas\nFila: %d em andamento, %d aguardando",
+		"user.usage":                 "Uso: /user <id>",
+		"user.summary":               "👤 Usuário %d\n\nSituação: %s\nConversas: %d\nPerguntas: %d (%d hoje)\nÚltima atividade: %s\nAgendamentos ativos: %d",
+		"user.never":                 "nunca",
+		"user.language":              "Idioma: %s",
+		"user.timezone":              "Fuso horário: %s",
+		"user.status.admin":          "administrador",
+		"user.status.allowed":        "com acesso",
+		"user.status.not_allowed":    "sem acesso",
+		"user.status.banned":         "banido",
+		"maintenance.active":         "🛠️ O bot está em manutenção. Tente novamente em alguns minutos.",
+		"maintenance.usage":          "Uso: /maintenance on|off",
+		"maintenance.status_on":      "🛠️ O modo de manutenção está ligado.",
+		"maintenance.status_off":     "O modo de manutenção está desligado.",
+		"maintenance.turned_on":      "🛠️ Modo de manutenção ligado. Apenas administradores serão atendidos.",
+		"maintenance.turned_off":     "✅ Modo de manutenção desligado.",
+		"provider.summary":           "🤖 Provedor: %s\nModelo: %s",
+		"provider.key":               "🔑 %s: %s\nRequisições: %d, falhas: %d, limites de cota: %d",
+		"provider.key.available":     "disponível",
+		"provider.key.benched":       "afastada",
+		"provider.key.benched_until": "afastada até %s",
+		"provider.key.last_error":    "Último erro: %s",
+		"provider.queue":             "Fila: %d/%d em andamento, %d aguardando, %d processadas",
+
+		"command.broadcast":          "Envia um aviso a todos os usuários",
+		"broadcast.usage":            "Uso:\n/broadcast <mensagem>\n/broadcast cancel <id>\n\nPara incluir um botão, termine a mensagem com uma linha no formato [Texto do botão](https://exemplo.com).",
+		"broadcast.confirm":          "👆 Prévia do aviso. Confirma o envio para %d usuários?",
+		"broadcast.button.send":      "📣 Enviar para %d",
+		"broadcast.button.cancel":    "Cancelar",
+		"broadcast.queued":           "📣 Broadcast #%d em envio. Avisarei quando terminar.",
+		"broadcast.not_draft":        "O broadcast #%d já foi enviado ou cancelado.",
+		"broadcast.cancelled":        "Broadcast #%d cancelado.",
+		"broadcast.not_found":        "Broadcast #%d não encontrado ou já concluído.",
+		"broadcast.finished":         "✅ Broadcast #%d concluído\n\nEntregues: %d\nBloqueados: %d\nFalhas: %d",
+		"broadcast.empty":            "Nenhum broadcast enviado.",
+		"broadcast.list_header":      "📣 Broadcasts recentes:",
+		"broadcast.item":             "#%d (%s): %d entregues, %d bloqueados, %d falhas de %d\n%s",
+		"broadcast.status.draft":     "aguardando confirmação",
+		"broadcast.status.pending":   "na fila",
+		"broadcast.status.running":   "em envio",
+		"broadcast.status.done":      "concluído",
+		"broadcast.status.cancelled": "cancelado",
+
+		// Preferências do /settings
+		"command.settings":              "Abre o menu de preferências",
+		"settings.title":                "⚙️ Suas preferências:\n",
+		"settings.line":                 "• %s: %s",
+		"settings.choose":               "⚙️ %s\n\nEscolha uma opção:",
+		"settings.saved":                "Preferência salva",
+		"settings.back":                 "⬅️ Voltar",
+		"settings.close":                "✖️ Fechar",
+		"settings.option.delivery":      "📨 Entrega",
+		"settings.option.length":        "📏 Tamanho",
+		"settings.option.temperature":   "🎨 Criatividade",
+		"settings.option.language":      "🌐 Idioma",
+		"settings.option.persona":       "🎭 Persona",
+		"settings.option.autonew":       "🕒 Novo chat",
+		"settings.length.normal":        "normal",
+		"settings.length.short":         "curta",
+		"settings.length.detailed":      "detalhada",
+		"settings.temperature.default":  "padrão do bot",
+		"settings.temperature.precise":  "precisa",
+		"settings.temperature.balanced": "equilibrada",
+		"settings.temperature.creative": "criativa",
+		"settings.persona.default":      "assistente padrão",
+		"settings.persona.teacher":      "professor",
+		"settings.persona.developer":    "desenvolvedor",
+		"settings.persona.casual":       "descontraído",
+		"settings.autonew.off":          "desativado",
+		"settings.autonew.hours":        "após %s h sem mensagens",
+		"persona.style.teacher":         "Adote o tom de um professor paciente: explique passo a passo e use exemplos.",
+		"persona.style.developer":       "Responda como um desenvolvedor de software experiente: seja técnico e preciso e use blocos de código quando fizer sentido.",
+		"persona.style.casual":          "Use um tom descontraído e amigável, como numa conversa entre amigos.",
+		"persona.length.short":          "Responda de forma curta e direta, em poucas frases.",
+		"persona.length.detailed":       "Responda de forma detalhada e completa, cobrindo todos os pontos relevantes.",
+
+		// Avaliação das respostas
+		"feedback.thanks":              "Obrigado pela avaliação!",
+		"feedback.comment_prompt":      "%s, quer contar o que achou da resposta? Responda a esta mensagem com um comentário (opcional).",
+		"feedback.comment_placeholder": "Seu comentário sobre a resposta",
+		"feedback.comment_saved":       "📝 Comentário registrado. Obrigado!",
+
+		// Perguntas editadas
+		"edit.not_latest": "✏️ Só refaço a resposta ao editar a última pergunta da conversa. Envie a pergunta corrigida como uma nova mensagem.",
+
+		"persona.default": "Você é o Orbi AI, um assistente virtual prestativo no Telegram. " +
+			"Responda sempre em português do Brasil, a menos que o usuário peça explicitamente outro idioma.",
+	},
+	"en": {
+		"error.generic":              "Sorry, something went wrong while processing your message. Please try again later.",
+		"newchat.started":            "✨ New chat started! You can start talking.",
+		"queue.position":             "⏳ Your message is in the queue (position #%d). I'll answer as soon as possible.",
+		"job.lost":                   "⚠️ I couldn't finish answering \"%s\" before a restart. Please send your question again.",
+		"inline.title":               "Orbi AI answer",
+		"inline.message":             "❓ %s\n\n%s",
+		"inline.pending_title":       "⏳ Still thinking…",
+		"inline.pending_description": "Tap to ask directly in the chat with the bot",
+		"inline.pending_message":     "❓ %s\n\nAsk the bot to see the answer.",
+		"inline.open_bot":            "💬 Open the bot",
+		"callback.expired":           "⌛ This button has expired. Please send the command again.",
+		"response.header":            "Answer for %s:\n\n%s", // HTML
+		"button.full_answer":         "📝 View Full Answer",
+		"button.open_answer":         "🔍 Tap here to open the answer",
+		"button.history":             "📱 Open history",
+		"start.found":                "📝 *Answer found\\!*\n\n%s\n\n _Tap the button below to see the full answer_", // MarkdownV2
+		"welcome.name":               "there",
+		"welcome.text": "Hi, %s! 👋\n\nI'm Orbi AI, your virtual assistant. Ask me anything!\n\n" +
+			"Available commands:\n" +
+			"/newchat - Start a new conversation\n" +
+			"/schedule - Schedule prompts and reminders\n" +
+			"/schedules - List your schedules\n" +
+			"/language - Change the bot language\n" +
+			"/help - List all commands",
+		"format.datetime": "2006-01-02 15:04",
+
+		"schedule.usage": "Usage:\n" +
+			"/schedule daily 08:00 <prompt> - every day at the given time\n" +
+			"/schedule once 14:30 <prompt> - once, at the next occurrence of the time\n" +
+			"/schedule once 2025-12-24 20:00 <prompt> - once, on the given date\n" +
+			"/schedule in 30m <prompt> - once, after an interval (e.g. 45m, 2h)\n\n" +
+			"/schedules - list your schedules\n" +
+			"/unschedule <id> - remove a schedule\n" +
+			"/timezone <zone> - set your time zone (e.g. America/New_York)",
+		"schedule.incomplete":       "Incomplete schedule",
+		"schedule.date_past":        "The given date is in the past",
+		"schedule.invalid_interval": "Invalid interval: %s (use, for example, 30m or 2h)",
+		"schedule.unknown_kind":     "Unknown schedule type: %s",
+		"schedule.missing_prompt":   "Please provide the prompt to run",
+		"schedule.invalid_time":     "Invalid time: %s (use the HH:MM format)",
+		"schedule.created":          "⏰ Schedule #%d created!\nNext run: %s (%s)",
+		"schedule.none":             "You have no active schedules.",
+		"schedule.list_header":      "⏰ Your schedules:\n",
+		"schedule.once":             "once",
+		"schedule.daily":            "daily at %s",
+		"schedule.list_item":        "\n#%d - %s - next: %s\n%s\n",
+		"schedule.unschedule_usage": "Please provide the schedule number. E.g.: /unschedule 3",
+		"schedule.not_found":        "Schedule #%d not found.",
+		"schedule.removed":          "🗑 Schedule #%d removed.",
+
+		"timezone.current": "🌍 Your time zone: %s\nTo change it: /timezone America/New_York",
+		"timezone.set":     "🌍 Time zone set to %s.\nExisting schedules keep the time zone they were created with.",
+		"timezone.unknown": "Unknown time zone: %s\nUse an IANA name, such as America/New_York or Europe/London.",
+
+		"language.current": "🌐 Current language: %s\n\nAvailable languages:\n%s\nTo change it: /language es",
+		"language.set":     "🌐 Language changed to %s.",
+		"language.unknown": "Unsupported language: %s\n\nAvailable languages:\n%s",
+
+		"help.header":         "📖 Available commands:",
+		"command.unknown":     "Unknown command: /%s\nUse /help to see the available commands.",
+		"command.not_allowed": "The /%s command is not available here.",
+		"command.start":       "Shows the welcome message",
+		"command.help":        "Lists the available commands",
+		"command.newchat":     "Starts a new conversation",
+		"command.language":    "Changes the bot language",
+		"command.schedule":    "Schedules prompts and reminders",
+		"command.schedules":   "Lists your schedules",
+		"command.unschedule":  "Removes a schedule",
+		"command.timezone":    "Sets your time zone",
+
+		"command.delivery":      "Chooses how answers are delivered",
+		"delivery.current":      "📨 Current delivery mode: %s\n\nAvailable modes:\n%s",
+		"delivery.set":          "📨 Delivery mode changed to: %s",
+		"delivery.unknown":      "Unknown delivery mode: %s\n\nAvailable modes:\n%s",
+		"delivery.mode.preview": "preview with a button to the app",
+		"delivery.mode.full":    "full answer in the chat",
+		"delivery.mode.auto":    "automatic (full when short)",
+
+		"command.group":         "Configure the bot in this group",
+		"group.settings":        "👥 Group settings\n\nConversation mode: %s\nTriggers: %s\nPrefix: %s\nKeywords: %s",
+		"group.usage":           "To change (admins only):\n/group mode user|shared\n/group triggers mention,reply,prefix,keyword\n/group prefix !ai\n/group keywords bot,help",
+		"group.not_admin":       "Only group admins can change the settings.",
+		"group.updated":         "✅ Group settings updated.",
+		"group.mode.user":       "individual history per member",
+		"group.mode.shared":     "history shared by the group",
+		"group.invalid_mode":    "Unknown conversation mode: %s\nUse user or shared.",
+		"group.invalid_trigger": "Unknown trigger: %s\nAvailable triggers: %s",
+		"group.none":            "none",
+
+		"command.access":              "Manage the bot access policy",
+		"command.invite":              "Create and manage access invites",
+		"command.ban":                 "Ban a user from the bot",
+		"command.unban":               "Lift a user ban",
+		"access.denied":               "🔒 This bot has restricted access. Ask an admin for an invite.",
+		"access.banned":               "🚫 You have been blocked and cannot use this bot.",
+		"access.summary":              "🔐 Bot access\n\nMode: %s",
+		"access.mode.open":            "open to everyone except banned users",
+		"access.mode.allowlist":       "allowed users and groups only",
+		"access.list.user":            "Allowed users (%d):",
+		"access.list.group":           "Allowed groups (%d):",
+		"access.list.banned":          "Banned users (%d):",
+		"access.usage":                "Usage:\n/access\n/access mode open|allowlist\n/access allow <id>\n/access remove <id>\n/access allowgroup [id]\n/access removegroup [id]",
+		"access.invalid_mode":         "Unknown access mode: %s\nUse open or allowlist.",
+		"access.mode_updated":         "✅ Access mode changed: %s",
+		"access.unchanged":            "Nothing to change for %d.",
+		"access.updated.allow_user":   "✅ User %d allowed.",
+		"access.updated.remove_user":  "✅ User %d removed from the access list.",
+		"access.updated.allow_group":  "✅ Group %d allowed.",
+		"access.updated.remove_group": "✅ Group %d removed from the access list.",
+		"access.updated.ban":          "🚫 User %d banned.",
+		"access.updated.unban":        "✅ Ban lifted for user %d.",
+		"ban.usage":                   "Usage: /ban <id> [reason], or reply to a message from the user with /ban [reason]",
+		"ban.unban_usage":             "Usage: /unban <id>",
+		"invite.usage":                "Usage:\n/invite [uses] (0 = unlimited)\n/invite list\n/invite revoke <code>",
+		"invite.created":              "🎟️ Invite created (uses: %[2]s):\n%[1]s",
+		"invite.unlimited":            "unlimited",
+		"invite.list_header":          "🎟️ Invites:",
+		"invite.empty":                "No invites created.",
+		"invite.revoked":              "Invite %s revoked.",
+		"invite.revoked_flag":         "revoked",
+		"invite.not_found":            "Invite not found: %s",
+		"invite.invalid":              "This invite is invalid, was revoked or has already been used.",
+		"invite.redeemed":             "✅ Invite accepted! You can now use the bot.",
+		"invite.already":              "You already have access to the bot.",
+
+		"command.stats":              "Show bot usage statistics",
+		"command.user":               "Show a user's usage and status",
+		"command.maintenance":        "Turn maintenance mode on or off",
+		"command.provider":           "Show the AI provider and key health",
+		"stats.summary":              "📊 Statistics\n\nUsers: %d (%d active today)\nConversations: %d (%d groups)\nQuestions today: %d (%d messages)\nProvider errors today: %d awaiting retry, %d dropped\nQueue: %d running, %d waiting",
+		"user.usage":                 "Usage: /user <id>",
+		"user.summary":               "👤 User %d\n\nStatus: %s\nConversations: %d\nQuestions: %d (%d today)\nLast activity: %s\nActive schedules: %d",
+		"user.never":                 "never",
+		"user.language":              "Language: %s",
+		"user.timezone":              "Time zone: %s",
+		"user.status.admin":          "admin",
+		"user.status.allowed":        "allowed",
+		"user.status.not_allowed":    "no access",
+		"user.status.banned":         "banned",
+		"maintenance.active":         "🛠️ The bot is under maintenance. Please try again in a few minutes.",
+		"maintenance.usage":          "Usage: /maintenance on|off",
+		"maintenance.status_on":      "🛠️ Maintenance mode is on.",
+		"maintenance.status_off":     "Maintenance mode is off.",
+		"maintenance.turned_on":      "🛠️ Maintenance mode on. Only admins will be answered.",
+		"maintenance.turned_off":     "✅ Maintenance mode off.",
+		"provider.summary":           "🤖 Provider: %s\nModel: %s",
+		"provider.key":               "🔑 %s: %s\nRequests: %d, failures: %d, quota limits: %d",
+		"provider.key.available":     "available",
+		"provider.key.benched":       "benched",
+		"provider.key.benched_until": "benched until %s",
+		"provider.key.last_error":    "Last error: %s",
+		"provider.queue":             "Queue: %d/%d running, %d waiting, %d processed",
+
+		"command.broadcast":          "Send an announcement to all users",
+		"broadcast.usage":            "Usage:\n/broadcast <message>\n/broadcast cancel <id>\n\nTo add a button, end the message with a line like [Button text](https://example.com).",
+		"broadcast.confirm":          "👆 Announcement preview. Send it to %d users?",
+		"broadcast.button.send":      "📣 Send to %d",
+		"broadcast.button.cancel":    "Cancel",
+		"broadcast.queued":           "📣 Broadcast #%d is being sent. I'll let you know when it's done.",
+		"broadcast.not_draft":        "Broadcast #%d was already sent or cancelled.",
+		"broadcast.cancelled":        "Broadcast #%d cancelled.",
+		"broadcast.not_found":        "Broadcast #%d not found or already finished.",
+		"broadcast.finished":         "✅ Broadcast #%d finished\n\nDelivered: %d\nBlocked: %d\nFailed: %d",
+		"broadcast.empty":            "No broadcasts sent.",
+		"broadcast.list_header":      "📣 Recent broadcasts:",
+		"broadcast.item":             "#%d (%s): %d delivered, %d blocked, %d failed of %d\n%s",
+		"broadcast.status.draft":     "awaiting confirmation",
+		"broadcast.status.pending":   "queued",
+		"broadcast.status.running":   "sending",
+		"broadcast.status.done":      "finished",
+		"broadcast.status.cancelled": "cancelled",
+
+		// Preferências do /settings
+		"command.settings":              "Opens the preferences menu",
+		"settings.title":                "⚙️ Your preferences:\n",
+		"settings.line":                 "• %s: %s",
+		"settings.choose":               "⚙️ %s\n\nChoose an option:",
+		"settings.saved":                "Preference saved",
+		"settings.back":                 "⬅️ Back",
+		"settings.close":                "✖️ Close",
+		"settings.option.delivery":      "📨 Delivery",
+		"settings.option.length":        "📏 Length",
+		"settings.option.temperature":   "🎨 Creativity",
+		"settings.option.language":      "🌐 Language",
+		"settings.option.persona":       "🎭 Persona",
+		"settings.option.autonew":       "🕒 New chat",
+		"settings.length.normal":        "normal",
+		"settings.length.short":         "short",
+		"settings.length.detailed":      "detailed",
+		"settings.temperature.default":  "bot default",
+		"settings.temperature.precise":  "precise",
+		"settings.temperature.balanced": "balanced",
+		"settings.temperature.creative": "creative",
+		"settings.persona.default":      "default assistant",
+		"settings.persona.teacher":      "teacher",
+		"settings.persona.developer":    "developer",
+		"settings.persona.casual":       "casual",
+		"settings.autonew.off":          "off",
+		"settings.autonew.hours":        "after %s h without messages",
+		"persona.style.teacher":         "Take the tone of a patient teacher: explain step by step and use examples.",
+		"persona.style.developer":       "Answer as an experienced software developer: be technical and precise and use code blocks when it makes sense.",
+		"persona.style.casual":          "Use a relaxed, friendly tone, like a chat between friends.",
+		"persona.length.short":          "Keep the answer short and direct, in a few sentences.",
+		"persona.length.detailed":       "Give a detailed, thorough answer covering all relevant points.",
+
+		// Avaliação das respostas
+		"feedback.thanks":              "Thanks for the rating!",
+		"feedback.comment_prompt":      "%s, want to tell us what you thought of the answer? Reply to this message with a comment (optional).",
+		"feedback.comment_placeholder": "Your comment on the answer",
+		"feedback.comment_saved":       "📝 Comment saved. Thank you!",
+
+		// Perguntas editadas
+		"edit.not_latest": "✏️ I only redo the answer when you edit the latest question in the conversation. Send the corrected question as a new message.",
+
+		"persona.default": "You are Orbi AI, a helpful virtual assistant on Telegram. " +
+			"Always answer in English, unless the user explicitly asks for another language.",
+	},
+	"es": {
+		"error.generic":              "Lo siento, ocurrió un error al procesar tu mensaje. Inténtalo de nuevo más tarde.",
+		"newchat.started":            "✨ ¡Nuevo chat iniciado! Puedes empezar a conversar.",
+		"queue.position":             "⏳ Tu mensaje está en la cola (posición #%d). Responderé lo antes posible.",
+		"job.lost":                   "⚠️ No pude terminar la respuesta para \"%s\" antes de un reinicio. Por favor, envía la pregunta de nuevo.",
+		"inline.title":               "Respuesta de Orbi AI",
+		"inline.message":             "❓ %s\n\n%s",
+		"inline.pending_title":       "⏳ Todavía pensando…",
+		"inline.pending_description": "Toca para preguntar directamente en el chat con el bot",
+		"inline.pending_message":     "❓ %s\n\nPregunta al bot para ver la respuesta.",
+		"inline.open_bot":            "💬 Abrir el bot",
+		"callback.expired":           "⌛ Este botón ha expirado. Envía el comando de nuevo.",
+		"response.header":            "Respuesta para %s:\n\n%s", // HTML
+		"button.full_answer":         "📝 Ver Respuesta Completa",
+		"button.open_answer":         "🔍 Toca aquí para abrir la respuesta",
+		"button.history":             "📱 Abrir historial",
+		"start.found":                "📝 *¡Respuesta encontrada\\!*\n\n%s\n\n _Toca el botón de abajo para ver la respuesta completa_", // MarkdownV2
+		"welcome.name":               "usuario",
+		"welcome.text": "¡Hola, %s! 👋\n\nSoy Orbi AI, tu asistente virtual. ¡Puedes preguntarme sobre cualquier tema!\n\n" +
+			"Comandos disponibles:\n" +
+			"/newchat - Inicia una nueva conversación\n" +
+			"/schedule - Programa prompts y recordatorios\n" +
+			"/schedules - Lista tus programaciones\n" +
+			"/language - Cambia el idioma del bot\n" +
+			"/help - Lista todos los comandos",
+		"format.datetime": "02/01/2006 15:04",
+
+		"schedule.usage": "Uso:\n" +
+			"/schedule daily 08:00 <prompt> - todos los días a la hora indicada\n" +
+			"/schedule once 14:30 <prompt> - una vez, en la próxima ocurrencia de la hora\n" +
+			"/schedule once 2025-12-24 20:00 <prompt> - una vez, en la fecha indicada\n" +
+			"/schedule in 30m <prompt> - una vez, después de un intervalo (ej: 45m, 2h)\n\n" +
+			"/schedules - lista tus programaciones\n" +
+			"/unschedule <id> - elimina una programación\n" +
+			"/timezone <zona> - define tu zona horaria (ej: America/Mexico_City)",
+		"schedule.incomplete":       "Programación incompleta",
+		"schedule.date_past":        "La fecha indicada ya pasó",
+		"schedule.invalid_interval": "Intervalo inválido: %s (usa, por ejemplo, 30m o 2h)",
+		"schedule.unknown_kind":     "Tipo de programación desconocido: %s",
+		"schedule.missing_prompt":   "Indica el prompt que se debe ejecutar",
+		"schedule.invalid_time":     "Hora inválida: %s (usa el formato HH:MM)",
+		"schedule.created":          "⏰ ¡Programación #%d creada!\nPróxima ejecución: %s (%s)",
+		"schedule.none":             "No tienes programaciones activas.",
+		"schedule.list_header":      "⏰ Tus programaciones:\n",
+		"schedule.once":             "una vez",
+		"schedule.daily":            "diario a las %s",
+		"schedule.list_item":        "\n#%d - %s - próxima: %s\n%s\n",
+		"schedule.unschedule_usage": "Indica el número de la programación. Ej: /unschedule 3",
+		"schedule.not_found":        "Programación #%d no encontrada.",
+		"schedule.removed":          "🗑 Programación #%d eliminada.",
+
+		"timezone.current": "🌍 Tu zona horaria: %s\nPara cambiarla: /timezone America/Mexico_City",
+		"timezone.set":     "🌍 Zona horaria definida como %s.\nLas programaciones existentes mantienen la zona con la que fueron creadas.",
+		"timezone.unknown": "Zona horaria desconocida: %s\nUsa un nombre IANA, como America/Mexico_City o Europe/Madrid.",
+
+		"language.current": "🌐 Idioma actual: %s\n\nIdiomas disponibles:\n%s\nPara cambiarlo: /language pt-BR",
+		"language.set":     "🌐 Idioma cambiado a %s.",
+		"language.unknown": "Idioma no soportado: %s\n\nIdiomas disponibles:\n%s",
+
+		"help.header":         "📖 Comandos disponibles:",
+		"command.unknown":     "Comando desconocido: /%s\nUsa /help para ver los comandos disponibles.",
+		"command.not_allowed": "El comando /%s no está disponible aquí.",
+		"command.start":       "Muestra el mensaje de bienvenida",
+		"command.help":        "Lista los comandos disponibles",
+		"command.newchat":     "Inicia una nueva conversación",
+		"command.language":    "Cambia el idioma del bot",
+		"command.schedule":    "Programa prompts y recordatorios",
+		"command.schedules":   "Lista tus programaciones",
+		"command.unschedule":  "Elimina una programación",
+		"command.timezone":    "Define tu zona horaria",
+
+		"command.delivery":      "Elige cómo se entregan las respuestas",
+		"delivery.current":      "📨 Modo de entrega actual: %s\n\nModos disponibles:\n%s",
+		"delivery.set":          "📨 Modo de entrega cambiado a: %s",
+		"delivery.unknown":      "Modo de entrega desconocido: %s\n\nModos disponibles:\n%s",
+		"delivery.mode.preview": "vista previa con botón a la app",
+		"delivery.mode.full":    "respuesta completa en el chat",
+		"delivery.mode.auto":    "automático (completa si es corta)",
+
+		"command.group":         "Configura el bot en este grupo",
+		"group.settings":        "👥 Configuración del grupo\n\nModo de conversación: %s\nActivadores: %s\nPrefijo: %s\nPalabras clave: %s",
+		"group.usage":           "Para cambiar (solo administradores):\n/group mode user|shared\n/group triggers mention,reply,prefix,keyword\n/group prefix !ai\n/group keywords bot,ayuda",
+		"group.not_admin":       "Solo los administradores del grupo pueden cambiar la configuración.",
+		"group.updated":         "✅ Configuración del grupo actualizada.",
+		"group.mode.user":       "historial individual por participante",
+		"group.mode.shared":     "historial compartido por el grupo",
+		"group.invalid_mode":    "Modo de conversación desconocido: %s\nUsa user o shared.",
+		"group.invalid_trigger": "Activador desconocido: %s\nActivadores disponibles: %s",
+		"group.none":            "ninguno",
+
+		"command.access":              "Gestiona la política de acceso al bot",
+		"command.invite":              "Crea y gestiona invitaciones de acceso",
+		"command.ban":                 "Bloquea a un usuario del bot",
+		"command.unban":               "Quita el bloqueo de un usuario",
+		"access.denied":               "🔒 Este bot tiene acceso restringido. Pide una invitación a un administrador.",
+		"access.banned":               "🚫 Has sido bloqueado y no puedes usar este bot.",
+		"access.summary":              "🔐 Acceso al bot\n\nModo: %s",
+		"access.mode.open":            "abierto a todos, excepto bloqueados",
+		"access.mode.allowlist":       "solo usuarios y grupos autorizados",
+		"access.list.user":            "Usuarios autorizados (%d):",
+		"access.list.group":           "Grupos autorizados (%d):",
+		"access.list.banned":          "Usuarios bloqueados (%d):",
+		"access.usage":                "Uso:\n/access\n/access mode open|allowlist\n/access allow <id>\n/access remove <id>\n/access allowgroup [id]\n/access removegroup [id]",
+		"access.invalid_mode":         "Modo de acceso desconocido: %s\nUsa open o allowlist.",
+		"access.mode_updated":         "✅ Modo de acceso cambiado: %s",
+		"access.unchanged":            "Nada que cambiar para %d.",
+		"access.updated.allow_user":   "✅ Usuario %d autorizado.",
+		"access.updated.remove_user":  "✅ Usuario %d eliminado de la lista de acceso.",
+		"access.updated.allow_group":  "✅ Grupo %d autorizado.",
+		"access.updated.remove_group": "✅ Grupo %d eliminado de la lista de acceso.",
+		"access.updated.ban":          "🚫 Usuario %d bloqueado.",
+		"access.updated.unban":        "✅ Bloqueo del usuario %d eliminado.",
+		"ban.usage":                   "Uso: /ban <id> [motivo], o responde a un mensaje del usuario con /ban [motivo]",
+		"ban.unban_usage":             "Uso: /unban <id>",
+		"invite.usage":                "Uso:\n/invite [usos] (0 = ilimitado)\n/invite list\n/invite revoke <código>",
+		"invite.created":              "🎟️ Invitación creada (usos: %[2]s):\n%[1]s",
+		"invite.unlimited":            "ilimitados",
+		"invite.list_header":          "🎟️ Invitaciones:",
+		"invite.empty":                "No hay invitaciones creadas.",
+		"invite.revoked":              "Invitación %s revocada.",
+		"invite.revoked_flag":         "revocada",
+		"invite.not_found":            "Invitación no encontrada: %s",
+		"invite.invalid":              "Esta invitación no es válida, fue revocada o ya se usó.",
+		"invite.redeemed":             "✅ ¡Invitación aceptada! Ya puedes usar el bot.",
+		"invite.already":              "Ya tienes acceso al bot.",
+
+		"command.stats":              "Muestra las estadísticas de uso del bot",
+		"command.user":               "Muestra el uso y la situación de un usuario",
+		"command.maintenance":        "Activa o desactiva el modo de mantenimiento",
+		"command.provider":           "Muestra el proveedor de IA y el estado de las claves",
+		"stats.summary":              "📊 Estadísticas\n\nUsuarios: %d (%d activos hoy)\nConversaciones: %d (%d grupos)\nPreguntas hoy: %d (%d mensajes)\nErrores del proveedor hoy: %d esperando reintento, %d descartadas\nCola: %d en curso, %d esperando",
+		"user.usage":                 "Uso: /user <id>",
+		"user.summary":               "👤 Usuario %d\n\nSituación: %s\nConversaciones: %d\nPreguntas: %d (%d hoy)\nÚltima actividad: %s\nProgramaciones activas: %d",
+		"user.never":                 "nunca",
+		"user.language":              "Idioma: %s",
+		"user.timezone":              "Zona horaria: %s",
+		"user.status.admin":          "administrador",
+		"user.status.allowed":        "con acceso",
+		"user.status.not_allowed":    "sin acceso",
+		"user.status.banned":         "bloqueado",
+		"maintenance.active":         "🛠️ El bot está en mantenimiento. Inténtalo de nuevo en unos minutos.",
+		"maintenance.usage":          "Uso: /maintenance on|off",
+		"maintenance.status_on":      "🛠️ El modo de mantenimiento está activado.",
+		"maintenance.status_off":     "El modo de mantenimiento está desactivado.",
+		"maintenance.turned_on":      "🛠️ Modo de mantenimiento activado. Solo se atenderá a los administradores.",
+		"maintenance.turned_off":     "✅ Modo de mantenimiento desactivado.",
+		"provider.summary":           "🤖 Proveedor: %s\nModelo: %s",
+		"provider.key":               "🔑 %s: %s\nSolicitudes: %d, fallos: %d, límites de cuota: %d",
+		"provider.key.available":     "disponible",
+		"provider.key.benched":       "apartada",
+		"provider.key.benched_until": "apartada hasta %s",
+		"provider.key.last_error":    "Último error: %s",
+		"provider.queue":             "Cola: %d/%d en curso, %d esperando, %d procesadas",
+
+		"command.broadcast":          "Envía un aviso a todos los usuarios",
+		"broadcast.usage":            "Uso:\n/broadcast <mensaje>\n/broadcast cancel <id>\n\nPara incluir un botón, termina el mensaje con una línea como [Texto del botón](https://ejemplo.com).",
+		"broadcast.confirm":          "👆 Vista previa del aviso. ¿Confirmas el envío a %d usuarios?",
+		"broadcast.button.send":      "📣 Enviar a %d",
+		"broadcast.button.cancel":    "Cancelar",
+		"broadcast.queued":           "📣 Broadcast #%d en envío. Te avisaré cuando termine.",
+		"broadcast.not_draft":        "El broadcast #%d ya fue enviado o cancelado.",
+		"broadcast.cancelled":        "Broadcast #%d cancelado.",
+		"broadcast.not_found":        "Broadcast #%d no encontrado o ya finalizado.",
+		"broadcast.finished":         "✅ Broadcast #%d finalizado\n\nEntregados: %d\nBloqueados: %d\nFallos: %d",
+		"broadcast.empty":            "No se ha enviado ningún broadcast.",
+		"broadcast.list_header":      "📣 Broadcasts recientes:",
+		"broadcast.item":             "#%d (%s): %d entregados, %d bloqueados, %d fallos de %d\n%s",
+		"broadcast.status.draft":     "esperando confirmación",
+		"broadcast.status.pending":   "en cola",
+		"broadcast.status.running":   "enviando",
+		"broadcast.status.done":      "finalizado",
+		"broadcast.status.cancelled": "cancelado",
+
+		// Preferências do /settings
+		"command.settings":              "Abre el menú de preferencias",
+		"settings.title":                "⚙️ Tus preferencias:\n",
+		"settings.line":                 "• %s: %s",
+		"settings.choose":               "⚙️ %s\n\nElige una opción:",
+		"settings.saved":                "Preferencia guardada",
+		"settings.back":                 "⬅️ Volver",
+		"settings.close":                "✖️ Cerrar",
+		"settings.option.delivery":      "📨 Entrega",
+		"settings.option.length":        "📏 Longitud",
+		"settings.option.temperature":   "🎨 Creatividad",
+		"settings.option.language":      "🌐 Idioma",
+		"settings.option.persona":       "🎭 Persona",
+		"settings.option.autonew":       "🕒 Chat nuevo",
+		"settings.length.normal":        "normal",
+		"settings.length.short":         "corta",
+		"settings.length.detailed":      "detallada",
+		"settings.temperature.default":  "predeterminada del bot",
+		"settings.temperature.precise":  "precisa",
+		"settings.temperature.balanced": "equilibrada",
+		"settings.temperature.creative": "creativa",
+		"settings.persona.default":      "asistente predeterminado",
+		"settings.persona.teacher":      "profesor",
+		"settings.persona.developer":    "desarrollador",
+		"settings.persona.casual":       "informal",
+		"settings.autonew.off":          "desactivado",
+		"settings.autonew.hours":        "tras %s h sin mensajes",
+		"persona.style.teacher":         "Adopta el tono de un profesor paciente: explica paso a paso y usa ejemplos.",
+		"persona.style.developer":       "Responde como un desarrollador de software experimentado: sé técnico y preciso y usa bloques de código cuando tenga sentido.",
+		"persona.style.casual":          "Usa un tono relajado y amistoso, como en una charla entre amigos.",
+		"persona.length.short":          "Responde de forma corta y directa, en pocas frases.",
+		"persona.length.detailed":       "Responde de forma detallada y completa, cubriendo todos los puntos relevantes.",
+
+		// Avaliação das respostas
+		"feedback.thanks":              "¡Gracias por la valoración!",
+		"feedback.comment_prompt":      "%s, ¿quieres contarnos qué te pareció la respuesta? Responde a este mensaje con un comentario (opcional).",
+		"feedback.comment_placeholder": "Tu comentario sobre la respuesta",
+		"feedback.comment_saved":       "📝 Comentario registrado. ¡Gracias!",
+
+		// Perguntas editadas
+		"edit.not_latest": "✏️ Solo rehago la respuesta al editar la última pregunta de la conversación. Envía la pregunta corregida como un mensaje nuevo.",
+
+		"persona.default": "Eres Orbi AI, un asistente virtual servicial en Telegram. " +
+			"Responde siempre en español, a menos que el usuario pida explícitamente otro idioma.",
+	},
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // Garante os fusos horários dos agendamentos mesmo sem zoneinfo no sistema
 
 	"bot-ai/config"
 	"bot-ai/database"
@@ -14,21 +18,28 @@ func initializeAIService(cfg *config.Config, db *database.Database) models.AISer
 	// Verifica qual serviço deve ser usado com base na configuração
 	switch cfg.AIService {
 	case "azure":
-		if strings.TrimSpace(cfg.AzureOpenAIKey) == "" {
-			log.Fatal("Serviço Azure OpenAI selecionado mas AZURE_OPENAI_API_KEY não está configurada")
+		if len(cfg.AzureOpenAIKeys) == 0 {
+			log.Fatal("Serviço Azure OpenAI selecionado mas AZURE_OPENAI_API_KEYS (ou AZURE_OPENAI_API_KEY) não está configurada")
 		}
-		log.Println("Usando Azure OpenAI como serviço de IA")
+		log.Printf("Usando Azure OpenAI como serviço de IA (%d chave(s))", len(cfg.AzureOpenAIKeys))
 		return services.NewAzureOpenAIService(cfg, db)
 
 	case "google":
-		if strings.TrimSpace(cfg.GeminiApiKey) == "" {
-			log.Fatal("Serviço Google Gemini selecionado mas GEMINI_API_KEY não está configurada")
+		if len(cfg.GeminiApiKeys) == 0 {
+			log.Fatal("Serviço Google Gemini selecionado mas GEMINI_API_KEYS (ou GEMINI_API_KEY) não está configurada")
 		}
-		log.Println("Usando Google Gemini como serviço de IA")
+		log.Printf("Usando Google Gemini como serviço de IA (%d chave(s))", len(cfg.GeminiApiKeys))
 		return services.NewGeminiService(cfg, db)
 
+	case "fake":
+		if cfg.FakeAIMode != "echo" && cfg.FakeAIMode != "script" {
+			log.Fatalf("Modo '%s' do serviço fake não suportado. Use 'echo' ou 'script' na variável FAKE_AI_MODE", cfg.FakeAIMode)
+		}
+		log.Printf("Usando serviço de IA fake (modo=%s) - apenas para desenvolvimento e testes", cfg.FakeAIMode)
+		return services.NewFakeAIService(cfg, db)
+
 	default:
-		log.Fatalf("Serviço de IA '%s' não suportado. Use 'google' ou 'azure' na variável AI_SERVICE", cfg.AIService)
+		log.Fatalf("Serviço de IA '%s' não suportado. Use 'google', 'azure' ou 'fake' na variável AI_SERVICE", cfg.AIService)
 		return nil
 	}
 }
@@ -37,6 +48,16 @@ func main() {
 	// Carregar configurações
 	cfg := config.LoadConfig()
 
+	switch cfg.TelegramMode {
+	case "polling":
+	case "webhook":
+		if cfg.TelegramWebhookURL == "" {
+			log.Fatal("Modo webhook selecionado mas TELEGRAM_WEBHOOK_URL não está configurada")
+		}
+	default:
+		log.Fatalf("Modo '%s' do Telegram não suportado. Use 'polling' ou 'webhook' na variável TELEGRAM_MODE", cfg.TelegramMode)
+	}
+
 	// Inicializar banco de dados
 	db, err := database.NewDatabase("messages.db")
 	if err != nil {
@@ -50,18 +71,41 @@ func main() {
 	// Inicializar o serviço de IA apropriado
 	aiService := initializeAIService(cfg, db)
 
+	// Fila de processamento das perguntas, compartilhada entre o bot e a API administrativa
+	dispatcher := services.NewDispatcher(cfg.MaxConcurrentAI)
+
 	// Inicializar serviço do Telegram
-	telegramService, err := services.NewTelegramService(cfg, db, aiService)
+	telegramService, err := services.NewTelegramService(cfg, db, aiService, dispatcher)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Retomar perguntas interrompidas pela última parada do processo
+	telegramService.ResumeJobs()
+
+	// Iniciar execução dos prompts agendados
+	telegramService.StartScheduler(cfg.ScheduleCheckInterval)
+
+	// Iniciar envio dos broadcasts, retomando os interrompidos
+	telegramService.StartBroadcaster()
+
 	// Inicializar servidor HTTP
-	httpServer := services.NewHTTPServer(cfg, db)
+	httpServer := services.NewHTTPServer(cfg, db, aiService, dispatcher)
+	if cfg.TelegramMode == "webhook" {
+		httpServer.SetWebhookHandler(telegramService.WebhookHandler())
+	}
 
 	// Iniciar servidor HTTP em uma goroutine
 	go httpServer.Start()
 
-	// Iniciar o bot do Telegram
-	telegramService.Start()
+	// Iniciar o bot do Telegram até receber um sinal de parada
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := telegramService.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Encerrando o bot...")
+	telegramService.Shutdown()
 }
@@ -1,13 +1,46 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // AIService interface comum para serviços de IA
 type AIService interface {
-	AskWithRetry(userID int64, question string) (string, string, error) // Retorna (resposta, hash, erro)
-	NewChat(userID int64) error
+	Ask(userID int64, question string, opts AskOptions) (string, string, error)          // Uma única tentativa; quem chama decide se tenta de novo
+	AskWithRetry(userID int64, question string, opts AskOptions) (string, string, error) // Retorna (resposta, hash, erro)
+	NewChat(userID int64, topic Topic) error
+	Complete(prompt string) (string, error) // Gera uma resposta única, sem histórico e sem persistência
+}
+
+// AskOptions reúne o contexto usado pelos serviços de IA ao montar a requisição
+type AskOptions struct {
+	Locale string // Idioma do usuário, usado no prompt de persona (ex: "pt-BR")
+
+	// Autor da pergunta, preenchido apenas em conversas compartilhadas de grupo
+	AuthorID   int64
+	AuthorName string
+
+	// Chat do histórico a continuar, quando a pergunta responde a uma resposta
+	// antiga do bot. Zero usa o chat ativo
+	ChatHistoryID int64
+
+	// Tópico de fórum da pergunta. Cada tópico tem o próprio chat ativo
+	Topic Topic
+
+	// Pergunta editada no Telegram: o turno é reescrito e a resposta gerada de
+	// novo, sem criar um turno novo. Zero é uma pergunta nova
+	ReplaceTurnID int64
+
+	// Preferências do autor definidas no /settings. Nil usa apenas a configuração global
+	Preferences *UserSettings
+}
+
+// Topic identifica um tópico de fórum de um supergrupo. O valor zero representa
+// as conversas fora de tópicos
+type Topic struct {
+	ChatID   int64
+	ThreadID int
 }
 
 // Message representa uma mensagem armazenada no banco de dados
@@ -25,6 +58,7 @@ type ChatHistory struct {
 	UserID         int64     `json:"user_id"`
 	IsActive       bool      `json:"is_active"`
 	PreviewMessage string    `json:"preview_message"`
+	Title          string    `json:"title"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -35,23 +69,38 @@ type ChatMessage struct {
 	ChatHistoryID int64     `json:"chat_history_id"`
 	Role          string    `json:"role"`
 	Content       string    `json:"content"`
+	AuthorID      int64     `json:"author_id,omitempty"`   // Autor da mensagem em conversas compartilhadas de grupo
+	AuthorName    string    `json:"author_name,omitempty"` // Nome exibido do autor, enviado ao modelo junto com o conteúdo
+	Superseded    bool      `json:"superseded,omitempty"`  // Resposta substituída após a edição da pergunta; não vai mais ao modelo
 	CreatedAt     time.Time `json:"created_at"`
 }
 
 // TelegramMessage representa uma mensagem do Telegram
 type TelegramMessage struct {
-	MessageID int           `json:"message_id"`
-	From      *TelegramUser `json:"from"`
-	Chat      *TelegramChat `json:"chat"`
-	Text      string        `json:"text"`
+	MessageID      int              `json:"message_id"`
+	From           *TelegramUser    `json:"from"`
+	Chat           *TelegramChat    `json:"chat"`
+	Text           string           `json:"text"`
+	ReplyToMessage *TelegramMessage `json:"reply_to_message,omitempty"`
+
+	// Tópico de fórum em que a mensagem foi enviada (supergrupos com tópicos)
+	MessageThreadID int  `json:"message_thread_id,omitempty"`
+	IsTopicMessage  bool `json:"is_topic_message,omitempty"`
+
+	// Mensagem de serviço enviada quando o grupo é convertido em supergrupo
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
+
+	// Teclado inline da mensagem, mantido em JSON para preservar todos os tipos de botão
+	ReplyMarkup json.RawMessage `json:"reply_markup,omitempty"`
 }
 
 // TelegramUser representa um usuário do Telegram
 type TelegramUser struct {
-	ID        int64  `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name,omitempty"`
-	UserName  string `json:"username,omitempty"`
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name,omitempty"`
+	UserName     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
 }
 
 // TelegramChat representa um chat do Telegram
@@ -91,3 +140,257 @@ type GeminiResponse struct {
 		} `json:"content"`
 	} `json:"candidates"`
 }
+
+// Schedule representa um prompt agendado (lembrete único ou recorrente diário)
+type Schedule struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	UserName  string     `json:"user_name"`
+	ChatID    int64      `json:"chat_id"`
+	ChatType  string     `json:"chat_type"`
+	ThreadID  int        `json:"thread_id,omitempty"`   // Tópico de fórum onde o agendamento foi criado
+	Kind      string     `json:"kind"`                  // "once" ou "daily"
+	TimeOfDay string     `json:"time_of_day,omitempty"` // HH:MM no fuso do usuário, apenas para "daily"
+	Timezone  string     `json:"timezone"`
+	Locale    string     `json:"locale"`
+	Prompt    string     `json:"prompt"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// UserSettings representa as preferências salvas de um usuário
+type UserSettings struct {
+	UserID       int64  `json:"user_id"`
+	Timezone     string `json:"timezone,omitempty"`
+	Language     string `json:"language,omitempty"`      // Idioma escolhido com /language, sobrepõe o language_code do Telegram
+	DeliveryMode string `json:"delivery_mode,omitempty"` // "preview", "full" ou "auto"; vazio usa DEFAULT_DELIVERY_MODE
+
+	// Preferências de geração escolhidas no /settings
+	AnswerLength     string `json:"answer_length,omitempty"`       // "short", "normal" ou "detailed"; vazio é "normal"
+	Temperature      string `json:"temperature,omitempty"`         // "precise", "balanced" ou "creative"; vazio usa a temperatura do provedor
+	Persona          string `json:"persona,omitempty"`             // "default", "teacher", "developer" ou "casual"; vazio é "default"
+	AutoNewChatHours int    `json:"auto_new_chat_hours,omitempty"` // Horas sem mensagens para começar um chat novo; zero desativa
+}
+
+// KeyHealth descreve o estado de uma chave de API do pool de um provedor
+type KeyHealth struct {
+	Provider     string     `json:"provider"`
+	Key          string     `json:"key"` // Chave mascarada, apenas para identificação
+	Available    bool       `json:"available"`
+	BenchedUntil *time.Time `json:"benched_until,omitempty"`
+	Requests     int64      `json:"requests"`
+	Failures     int64      `json:"failures"`
+	RateLimits   int64      `json:"rate_limits"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// KeyHealthReporter é implementado pelos serviços de IA que usam um pool de chaves
+type KeyHealthReporter interface {
+	KeyHealth() []KeyHealth
+}
+
+// Estados de um job de pergunta
+const (
+	JobPending = "pending" // Registrado, aguardando processamento
+	JobRunning = "running" // Em processamento pela IA
+	JobDone    = "done"    // Respondido com sucesso
+	JobFailed  = "failed"  // Última tentativa falhou, será tentado novamente
+	JobDead    = "dead"    // Tentativas esgotadas, o usuário foi avisado
+)
+
+// Job representa uma pergunta registrada antes da chamada ao provedor de IA,
+// para que possa ser retomada caso o processo seja interrompido
+type Job struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	ChatID         int64     `json:"chat_id"`
+	ConversationID int64     `json:"conversation_id"`           // Dono do histórico: o usuário ou, em conversas compartilhadas, o grupo
+	ThreadID       int       `json:"thread_id,omitempty"`       // Tópico de fórum da pergunta, com fila e histórico próprios
+	ReplaceTurnID  int64     `json:"replace_turn_id,omitempty"` // Turno refeito quando a pergunta foi editada; zero em perguntas novas
+	Message        string    `json:"message"`                   // TelegramMessage original em JSON, usado para responder ao usuário
+	Question       string    `json:"question"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// InlineUsage resume o uso do modo inline a partir dos resultados escolhidos pelos usuários
+type InlineUsage struct {
+	Chosen     int64             `json:"chosen"`
+	Users      int64             `json:"users"`
+	TopQueries []InlineQueryStat `json:"top_queries"`
+}
+
+type InlineQueryStat struct {
+	Query string `json:"query"`
+	Count int64  `json:"count"`
+}
+
+// GroupSettings guarda a política de um grupo: se a conversa é individual
+// ("user") ou compartilhada ("shared") e o que faz o bot responder
+type GroupSettings struct {
+	ChatID           int64    `json:"chat_id"`
+	ConversationMode string   `json:"conversation_mode,omitempty"`
+	Triggers         []string `json:"triggers,omitempty"` // "mention", "reply", "prefix" e "keyword"
+	CommandPrefix    string   `json:"command_prefix,omitempty"`
+	Keywords         []string `json:"keywords,omitempty"`
+}
+
+// Tipos de entrada da lista de acesso
+const (
+	AccessUser   = "user"   // Usuário liberado
+	AccessGroup  = "group"  // Grupo liberado
+	AccessBanned = "banned" // Usuário banido, bloqueado mesmo no modo aberto
+)
+
+// AccessEntry é um usuário ou grupo liberado, ou um usuário banido
+type AccessEntry struct {
+	Kind      string    `json:"kind"`
+	ID        int64     `json:"id"`
+	Note      string    `json:"note,omitempty"`     // Motivo do banimento ou origem da liberação (ex: convite)
+	AddedBy   int64     `json:"added_by,omitempty"` // Zero quando feito pela API administrativa
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InviteCode é um convite resgatado com /start inv_<code>. MaxUses zero é ilimitado
+type InviteCode struct {
+	Code      string    `json:"code"`
+	MaxUses   int       `json:"max_uses"`
+	Uses      int       `json:"uses"`
+	CreatedBy int64     `json:"created_by,omitempty"`
+	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditEntry registra uma decisão ou alteração de acesso
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	ActorID   int64     `json:"actor_id,omitempty"` // Quem agiu; zero para o sistema ou a API administrativa
+	Source    string    `json:"source"`             // "telegram", "api" ou "system"
+	Action    string    `json:"action"`             // Ex: "deny", "ban", "allow_user", "redeem_invite"
+	TargetID  int64     `json:"target_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BotStats resume o uso do bot. Os campos "Today" contam a partir do início do dia
+type BotStats struct {
+	Users          int64 `json:"users"` // Usuários que já conversaram com o bot
+	ActiveToday    int64 `json:"active_today"`
+	Conversations  int64 `json:"conversations"`
+	Groups         int64 `json:"groups"`
+	MessagesToday  int64 `json:"messages_today"` // Perguntas e respostas
+	QuestionsToday int64 `json:"questions_today"`
+	FailedToday    int64 `json:"failed_today"` // Perguntas aguardando nova tentativa após erro do provedor
+	DeadToday      int64 `json:"dead_today"`   // Perguntas descartadas após esgotar as tentativas
+}
+
+// UserStats resume o uso do bot por um usuário, inclusive em conversas compartilhadas de grupos
+type UserStats struct {
+	UserID         int64      `json:"user_id"`
+	Conversations  int64      `json:"conversations"`
+	Questions      int64      `json:"questions"`
+	QuestionsToday int64      `json:"questions_today"`
+	LastActiveAt   *time.Time `json:"last_active_at,omitempty"`
+}
+
+// Estados de um broadcast
+const (
+	BroadcastDraft     = "draft"     // Criado pelo /broadcast, aguardando confirmação
+	BroadcastPending   = "pending"   // Destinatários definidos, aguardando o envio
+	BroadcastRunning   = "running"   // Em envio; retomado após uma reinicialização
+	BroadcastDone      = "done"      // Todos os destinatários processados
+	BroadcastCancelled = "cancelled" // Cancelado por um administrador
+)
+
+// Resultados da entrega de um broadcast a um usuário
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryBlocked   = "blocked" // O usuário bloqueou o bot ou nunca iniciou uma conversa com ele
+	DeliveryFailed    = "failed"
+)
+
+// Broadcast é um aviso enviado pelos administradores a todos os usuários do bot.
+// Os contadores são calculados a partir das entregas
+type Broadcast struct {
+	ID         int64      `json:"id"`
+	Text       string     `json:"text"`
+	ButtonText string     `json:"button_text,omitempty"`
+	ButtonURL  string     `json:"button_url,omitempty"`
+	CreatedBy  int64      `json:"created_by,omitempty"` // Zero quando criado pela API administrativa
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Delivered  int        `json:"delivered"`
+	Blocked    int        `json:"blocked"`
+	Failed     int        `json:"failed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BroadcastDelivery é o resultado do envio de um broadcast a um usuário
+type BroadcastDelivery struct {
+	UserID    int64     `json:"user_id"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnswerSource identifica o que gerou uma resposta da IA. É gravado com a
+// resposta, para que as avaliações sejam agregadas pela origem real
+type AnswerSource struct {
+	Provider string
+	Model    string
+	Persona  string
+}
+
+// Avaliações de uma resposta
+const (
+	RatingUp   = 1  // 👍
+	RatingDown = -1 // 👎
+)
+
+// AnswerFeedback é a avaliação de uma resposta por um usuário. A origem da
+// resposta fica com ela, na tabela messages
+type AnswerFeedback struct {
+	Hash      string    `json:"hash"`
+	UserID    int64     `json:"user_id"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Mensagem do bot que pediu o comentário no Telegram. Zero se ainda não foi pedido
+	PromptChatID    int64 `json:"-"`
+	PromptMessageID int   `json:"-"`
+}
+
+// FeedbackSummary agrega as avaliações de um modelo ou de uma persona. Os
+// campos que não fazem parte do agrupamento ficam vazios
+type FeedbackSummary struct {
+	Provider     string  `json:"provider,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Persona      string  `json:"persona,omitempty"`
+	Up           int     `json:"up"`
+	Down         int     `json:"down"`
+	Total        int     `json:"total"`
+	Satisfaction float64 `json:"satisfaction"` // Fração de avaliações positivas, de 0 a 1
+}
+
+// RatedAnswer é uma resposta com o saldo das suas avaliações e os comentários recebidos
+type RatedAnswer struct {
+	Hash     string   `json:"hash"`
+	Content  string   `json:"content"`
+	Provider string   `json:"provider,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Persona  string   `json:"persona,omitempty"`
+	Up       int      `json:"up"`
+	Down     int      `json:"down"`
+	Score    int      `json:"score"` // Up menos Down
+	Comments []string `json:"comments"`
+}
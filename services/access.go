@@ -0,0 +1,535 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"bot-ai/config"
+	"bot-ai/database"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// Modos da política de acesso
+const (
+	AccessOpen      = "open"      // Qualquer usuário que não esteja banido
+	AccessAllowlist = "allowlist" // Apenas usuários e grupos liberados
+)
+
+// accessModeSetting é a chave de bot_settings que substitui ACCESS_MODE
+const accessModeSetting = "access_mode"
+
+// Alterações de acesso aceitas pelos comandos e pela API administrativa.
+// Os nomes também identificam a ação no log de auditoria
+const (
+	AccessSetMode     = "set_mode"
+	AccessAllowUser   = "allow_user"
+	AccessRemoveUser  = "remove_user"
+	AccessAllowGroup  = "allow_group"
+	AccessRemoveGroup = "remove_group"
+	AccessBan         = "ban"
+	AccessUnban       = "unban"
+)
+
+// Origens das entradas do log de auditoria
+const (
+	AuditTelegram = "telegram" // Comando de um administrador ou ação de um usuário no bot
+	AuditAPI      = "api"      // Rotas /api/admin
+	AuditSystem   = "system"   // Decisões automáticas, como o bloqueio de uma mensagem
+)
+
+// Motivos de bloqueio de uma atualização
+const (
+	denyBanned      = "banned"
+	denyNotAllowed  = "not_allowed"
+	denyMaintenance = "maintenance"
+)
+
+// inviteCodeBytes é o tamanho, em bytes aleatórios, dos códigos de convite
+const inviteCodeBytes = 6
+
+var (
+	errInvalidAccessMode   = errors.New("modo de acesso inválido")
+	errInvalidAccessAction = errors.New("ação de acesso inválida")
+	errInvalidAccessTarget = errors.New("ID de usuário ou grupo inválido")
+)
+
+// AccessChange é uma alteração da política de acesso
+type AccessChange struct {
+	Action string `json:"action"`
+	ID     int64  `json:"id,omitempty"`   // Usuário ou grupo afetado
+	Mode   string `json:"mode,omitempty"` // Novo modo, para set_mode
+	Note   string `json:"note,omitempty"` // Motivo do banimento ou observação da liberação
+}
+
+// currentAccessMode retorna o modo definido pelos administradores ou, na falta
+// dele, o de ACCESS_MODE. Valores desconhecidos restringem o acesso
+func currentAccessMode(db *database.Database, cfg *config.Config) string {
+	mode, err := db.GetBotSetting(accessModeSetting)
+	if err != nil {
+		log.Printf("Erro ao buscar modo de acesso: %v", err)
+	}
+	if mode == "" {
+		mode = cfg.AccessMode
+	}
+	if mode != AccessOpen {
+		return AccessAllowlist
+	}
+	return AccessOpen
+}
+
+// applyAccessChange aplica a alteração e a registra no log de auditoria.
+// Retorna false se nada mudou, como ao remover um ID que não estava na lista
+func applyAccessChange(db *database.Database, change AccessChange, actorID int64, source string) (bool, error) {
+	if change.Action != AccessSetMode && change.ID == 0 {
+		return false, errInvalidAccessTarget
+	}
+
+	changed := true
+	var err error
+	switch change.Action {
+	case AccessSetMode:
+		change.Mode = strings.ToLower(change.Mode)
+		if change.Mode != AccessOpen && change.Mode != AccessAllowlist {
+			return false, errInvalidAccessMode
+		}
+		err = db.SetBotSetting(accessModeSetting, change.Mode)
+	case AccessAllowUser:
+		err = db.AddAccessEntry(models.AccessEntry{Kind: models.AccessUser, ID: change.ID, Note: change.Note, AddedBy: actorID})
+	case AccessAllowGroup:
+		err = db.AddAccessEntry(models.AccessEntry{Kind: models.AccessGroup, ID: change.ID, Note: change.Note, AddedBy: actorID})
+	case AccessBan:
+		err = db.AddAccessEntry(models.AccessEntry{Kind: models.AccessBanned, ID: change.ID, Note: change.Note, AddedBy: actorID})
+	case AccessRemoveUser:
+		changed, err = db.RemoveAccessEntry(models.AccessUser, change.ID)
+	case AccessRemoveGroup:
+		changed, err = db.RemoveAccessEntry(models.AccessGroup, change.ID)
+	case AccessUnban:
+		changed, err = db.RemoveAccessEntry(models.AccessBanned, change.ID)
+	default:
+		return false, errInvalidAccessAction
+	}
+	if err != nil || !changed {
+		return false, err
+	}
+
+	detail := change.Note
+	if change.Action == AccessSetMode {
+		detail = change.Mode
+	}
+	recordAudit(db, models.AuditEntry{ActorID: actorID, Source: source, Action: change.Action, TargetID: change.ID, Detail: detail})
+	return true, nil
+}
+
+// createInvite gera um código de convite e registra a criação no log de auditoria
+func createInvite(db *database.Database, maxUses int, actorID int64, source string) (string, error) {
+	buf := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar código de convite: %w", err)
+	}
+	code := hex.EncodeToString(buf)
+
+	if err := db.CreateInvite(code, maxUses, actorID); err != nil {
+		return "", err
+	}
+
+	recordAudit(db, models.AuditEntry{ActorID: actorID, Source: source, Action: "create_invite", Detail: fmt.Sprintf("%s max_uses=%d", code, maxUses)})
+	return code, nil
+}
+
+// revokeInvite revoga o convite e registra a revogação no log de auditoria
+func revokeInvite(db *database.Database, code string, actorID int64, source string) (bool, error) {
+	revoked, err := db.RevokeInvite(code)
+	if err != nil || !revoked {
+		return false, err
+	}
+
+	recordAudit(db, models.AuditEntry{ActorID: actorID, Source: source, Action: "revoke_invite", Detail: code})
+	return true, nil
+}
+
+// recordAudit grava a entrada no log de auditoria. Falhas são apenas registradas no log
+func recordAudit(db *database.Database, entry models.AuditEntry) {
+	if err := db.RecordAudit(entry); err != nil {
+		log.Printf("Erro ao registrar auditoria (%s): %v", entry.Action, err)
+	}
+}
+
+// inAccessList consulta a lista de acesso. Em caso de erro, o ID é tratado como fora da lista
+func (s *TelegramService) inAccessList(kind string, id int64) bool {
+	found, err := s.db.HasAccessEntry(kind, id)
+	if err != nil {
+		log.Printf("Erro ao consultar acesso de %d: %v", id, err)
+		return false
+	}
+	return found
+}
+
+// accessDenial retorna o motivo do bloqueio do usuário no chat, ou uma string
+// vazia se ele tiver acesso. Administradores nunca são bloqueados, nem mesmo
+// durante a manutenção, e, no modo allowlist, basta que o usuário ou o grupo esteja liberado
+func (s *TelegramService) accessDenial(user *models.TelegramUser, chat *models.TelegramChat) string {
+	if s.isAdmin(user.ID) {
+		return ""
+	}
+	if s.inAccessList(models.AccessBanned, user.ID) {
+		return denyBanned
+	}
+	if s.inMaintenance() {
+		return denyMaintenance
+	}
+	if currentAccessMode(s.db, s.config) == AccessOpen {
+		return ""
+	}
+	if s.inAccessList(models.AccessUser, user.ID) {
+		return ""
+	}
+	if chat != nil && chat.Type != "private" && s.inAccessList(models.AccessGroup, chat.ID) {
+		return ""
+	}
+	return denyNotAllowed
+}
+
+// backgroundDenial aplica a política de acesso a trabalhos disparados sem uma
+// mensagem do usuário, como agendamentos e jobs retomados, que não passam por
+// checkAccess. Os bloqueios são auditados como em checkAccess, com o trabalho
+// descrito em detail
+func (s *TelegramService) backgroundDenial(user *models.TelegramUser, chat *models.TelegramChat, detail string) string {
+	denial := s.accessDenial(user, chat)
+	if denial != "" && denial != denyMaintenance {
+		recordAudit(s.db, models.AuditEntry{
+			Source: AuditSystem, Action: "deny", TargetID: user.ID,
+			Detail: fmt.Sprintf("%s chat=%d %s", denial, chat.ID, detail),
+		})
+	}
+	return denial
+}
+
+// checkAccess aplica a política de acesso antes de qualquer tratamento da
+// atualização. Retorna false se ela deve ser descartada. Bloqueios de mensagens
+// dirigidas ao bot são auditados; só chats privados recebem o aviso, para que
+// o bot não polua grupos não liberados. A manutenção não é auditada e também é
+// avisada nos grupos. policy é a política do grupo da mensagem (nil fora de grupos)
+func (s *TelegramService) checkAccess(update Update, policy *models.GroupSettings) bool {
+	var user *models.TelegramUser
+	var chat *models.TelegramChat
+	msg := update.Message
+	if msg == nil {
+		msg = update.EditedMessage
+	}
+
+	switch {
+	case msg != nil:
+		// A migração para supergrupo não é uma ação do usuário e precisa sempre ser aplicada
+		if msg.MigrateToChatID != 0 {
+			return true
+		}
+		user, chat = msg.From, msg.Chat
+	case update.CallbackQuery != nil:
+		user = update.CallbackQuery.From
+		if update.CallbackQuery.Message != nil {
+			chat = update.CallbackQuery.Message.Chat
+		}
+	case update.InlineQuery != nil:
+		user = update.InlineQuery.From
+	case update.ChosenInlineResult != nil:
+		user = update.ChosenInlineResult.From
+	}
+
+	// Atualizações sem usuário seguem o fluxo normal, que as ignora
+	if user == nil {
+		return true
+	}
+
+	denial := s.accessDenial(user, chat)
+	if denial == "" {
+		return true
+	}
+
+	// O convite é a forma de quem está fora da lista ganhar acesso
+	if denial == denyNotAllowed && msg != nil && s.isInviteStart(msg) {
+		return true
+	}
+
+	// Em grupos, mensagens que não são para o bot são descartadas sem auditoria
+	if msg != nil && policy != nil && !strings.HasPrefix(msg.Text, "/") && !s.matchesGroupTrigger(msg, policy) {
+		return false
+	}
+
+	if denial != denyMaintenance {
+		detail := denial
+		if chat != nil {
+			detail = fmt.Sprintf("%s chat=%d", denial, chat.ID)
+		}
+		recordAudit(s.db, models.AuditEntry{Source: AuditSystem, Action: "deny", TargetID: user.ID, Detail: detail})
+	}
+
+	key := "access.denied"
+	switch denial {
+	case denyBanned:
+		key = "access.banned"
+	case denyMaintenance:
+		key = "maintenance.active"
+	}
+	locale := s.userLocale(user)
+
+	switch {
+	case update.CallbackQuery != nil:
+		go s.answerCallbackQuery(update.CallbackQuery, &CallbackResponse{Text: i18n.T(locale, key), ShowAlert: true})
+	case update.Message != nil && update.Message.Chat != nil && (update.Message.Chat.Type == "private" || denial == denyMaintenance):
+		go s.sendTextMessage(update.Message, i18n.T(locale, key))
+	}
+	return false
+}
+
+// isInviteStart indica se a mensagem é o deeplink /start inv_<code> em um chat privado
+func (s *TelegramService) isInviteStart(msg *models.TelegramMessage) bool {
+	if msg.Chat == nil || msg.Chat.Type != "private" {
+		return false
+	}
+	name, args, _, ok := parseCommand(msg.Text, s.botInfo.UserName)
+	return ok && name == "start" && strings.HasPrefix(args, "inv_")
+}
+
+// redeemInvite libera o usuário que abriu o deeplink de um convite válido
+func (s *TelegramService) redeemInvite(msg *models.TelegramMessage, code string) {
+	locale := s.userLocale(msg.From)
+
+	if s.inAccessList(models.AccessUser, msg.From.ID) {
+		s.sendTextMessage(msg, i18n.T(locale, "invite.already"))
+		return
+	}
+
+	redeemed, err := s.db.RedeemInvite(code, msg.From.ID)
+	if err != nil {
+		log.Printf("Erro ao resgatar convite %s: %v", code, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+	if !redeemed {
+		recordAudit(s.db, models.AuditEntry{Source: AuditSystem, Action: "deny", TargetID: msg.From.ID, Detail: "invalid_invite " + code})
+		s.sendTextMessage(msg, i18n.T(locale, "invite.invalid"))
+		return
+	}
+
+	recordAudit(s.db, models.AuditEntry{ActorID: msg.From.ID, Source: AuditTelegram, Action: "redeem_invite", TargetID: msg.From.ID, Detail: code})
+	s.sendTextMessage(msg, i18n.T(locale, "invite.redeemed"))
+	s.sendWelcomeMessage(msg)
+}
+
+// handleAccessCommand mostra ou altera a política de acesso:
+// /access [mode open|allowlist | allow <id> | remove <id> | allowgroup [id] | removegroup [id]]
+func (s *TelegramService) handleAccessCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+
+	option, value, _ := strings.Cut(args, " ")
+	value = strings.TrimSpace(value)
+
+	var change AccessChange
+	switch strings.ToLower(option) {
+	case "", "list":
+		s.sendTextMessage(msg, s.describeAccess(locale))
+		return
+	case "mode":
+		change = AccessChange{Action: AccessSetMode, Mode: value}
+	case "allow":
+		change = AccessChange{Action: AccessAllowUser}
+	case "remove":
+		change = AccessChange{Action: AccessRemoveUser}
+	case "allowgroup":
+		change = AccessChange{Action: AccessAllowGroup}
+	case "removegroup":
+		change = AccessChange{Action: AccessRemoveGroup}
+	default:
+		s.sendTextMessage(msg, i18n.T(locale, "access.usage"))
+		return
+	}
+
+	if change.Action != AccessSetMode {
+		id, ok := accessTarget(msg, value, change.Action == AccessAllowGroup || change.Action == AccessRemoveGroup)
+		if !ok {
+			s.sendTextMessage(msg, i18n.T(locale, "access.usage"))
+			return
+		}
+		change.ID = id
+	}
+
+	s.applyAccessCommand(msg, change)
+}
+
+// handleBanCommand bane um usuário pelo ID ou pela mensagem respondida: /ban <id> [motivo]
+func (s *TelegramService) handleBanCommand(msg *models.TelegramMessage, args string) {
+	target, reason, _ := strings.Cut(args, " ")
+	change := AccessChange{Action: AccessBan, Note: strings.TrimSpace(reason)}
+
+	if id, err := strconv.ParseInt(target, 10, 64); err == nil {
+		change.ID = id
+	} else if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil {
+		// Respondendo a uma mensagem, todo o texto é o motivo
+		change.ID = msg.ReplyToMessage.From.ID
+		change.Note = args
+	} else {
+		s.sendTextMessage(msg, i18n.T(s.userLocale(msg.From), "ban.usage"))
+		return
+	}
+
+	s.applyAccessCommand(msg, change)
+}
+
+// handleUnbanCommand remove um usuário da lista de banidos: /unban <id>
+func (s *TelegramService) handleUnbanCommand(msg *models.TelegramMessage, args string) {
+	id, ok := accessTarget(msg, args, false)
+	if !ok {
+		s.sendTextMessage(msg, i18n.T(s.userLocale(msg.From), "ban.unban_usage"))
+		return
+	}
+
+	s.applyAccessCommand(msg, AccessChange{Action: AccessUnban, ID: id})
+}
+
+// applyAccessCommand aplica a alteração pedida por um administrador e responde com o resultado
+func (s *TelegramService) applyAccessCommand(msg *models.TelegramMessage, change AccessChange) {
+	locale := s.userLocale(msg.From)
+
+	changed, err := applyAccessChange(s.db, change, msg.From.ID, AuditTelegram)
+	switch {
+	case errors.Is(err, errInvalidAccessMode):
+		s.sendTextMessage(msg, i18n.T(locale, "access.invalid_mode", change.Mode))
+	case err != nil:
+		log.Printf("Erro ao alterar acesso (%s): %v", change.Action, err)
+		s.sendErrorMessage(msg)
+	case !changed:
+		s.sendTextMessage(msg, i18n.T(locale, "access.unchanged", change.ID))
+	case change.Action == AccessSetMode:
+		s.sendTextMessage(msg, i18n.T(locale, "access.mode_updated", i18n.T(locale, "access.mode."+strings.ToLower(change.Mode))))
+	default:
+		s.sendTextMessage(msg, i18n.T(locale, "access.updated."+change.Action, change.ID))
+	}
+}
+
+// accessTarget lê o ID do argumento. Para grupos, sem argumento, usa o chat atual
+func accessTarget(msg *models.TelegramMessage, value string, group bool) (int64, bool) {
+	if value == "" {
+		if group && msg.Chat.Type != "private" {
+			return msg.Chat.ID, true
+		}
+		return 0, false
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	return id, err == nil && id != 0
+}
+
+// describeAccess resume o modo de acesso e as listas de usuários, grupos e banidos
+func (s *TelegramService) describeAccess(locale string) string {
+	mode := currentAccessMode(s.db, s.config)
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "access.summary", i18n.T(locale, "access.mode."+mode)))
+	for _, kind := range []string{models.AccessUser, models.AccessGroup, models.AccessBanned} {
+		entries, err := s.db.ListAccessEntries(kind)
+		if err != nil {
+			log.Printf("Erro ao listar acesso (%s): %v", kind, err)
+			continue
+		}
+
+		fmt.Fprintf(&text, "\n\n%s", i18n.T(locale, "access.list."+kind, len(entries)))
+		for _, entry := range entries {
+			fmt.Fprintf(&text, "\n• %d", entry.ID)
+			if entry.Note != "" {
+				fmt.Fprintf(&text, " (%s)", entry.Note)
+			}
+		}
+	}
+	return text.String()
+}
+
+// handleInviteCommand cria, lista ou revoga convites:
+// /invite [usos | list | revoke <código>]. Zero usos cria um convite ilimitado
+func (s *TelegramService) handleInviteCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+
+	option, value, _ := strings.Cut(args, " ")
+	value = strings.TrimSpace(value)
+
+	switch strings.ToLower(option) {
+	case "list":
+		s.sendTextMessage(msg, s.describeInvites(locale))
+		return
+	case "revoke":
+		if value == "" {
+			s.sendTextMessage(msg, i18n.T(locale, "invite.usage"))
+			return
+		}
+		revoked, err := revokeInvite(s.db, value, msg.From.ID, AuditTelegram)
+		if err != nil {
+			log.Printf("Erro ao revogar convite %s: %v", value, err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		if !revoked {
+			s.sendTextMessage(msg, i18n.T(locale, "invite.not_found", value))
+			return
+		}
+		s.sendTextMessage(msg, i18n.T(locale, "invite.revoked", value))
+		return
+	}
+
+	maxUses := 1
+	if option != "" {
+		var err error
+		maxUses, err = strconv.Atoi(option)
+		if err != nil || maxUses < 0 {
+			s.sendTextMessage(msg, i18n.T(locale, "invite.usage"))
+			return
+		}
+	}
+
+	code, err := createInvite(s.db, maxUses, msg.From.ID, AuditTelegram)
+	if err != nil {
+		log.Printf("Erro ao criar convite: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	uses := strconv.Itoa(maxUses)
+	if maxUses == 0 {
+		uses = i18n.T(locale, "invite.unlimited")
+	}
+	s.sendTextMessage(msg, i18n.T(locale, "invite.created", s.inviteLink(code), uses))
+}
+
+// inviteLink monta o deeplink que resgata o convite ao iniciar o bot
+func (s *TelegramService) inviteLink(code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=inv_%s", s.botInfo.UserName, code)
+}
+
+// describeInvites lista os convites com os usos e o estado de cada um
+func (s *TelegramService) describeInvites(locale string) string {
+	invites, err := s.db.ListInvites()
+	if err != nil {
+		log.Printf("Erro ao listar convites: %v", err)
+		return i18n.T(locale, "error.generic")
+	}
+	if len(invites) == 0 {
+		return i18n.T(locale, "invite.empty")
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "invite.list_header"))
+	for _, invite := range invites {
+		limit := strconv.Itoa(invite.MaxUses)
+		if invite.MaxUses == 0 {
+			limit = "∞"
+		}
+		fmt.Fprintf(&text, "\n• %s: %d/%s", invite.Code, invite.Uses, limit)
+		if invite.Revoked {
+			fmt.Fprintf(&text, " (%s)", i18n.T(locale, "invite.revoked_flag"))
+		}
+	}
+	return text.String()
+}
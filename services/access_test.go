@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-ai/database"
+	"bot-ai/models"
+	"bot-ai/services/telegramtest"
+)
+
+// hasDenyAudit indica se o log de auditoria tem um bloqueio do usuário cujo detalhe contém detail
+func hasDenyAudit(t *testing.T, db *database.Database, userID int64, detail string) bool {
+	t.Helper()
+	entries, err := db.ListAudit(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Action == "deny" && entry.TargetID == userID && strings.Contains(entry.Detail, detail) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScheduleOfBannedUserIsDeactivated(t *testing.T) {
+	srv, bot, db := startTestBot(t, nil)
+	if err := db.AddAccessEntry(models.AccessEntry{Kind: models.AccessBanned, ID: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Now().Add(-time.Minute)
+	for _, userID := range []int64{42, 43} {
+		_, err := db.CreateSchedule(&models.Schedule{
+			UserID: userID, UserName: "Ana", ChatID: userID, ChatType: "private",
+			Kind: "daily", TimeOfDay: "09:00", Timezone: "UTC", Locale: "pt-BR",
+			Prompt: "bom dia", NextRunAt: due,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.runDueSchedules(time.Now())
+
+	// O agendamento de quem não foi banido segue normalmente
+	if _, err := srv.WaitFor(10*time.Second, func(messages []telegramtest.SentMessage) bool {
+		return len(messages) > 0 && messages[len(messages)-1].ChatID == 43
+	}); err != nil {
+		t.Fatalf("agendamento liberado não executou: %v", err)
+	}
+	for _, m := range srv.Messages() {
+		if m.ChatID == 42 {
+			t.Errorf("usuário banido recebeu %q", m.Text)
+		}
+	}
+
+	if schedules, err := db.ListUserSchedules(42); err != nil || len(schedules) != 0 {
+		t.Errorf("agendamentos do usuário banido = %+v, erro %v", schedules, err)
+	}
+	if schedules, err := db.ListUserSchedules(43); err != nil || len(schedules) != 1 {
+		t.Errorf("agendamentos do usuário liberado = %+v, erro %v", schedules, err)
+	}
+	if !hasDenyAudit(t, db, 42, "schedule=") {
+		t.Error("bloqueio do agendamento não foi auditado")
+	}
+}
+
+func TestResumedJobOfBannedUserIsDiscarded(t *testing.T) {
+	srv, bot, db := startTestBot(t, nil)
+	if err := db.AddAccessEntry(models.AccessEntry{Kind: models.AccessBanned, ID: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+	data, err := json.Marshal(models.TelegramMessage{MessageID: 7, From: &ana, Chat: &chat, Text: "Olá"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.CreateJob(&models.Job{UserID: 42, ChatID: 42, ConversationID: 42, Message: string(data), Question: "Olá"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bot.ResumeJobs()
+
+	dead, err := db.ListJobs([]string{models.JobDead}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ID != id {
+		t.Errorf("jobs descartados = %+v, esperava o job %d", dead, id)
+	}
+	if calls := srv.Calls("sendMessage"); len(calls) != 0 {
+		t.Errorf("job bloqueado enviou %d mensagens", len(calls))
+	}
+	if !hasDenyAudit(t, db, 42, "job=") {
+		t.Error("bloqueio do job não foi auditado")
+	}
+}
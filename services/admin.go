@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-ai/config"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// maintenanceSetting é a chave de bot_settings que liga o modo de manutenção
+const maintenanceSetting = "maintenance"
+
+// inMaintenance indica se o bot está em manutenção, respondendo apenas aos administradores
+func (s *TelegramService) inMaintenance() bool {
+	value, err := s.db.GetBotSetting(maintenanceSetting)
+	if err != nil {
+		log.Printf("Erro ao buscar modo de manutenção: %v", err)
+		return false
+	}
+	return value == "on"
+}
+
+// startOfDay retorna a meia-noite do dia atual no fuso do servidor
+func startOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// handleStatsCommand resume o uso do bot e as falhas do provedor no dia
+func (s *TelegramService) handleStatsCommand(msg *models.TelegramMessage) {
+	locale := s.userLocale(msg.From)
+
+	stats, err := s.db.GetBotStats(startOfDay(time.Now()))
+	if err != nil {
+		log.Printf("Erro ao calcular estatísticas: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	queue := s.dispatcher.Stats()
+	s.sendTextMessage(msg, i18n.T(locale, "stats.summary",
+		stats.Users, stats.ActiveToday,
+		stats.Conversations, stats.Groups,
+		stats.QuestionsToday, stats.MessagesToday,
+		stats.FailedToday, stats.DeadToday,
+		queue.Running, queue.Queued,
+	))
+}
+
+// handleUserCommand mostra o uso e a situação de acesso de um usuário: /user <id>
+func (s *TelegramService) handleUserCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil || userID == 0 {
+		s.sendTextMessage(msg, i18n.T(locale, "user.usage"))
+		return
+	}
+
+	stats, err := s.db.GetUserStats(userID, startOfDay(time.Now()))
+	if err != nil {
+		log.Printf("Erro ao calcular uso do usuário %d: %v", userID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	settings, err := s.db.GetUserSettings(userID)
+	if err != nil {
+		log.Printf("Erro ao buscar configurações do usuário %d: %v", userID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	schedules, err := s.db.ListUserSchedules(userID)
+	if err != nil {
+		log.Printf("Erro ao listar agendamentos do usuário %d: %v", userID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	lastActive := i18n.T(locale, "user.never")
+	if stats.LastActiveAt != nil {
+		lastActive = stats.LastActiveAt.Local().Format("02/01/2006 15:04")
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "user.summary",
+		userID, s.describeUserStatus(locale, userID),
+		stats.Conversations, stats.Questions, stats.QuestionsToday,
+		lastActive, len(schedules),
+	))
+	if settings.Language != "" {
+		fmt.Fprintf(&text, "\n%s", i18n.T(locale, "user.language", settings.Language))
+	}
+	if settings.Timezone != "" {
+		fmt.Fprintf(&text, "\n%s", i18n.T(locale, "user.timezone", settings.Timezone))
+	}
+
+	s.sendTextMessage(msg, text.String())
+}
+
+// describeUserStatus descreve a situação do usuário na política de acesso,
+// sem considerar a manutenção nem os grupos liberados
+func (s *TelegramService) describeUserStatus(locale string, userID int64) string {
+	switch {
+	case s.isAdmin(userID):
+		return i18n.T(locale, "user.status.admin")
+	case s.inAccessList(models.AccessBanned, userID):
+		return i18n.T(locale, "user.status.banned")
+	case currentAccessMode(s.db, s.config) == AccessOpen || s.inAccessList(models.AccessUser, userID):
+		return i18n.T(locale, "user.status.allowed")
+	}
+	return i18n.T(locale, "user.status.not_allowed")
+}
+
+// handleMaintenanceCommand liga ou desliga o modo de manutenção: /maintenance [on|off]
+func (s *TelegramService) handleMaintenanceCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+
+	value := strings.ToLower(args)
+	switch value {
+	case "":
+		key := "maintenance.status_off"
+		if s.inMaintenance() {
+			key = "maintenance.status_on"
+		}
+		s.sendTextMessage(msg, i18n.T(locale, key))
+		return
+	case "on", "off":
+	default:
+		s.sendTextMessage(msg, i18n.T(locale, "maintenance.usage"))
+		return
+	}
+
+	if err := s.db.SetBotSetting(maintenanceSetting, value); err != nil {
+		log.Printf("Erro ao alterar modo de manutenção: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+	recordAudit(s.db, models.AuditEntry{ActorID: msg.From.ID, Source: AuditTelegram, Action: "maintenance", Detail: value})
+
+	s.sendTextMessage(msg, i18n.T(locale, "maintenance.turned_"+value))
+}
+
+// handleProviderCommand mostra o provedor de IA ativo, a saúde das chaves e a fila de perguntas
+func (s *TelegramService) handleProviderCommand(msg *models.TelegramMessage) {
+	locale := s.userLocale(msg.From)
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "provider.summary", s.config.AIService, providerModel(s.config)))
+
+	if reporter, ok := s.ai.(models.KeyHealthReporter); ok {
+		for _, key := range reporter.KeyHealth() {
+			status := i18n.T(locale, "provider.key.available")
+			if !key.Available {
+				status = i18n.T(locale, "provider.key.benched")
+				if key.BenchedUntil != nil {
+					status = i18n.T(locale, "provider.key.benched_until", key.BenchedUntil.Local().Format("15:04:05"))
+				}
+			}
+			fmt.Fprintf(&text, "\n\n%s", i18n.T(locale, "provider.key", key.Key, status, key.Requests, key.Failures, key.RateLimits))
+			if key.LastError != "" {
+				fmt.Fprintf(&text, "\n%s", i18n.T(locale, "provider.key.last_error", key.LastError))
+			}
+		}
+	}
+
+	queue := s.dispatcher.Stats()
+	fmt.Fprintf(&text, "\n\n%s", i18n.T(locale, "provider.queue",
+		queue.Running, queue.MaxConcurrent, queue.Queued, queue.Processed))
+
+	s.sendTextMessage(msg, text.String())
+}
+
+// providerModel retorna o modelo configurado para o provedor ativo
+func providerModel(cfg *config.Config) string {
+	switch cfg.AIService {
+	case "google":
+		return cfg.GeminiModel
+	case "azure":
+		return cfg.AzureOpenAIModel
+	case "fake":
+		return cfg.FakeAIMode
+	}
+	return "-"
+}
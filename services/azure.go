@@ -16,6 +16,7 @@ import (
 
 type AzureOpenAIService struct {
 	client *http.Client
+	keys   *KeyPool
 	config *config.Config
 	db     *database.Database
 }
@@ -40,15 +41,16 @@ func NewAzureOpenAIService(cfg *config.Config, db *database.Database) models.AIS
 		client: &http.Client{
 			Timeout: cfg.HTTPTimeout,
 		},
+		keys:   NewKeyPool("azure", cfg.AzureOpenAIKeys, cfg.KeyPoolStrategy, cfg.KeyCooldown),
 		config: cfg,
 		db:     db,
 	}
 }
 
-func (s *AzureOpenAIService) AskWithRetry(userID int64, question string) (string, string, error) {
+func (s *AzureOpenAIService) AskWithRetry(userID int64, question string, opts models.AskOptions) (string, string, error) {
 	var lastErr error
 	for attempt := 1; attempt <= s.config.MaxRetries; attempt++ {
-		answer, hash, err := s.Ask(userID, question)
+		answer, hash, err := s.Ask(userID, question, opts)
 		if err == nil {
 			return answer, hash, nil
 		}
@@ -61,113 +63,128 @@ func (s *AzureOpenAIService) AskWithRetry(userID int64, question string) (string
 	return "", "", fmt.Errorf("todas as tentativas falharam: %v", lastErr)
 }
 
-func (s *AzureOpenAIService) Ask(userID int64, question string) (string, string, error) {
-	// Busca ou cria um chat ativo para o usuário
-	chat, err := s.db.GetActiveChat(userID)
+func (s *AzureOpenAIService) Ask(userID int64, question string, opts models.AskOptions) (string, string, error) {
+	// Busca ou cria um chat ativo para o usuário e recupera o histórico
+	chat, messages, err := loadConversation(s.db, userID, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao buscar chat ativo: %w", err)
+		return "", "", err
 	}
 
-	if chat == nil {
-		chat, err = s.db.CreateNewChat(userID)
-		if err != nil {
-			return "", "", fmt.Errorf("erro ao criar novo chat: %w", err)
-		}
-	}
-
-	// Recupera o histórico de mensagens
-	messages, err := s.db.GetChatMessages(chat.ID)
-	if err != nil {
-		return "", "", fmt.Errorf("erro ao recuperar histórico: %w", err)
-	}
-
-	// Prepara a requisição com o histórico
-	url := fmt.Sprintf("%s/chat/completions", s.config.AzureOpenAIEndpoint)
-
 	reqMessages := []models.ChatMessage{
 		{
 			Role:    "system",
-			Content: "You are a helpful assistant.",
+			Content: personaPrompt(opts.Locale, opts.Preferences),
 		},
 	}
 
 	// Adiciona o histórico de mensagens
-	reqMessages = append(reqMessages, messages...)
+	for _, msg := range messages {
+		reqMessages = append(reqMessages, models.ChatMessage{
+			Role:    msg.Role,
+			Content: turnContent(msg),
+		})
+	}
 
 	// Adiciona a pergunta atual
 	reqMessages = append(reqMessages, models.ChatMessage{
 		Role:    "user",
-		Content: question,
+		Content: questionContent(question, opts),
 	})
 
+	answer, err := s.complete(reqMessages, requestTemperature(opts.Preferences, s.config.AzureOpenAITemperature))
+	if err != nil {
+		return "", "", err
+	}
+
+	// Salva a pergunta e a resposta no histórico
+	hash, err := saveExchange(s, s.config, s.db, chat, messages, question, answer, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	return answer, hash, nil
+}
+
+// Complete envia um prompt isolado ao Azure OpenAI, sem histórico e sem salvar no banco
+func (s *AzureOpenAIService) Complete(prompt string) (string, error) {
+	return s.complete([]models.ChatMessage{
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}, s.config.AzureOpenAITemperature)
+}
+
+// complete envia as mensagens para o endpoint de chat completions e retorna o texto da resposta
+func (s *AzureOpenAIService) complete(reqMessages []models.ChatMessage, temperature float64) (string, error) {
+	url := fmt.Sprintf("%s/chat/completions", s.config.AzureOpenAIEndpoint)
+
 	reqBody := AzureRequest{
 		Messages:    reqMessages,
 		Model:       s.config.AzureOpenAIModel,
 		MaxTokens:   s.config.AzureOpenAIMaxTokens,
-		Temperature: s.config.AzureOpenAITemperature,
+		Temperature: temperature,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao serializar requisição: %w", err)
+		return "", fmt.Errorf("erro ao serializar requisição: %w", err)
 	}
 
 	ctx := context.Background()
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao criar requisição: %w", err)
+		return "", fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	// Escolhe a chave de API da vez no pool
+	keyIndex, apiKey, err := s.keys.Acquire()
+	if err != nil {
+		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.config.AzureOpenAIKey))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("erro na requisição HTTP: %w", err)
+		s.keys.ReportFailure(keyIndex, err)
+		return "", fmt.Errorf("erro na requisição HTTP: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao ler resposta: %w", err)
+		s.keys.ReportFailure(keyIndex, err)
+		return "", fmt.Errorf("erro ao ler resposta: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("API retornou status %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("API retornou status %d: %s", resp.StatusCode, string(body))
+		s.keys.ReportFailure(keyIndex, err)
+		return "", err
 	}
+	s.keys.ReportSuccess(keyIndex)
 
 	var azureResp AzureResponse
 	if err := json.Unmarshal(body, &azureResp); err != nil {
-		return "", "", fmt.Errorf("erro ao decodificar resposta: %w", err)
+		return "", fmt.Errorf("erro ao decodificar resposta: %w", err)
 	}
 
 	if len(azureResp.Choices) == 0 {
-		return "", "", fmt.Errorf("resposta vazia do Azure OpenAI")
+		return "", fmt.Errorf("resposta vazia do Azure OpenAI")
 	}
 
-	answer := azureResp.Choices[0].Message.Content
-
-	// Salva a pergunta no histórico
-	if err := s.db.AddMessageToChat(chat.ID, "user", question); err != nil {
-		return "", "", fmt.Errorf("erro ao salvar pergunta no histórico: %w", err)
-	}
-
-	// Salva a resposta na tabela messages e obtém o hash
-	hash, err := s.db.SaveMessage(answer)
-	if err != nil {
-		return "", "", fmt.Errorf("erro ao salvar mensagem: %w", err)
-	}
-
-	// Adiciona a resposta ao histórico do chat usando o hash já existente
-	if err := s.db.AddMessageToChatWithExistingHash(chat.ID, "assistant", answer, hash); err != nil {
-		return "", "", fmt.Errorf("erro ao salvar resposta no histórico: %w", err)
-	}
+	return azureResp.Choices[0].Message.Content, nil
+}
 
-	return answer, hash, nil
+// KeyHealth retorna o estado das chaves de API do Azure OpenAI
+func (s *AzureOpenAIService) KeyHealth() []models.KeyHealth {
+	return s.keys.Health()
 }
 
-func (s *AzureOpenAIService) NewChat(userID int64) error {
-	if err := s.db.NewChat(userID); err != nil {
+func (s *AzureOpenAIService) NewChat(userID int64, topic models.Topic) error {
+	if err := s.db.NewChat(userID, topic); err != nil {
 		return fmt.Errorf("erro ao criar novo chat: %w", err)
 	}
 	return nil
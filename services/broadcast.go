@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"bot-ai/database"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+const (
+	// broadcastCheckInterval é o intervalo entre buscas por broadcasts criados
+	// pela API administrativa, que não acordam o envio diretamente
+	broadcastCheckInterval = 5 * time.Second
+
+	// broadcastBatchSize é o número de entregas lidas por vez. O cancelamento
+	// é verificado entre os lotes
+	broadcastBatchSize = 100
+)
+
+// broadcastButtonPattern reconhece o botão opcional na última linha do
+// /broadcast, no formato de link do Markdown: [Texto](https://exemplo.com)
+var broadcastButtonPattern = regexp.MustCompile(`^\[([^\]]+)\]\((https?://\S+)\)$`)
+
+// parseBroadcast separa o texto do broadcast e o botão opcional da última linha
+func parseBroadcast(args string) *models.Broadcast {
+	broadcast := &models.Broadcast{Text: strings.TrimSpace(args)}
+
+	lines := strings.Split(broadcast.Text, "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if match := broadcastButtonPattern.FindStringSubmatch(last); match != nil && len(lines) > 1 {
+		broadcast.Text = strings.TrimSpace(strings.Join(lines[:len(lines)-1], "\n"))
+		broadcast.ButtonText = match[1]
+		broadcast.ButtonURL = match[2]
+	}
+	return broadcast
+}
+
+// StartBroadcaster inicia a rotina que envia os broadcasts liberados. Broadcasts
+// interrompidos por uma reinicialização continuam das entregas pendentes
+func (s *TelegramService) StartBroadcaster() {
+	go func() {
+		ticker := time.NewTicker(broadcastCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			s.runBroadcasts()
+
+			select {
+			case <-ticker.C:
+			case <-s.broadcastWake:
+			}
+		}
+	}()
+
+	log.Printf("Envio de broadcasts iniciado: %d mensagens por segundo", s.config.BroadcastRateLimit)
+}
+
+// wakeBroadcaster antecipa a busca por broadcasts liberados
+func (s *TelegramService) wakeBroadcaster() {
+	select {
+	case s.broadcastWake <- struct{}{}:
+	default:
+	}
+}
+
+// runBroadcasts envia, um de cada vez, os broadcasts aguardando ou em envio.
+// Um panic não derruba o envio: o broadcast é retomado na próxima verificação
+func (s *TelegramService) runBroadcasts() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recuperado de panic ao enviar broadcasts: %v", r)
+		}
+	}()
+
+	for {
+		broadcast, err := s.db.NextBroadcast()
+		if err != nil {
+			log.Printf("Erro ao buscar broadcasts: %v", err)
+			return
+		}
+		if broadcast == nil {
+			return
+		}
+
+		if err := s.sendBroadcast(broadcast); err != nil {
+			log.Printf("Erro ao enviar broadcast %d: %v", broadcast.ID, err)
+			return
+		}
+	}
+}
+
+// sendBroadcast entrega o broadcast aos destinatários pendentes, respeitando
+// BROADCAST_RATE_LIMIT. Cada resultado é gravado logo após o envio; se o
+// processo parar entre os dois, o usuário pode receber a mensagem duas vezes
+func (s *TelegramService) sendBroadcast(broadcast *models.Broadcast) error {
+	if broadcast.Status == models.BroadcastPending {
+		if err := s.db.SetBroadcastStatus(broadcast.ID, models.BroadcastRunning); err != nil {
+			return err
+		}
+		log.Printf("Iniciando broadcast %d para %d usuários", broadcast.ID, broadcast.Total)
+	} else {
+		log.Printf("Retomando broadcast %d: %d de %d usuários processados",
+			broadcast.ID, broadcast.Delivered+broadcast.Blocked+broadcast.Failed, broadcast.Total)
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(max(s.config.BroadcastRateLimit, 1)), 1)
+	for {
+		current, err := s.db.GetBroadcast(broadcast.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Status == models.BroadcastCancelled {
+			log.Printf("Broadcast %d cancelado", broadcast.ID)
+			return nil
+		}
+
+		userIDs, err := s.db.PendingDeliveries(broadcast.ID, broadcastBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			if err := limiter.Wait(context.Background()); err != nil {
+				return err
+			}
+
+			status, deliveryError := s.deliverBroadcast(broadcast, userID)
+			if err := s.db.RecordDelivery(broadcast.ID, userID, status, deliveryError); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.db.SetBroadcastStatus(broadcast.ID, models.BroadcastDone); err != nil {
+		return err
+	}
+	s.reportBroadcast(broadcast.ID)
+	return nil
+}
+
+// deliverBroadcast envia o broadcast a um usuário e retorna o resultado da
+// entrega. Usuários que bloquearam o bot são marcados como inativos
+func (s *TelegramService) deliverBroadcast(broadcast *models.Broadcast, userID int64) (string, string) {
+	err := s.sendBroadcastMessage(userID, broadcast)
+	if err == nil {
+		return models.DeliveryDelivered, ""
+	}
+
+	var tgErr *TelegramError
+	if errors.As(err, &tgErr) && isUnreachable(tgErr) {
+		if err := s.db.MarkUserInactive(userID, tgErr.Description); err != nil {
+			log.Printf("Erro ao marcar usuário %d como inativo: %v", userID, err)
+		}
+		return models.DeliveryBlocked, tgErr.Description
+	}
+
+	log.Printf("Erro ao entregar broadcast %d para %d: %v", broadcast.ID, userID, err)
+	return models.DeliveryFailed, err.Error()
+}
+
+// isUnreachable indica que o bot não pode escrever para o usuário: ele
+// bloqueou o bot, apagou a conta ou nunca iniciou uma conversa
+func isUnreachable(err *TelegramError) bool {
+	return err.Code == http.StatusForbidden ||
+		(err.Code == http.StatusBadRequest && strings.Contains(err.Description, "chat not found"))
+}
+
+// sendBroadcastMessage envia o texto do broadcast, formatado como as respostas
+// da IA, com o botão opcional. Se o Telegram recusar a formatação, o texto é
+// reenviado sem ela
+func (s *TelegramService) sendBroadcastMessage(chatID int64, broadcast *models.Broadcast) error {
+	payload := SendMessageRequest{
+		ChatID:    chatID,
+		Text:      renderMarkdown(broadcast.Text),
+		ParseMode: "HTML",
+	}
+	if broadcast.ButtonURL != "" {
+		payload.ReplyMarkup = &InlineKeyboardMarkup{
+			InlineKeyboard: [][]InlineKeyboardButton{{{Text: broadcast.ButtonText, URL: broadcast.ButtonURL}}},
+		}
+	}
+
+	_, err := s.makeRequest("sendMessage", payload)
+	if isEntityParseError(err) {
+		payload.Text = broadcast.Text
+		payload.ParseMode = ""
+		_, err = s.makeRequest("sendMessage", payload)
+	}
+	return err
+}
+
+// reportBroadcast avisa o administrador que criou o broadcast do resultado do envio
+func (s *TelegramService) reportBroadcast(id int64) {
+	broadcast, err := s.db.GetBroadcast(id)
+	if err != nil || broadcast == nil {
+		log.Printf("Erro ao buscar resultado do broadcast %d: %v", id, err)
+		return
+	}
+	log.Printf("Broadcast %d concluído: %d entregues, %d bloqueados, %d falhas",
+		id, broadcast.Delivered, broadcast.Blocked, broadcast.Failed)
+
+	if broadcast.CreatedBy == 0 {
+		return
+	}
+
+	locale := s.userLocale(&models.TelegramUser{ID: broadcast.CreatedBy})
+	payload := SendMessageRequest{
+		ChatID: broadcast.CreatedBy,
+		Text:   i18n.T(locale, "broadcast.finished", id, broadcast.Delivered, broadcast.Blocked, broadcast.Failed),
+	}
+	if _, err := s.makeRequest("sendMessage", payload); err != nil {
+		log.Printf("Erro ao enviar resultado do broadcast %d: %v", id, err)
+	}
+}
+
+// handleBroadcastCommand prepara um broadcast ou cancela um em andamento:
+// /broadcast <mensagem> ou /broadcast cancel <id>. Sem argumentos, lista os
+// broadcasts recentes. A mensagem só é enviada após a confirmação no botão
+func (s *TelegramService) handleBroadcastCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+
+	if args == "" {
+		s.sendTextMessage(msg, i18n.T(locale, "broadcast.usage")+"\n\n"+s.describeBroadcasts(locale))
+		return
+	}
+
+	option, value, _ := strings.Cut(args, " ")
+	if id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); strings.EqualFold(option, "cancel") && err == nil {
+		cancelled, err := s.db.CancelBroadcast(id)
+		if err != nil {
+			log.Printf("Erro ao cancelar broadcast %d: %v", id, err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		if !cancelled {
+			s.sendTextMessage(msg, i18n.T(locale, "broadcast.not_found", id))
+			return
+		}
+		s.sendTextMessage(msg, i18n.T(locale, "broadcast.cancelled", id))
+		return
+	}
+
+	broadcast := parseBroadcast(args)
+	broadcast.CreatedBy = msg.From.ID
+
+	recipients, err := s.db.CountBroadcastRecipients()
+	if err != nil {
+		log.Printf("Erro ao contar destinatários: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	broadcast.ID, err = s.db.CreateBroadcast(broadcast)
+	if err != nil {
+		log.Printf("Erro ao criar broadcast: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	// Prévia exatamente como os usuários a receberão, seguida da confirmação
+	if err := s.sendBroadcastMessage(msg.Chat.ID, broadcast); err != nil {
+		log.Printf("Erro ao enviar prévia do broadcast %d: %v", broadcast.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	payload := strconv.FormatInt(broadcast.ID, 10)
+	send, err := s.callbacks.Button(i18n.T(locale, "broadcast.button.send", recipients), "bc_send", payload)
+	if err != nil {
+		log.Printf("Erro ao criar botão do broadcast: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+	cancel, err := s.callbacks.Button(i18n.T(locale, "broadcast.button.cancel"), "bc_cancel", payload)
+	if err != nil {
+		log.Printf("Erro ao criar botão do broadcast: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	_, err = s.makeRequest("sendMessage", SendMessageRequest{
+		ChatID:      msg.Chat.ID,
+		Text:        i18n.T(locale, "broadcast.confirm", recipients),
+		ReplyMarkup: &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{send, cancel}}},
+	})
+	if err != nil {
+		log.Printf("Erro ao enviar confirmação do broadcast %d: %v", broadcast.ID, err)
+	}
+}
+
+// handleBroadcastSend libera o envio do broadcast confirmado no botão
+func (s *TelegramService) handleBroadcastSend(ctx *CallbackContext) (*CallbackResponse, error) {
+	if !s.isAdmin(ctx.Query.From.ID) {
+		return &CallbackResponse{Text: i18n.T(ctx.Locale, "command.not_allowed", "broadcast"), ShowAlert: true}, nil
+	}
+
+	id, err := strconv.ParseInt(ctx.Payload, 10, 64)
+	if err != nil {
+		return nil, errInvalidCallback
+	}
+
+	queued, err := s.db.QueueBroadcast(id)
+	if err != nil {
+		return nil, err
+	}
+	removeButtons := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}
+	if !queued {
+		return &CallbackResponse{Text: i18n.T(ctx.Locale, "broadcast.not_draft", id), Markup: removeButtons}, nil
+	}
+
+	s.wakeBroadcaster()
+	return &CallbackResponse{EditText: i18n.T(ctx.Locale, "broadcast.queued", id), Markup: removeButtons}, nil
+}
+
+// handleBroadcastCancel descarta o broadcast ainda não confirmado
+func (s *TelegramService) handleBroadcastCancel(ctx *CallbackContext) (*CallbackResponse, error) {
+	if !s.isAdmin(ctx.Query.From.ID) {
+		return &CallbackResponse{Text: i18n.T(ctx.Locale, "command.not_allowed", "broadcast"), ShowAlert: true}, nil
+	}
+
+	id, err := strconv.ParseInt(ctx.Payload, 10, 64)
+	if err != nil {
+		return nil, errInvalidCallback
+	}
+
+	if _, err := s.db.CancelBroadcast(id); err != nil {
+		return nil, err
+	}
+	return &CallbackResponse{
+		EditText: i18n.T(ctx.Locale, "broadcast.cancelled", id),
+		Markup:   &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}},
+	}, nil
+}
+
+// describeBroadcasts lista os broadcasts recentes com o resultado das entregas
+func (s *TelegramService) describeBroadcasts(locale string) string {
+	broadcasts, err := s.db.ListBroadcasts(5)
+	if err != nil {
+		log.Printf("Erro ao listar broadcasts: %v", err)
+		return i18n.T(locale, "error.generic")
+	}
+	if len(broadcasts) == 0 {
+		return i18n.T(locale, "broadcast.empty")
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "broadcast.list_header"))
+	for _, broadcast := range broadcasts {
+		fmt.Fprintf(&text, "\n\n%s", i18n.T(locale, "broadcast.item",
+			broadcast.ID, i18n.T(locale, "broadcast.status."+broadcast.Status),
+			broadcast.Delivered, broadcast.Blocked, broadcast.Failed, broadcast.Total,
+			database.TruncateText(broadcast.Text, 60),
+		))
+	}
+	return text.String()
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// CallbackQuery é o clique em um botão de teclado inline
+type CallbackQuery struct {
+	ID              string                  `json:"id"`
+	From            *models.TelegramUser    `json:"from"`
+	Message         *models.TelegramMessage `json:"message,omitempty"`
+	InlineMessageID string                  `json:"inline_message_id,omitempty"`
+	Data            string                  `json:"data,omitempty"`
+}
+
+// CallbackContext reúne os dados entregues ao handler de uma ação
+type CallbackContext struct {
+	Query   *CallbackQuery
+	Payload string
+	Locale  string
+}
+
+// CallbackResponse descreve como responder ao clique. Text aparece como
+// notificação no Telegram; EditText e Markup alteram a mensagem de origem
+type CallbackResponse struct {
+	Text      string
+	ShowAlert bool
+	EditText  string
+	ParseMode string
+	Markup    *InlineKeyboardMarkup // Com EditText vazio, substitui apenas o teclado
+}
+
+type CallbackHandler func(ctx *CallbackContext) (*CallbackResponse, error)
+
+// maxCallbackData é o limite do Telegram para o campo callback_data
+const maxCallbackData = 64
+
+var errInvalidCallback = errors.New("callback_data inválido")
+
+// CallbackRouter mapeia ações assinadas do callback_data para handlers. O
+// formato é "ação:payload:emissão:assinatura", com a emissão em base 36 e uma
+// assinatura HMAC truncada, para que botões antigos ou forjados sejam recusados
+type CallbackRouter struct {
+	mu     sync.RWMutex
+	routes map[string]callbackRoute
+	secret []byte
+	ttl    time.Duration
+}
+
+// callbackRoute é o handler de uma ação e a validade dos seus botões. Com
+// ttl zero, os botões da ação não expiram
+type callbackRoute struct {
+	handler CallbackHandler
+	ttl     time.Duration
+}
+
+func NewCallbackRouter(secret string, ttl time.Duration) *CallbackRouter {
+	key := sha256.Sum256([]byte("callback:" + secret))
+	return &CallbackRouter{
+		routes: make(map[string]callbackRoute),
+		secret: key[:],
+		ttl:    ttl,
+	}
+}
+
+// Handle registra o handler de uma ação, com a validade padrão do roteador.
+// A ação não pode conter ":"
+func (r *CallbackRouter) Handle(action string, handler CallbackHandler) {
+	r.HandleWithTTL(action, r.ttl, handler)
+}
+
+// HandleWithTTL registra o handler de uma ação com validade própria. Com ttl
+// zero, os botões da ação valem enquanto a mensagem existir
+func (r *CallbackRouter) HandleWithTTL(action string, ttl time.Duration, handler CallbackHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.routes[action] = callbackRoute{handler: handler, ttl: ttl}
+}
+
+// Encode monta o callback_data assinado de um botão
+func (r *CallbackRouter) Encode(action, payload string) (string, error) {
+	body := fmt.Sprintf("%s:%s:%s", action, payload, strconv.FormatInt(time.Now().Unix(), 36))
+	data := body + ":" + r.sign(body)
+	if len(data) > maxCallbackData {
+		return "", fmt.Errorf("callback_data da ação %s excede %d bytes", action, maxCallbackData)
+	}
+	return data, nil
+}
+
+// Button cria um botão que dispara a ação informada
+func (r *CallbackRouter) Button(text, action, payload string) (InlineKeyboardButton, error) {
+	data, err := r.Encode(action, payload)
+	if err != nil {
+		return InlineKeyboardButton{}, err
+	}
+	return InlineKeyboardButton{Text: text, CallbackData: data}, nil
+}
+
+// decode valida a assinatura e retorna a ação, o payload e o instante de emissão
+func (r *CallbackRouter) decode(data string) (string, string, time.Time, error) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 4 {
+		return "", "", time.Time{}, errInvalidCallback
+	}
+
+	sig := parts[len(parts)-1]
+	body := strings.Join(parts[:len(parts)-1], ":")
+	if !hmac.Equal([]byte(sig), []byte(r.sign(body))) {
+		return "", "", time.Time{}, errInvalidCallback
+	}
+
+	issued, err := strconv.ParseInt(parts[len(parts)-2], 36, 64)
+	if err != nil {
+		return "", "", time.Time{}, errInvalidCallback
+	}
+
+	return parts[0], strings.Join(parts[1:len(parts)-2], ":"), time.Unix(issued, 0), nil
+}
+
+// expired indica se um botão da ação emitido em issuedAt já passou da
+// validade. Ações desconhecidas usam a validade padrão do roteador
+func (r *CallbackRouter) expired(action string, issuedAt time.Time) bool {
+	r.mu.RLock()
+	route, ok := r.routes[action]
+	r.mu.RUnlock()
+
+	ttl := r.ttl
+	if ok {
+		ttl = route.ttl
+	}
+	return ttl > 0 && time.Since(issuedAt) > ttl
+}
+
+func (r *CallbackRouter) sign(body string) string {
+	mac := hmac.New(sha256.New, r.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func (r *CallbackRouter) handler(action string) (CallbackHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	route, ok := r.routes[action]
+	return route.handler, ok
+}
+
+// registerCallbacks registra os handlers das ações dos botões inline
+func (s *TelegramService) registerCallbacks() {
+	s.callbacks.Handle("bc_send", s.handleBroadcastSend)
+	s.callbacks.Handle("bc_cancel", s.handleBroadcastCancel)
+	s.callbacks.Handle("st_menu", s.handleSettingsMenu)
+	s.callbacks.Handle("st_open", s.handleSettingsOpen)
+	s.callbacks.Handle("st_set", s.handleSettingsSet)
+	s.callbacks.Handle("st_close", s.handleSettingsClose)
+	// Respostas continuam avaliáveis enquanto existirem
+	s.callbacks.HandleWithTTL("fb_rate", 0, s.handleFeedbackRate)
+}
+
+// handleCallbackQuery encaminha o clique ao handler da ação. Botões expirados,
+// inválidos ou de ações desconhecidas são removidos da mensagem, junto com a
+// linha em que estão; os demais botões são mantidos
+func (s *TelegramService) handleCallbackQuery(cb *CallbackQuery) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recuperado de panic ao processar callback: %v", r)
+		}
+	}()
+
+	if cb.From == nil {
+		return
+	}
+	locale := s.userLocale(cb.From)
+
+	action, payload, issuedAt, err := s.callbacks.decode(cb.Data)
+	handler, ok := s.callbacks.handler(action)
+	if err != nil || !ok || s.callbacks.expired(action, issuedAt) {
+		s.answerCallbackQuery(cb, &CallbackResponse{Text: i18n.T(locale, "callback.expired"), ShowAlert: true})
+		s.removeCallbackButton(cb)
+		return
+	}
+
+	response, err := handler(&CallbackContext{Query: cb, Payload: payload, Locale: locale})
+	if err != nil {
+		log.Printf("Erro ao processar ação %s: %v", action, err)
+		s.answerCallbackQuery(cb, &CallbackResponse{Text: i18n.T(locale, "error.generic"), ShowAlert: true})
+		return
+	}
+	if response == nil {
+		response = &CallbackResponse{}
+	}
+
+	// O Telegram exige a resposta ao callback, mesmo sem texto, para encerrar o indicador de carregamento
+	s.answerCallbackQuery(cb, response)
+	s.editCallbackMessage(cb, response)
+}
+
+func (s *TelegramService) answerCallbackQuery(cb *CallbackQuery, response *CallbackResponse) {
+	payload := map[string]interface{}{
+		"callback_query_id": cb.ID,
+	}
+	if response.Text != "" {
+		payload["text"] = response.Text
+		payload["show_alert"] = response.ShowAlert
+	}
+
+	if _, err := s.makeRequest("answerCallbackQuery", payload); err != nil {
+		log.Printf("Erro ao responder callback: %v", err)
+	}
+}
+
+// removeCallbackButton tira da mensagem a linha do botão clicado. O teclado é
+// reconstruído a partir do recebido com o clique, para não afetar botões que
+// não pertencem ao roteador, como os de Mini App. Sem o teclado (mensagens
+// inline), a mensagem não é alterada
+func (s *TelegramService) removeCallbackButton(cb *CallbackQuery) {
+	if cb.Message == nil || cb.Message.Chat == nil || cb.Message.ReplyMarkup == nil {
+		return
+	}
+
+	markup, ok := withoutCallbackRow(cb.Message.ReplyMarkup, cb.Data)
+	if !ok {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":      cb.Message.Chat.ID,
+		"message_id":   cb.Message.MessageID,
+		"reply_markup": markup,
+	}
+	if _, err := s.makeRequest("editMessageReplyMarkup", payload); err != nil {
+		log.Printf("Erro ao remover botão expirado: %v", err)
+	}
+}
+
+// withoutCallbackRow remove do teclado as linhas com o callback_data informado.
+// Os botões são mantidos em JSON para preservar campos que o bot não modela.
+// Retorna false se nenhuma linha foi removida
+func withoutCallbackRow(markup json.RawMessage, data string) (json.RawMessage, bool) {
+	var keyboard struct {
+		InlineKeyboard [][]json.RawMessage `json:"inline_keyboard"`
+	}
+	if err := json.Unmarshal(markup, &keyboard); err != nil {
+		return nil, false
+	}
+
+	rows := [][]json.RawMessage{}
+	for _, row := range keyboard.InlineKeyboard {
+		keep := true
+		for _, raw := range row {
+			var button struct {
+				CallbackData string `json:"callback_data"`
+			}
+			if json.Unmarshal(raw, &button) == nil && button.CallbackData == data {
+				keep = false
+			}
+		}
+		if keep {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == len(keyboard.InlineKeyboard) {
+		return nil, false
+	}
+
+	result, err := json.Marshal(map[string]interface{}{"inline_keyboard": rows})
+	if err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// editCallbackMessage aplica à mensagem de origem o texto e o teclado da resposta
+func (s *TelegramService) editCallbackMessage(cb *CallbackQuery, response *CallbackResponse) {
+	if response.EditText == "" && response.Markup == nil {
+		return
+	}
+
+	payload := map[string]interface{}{}
+	if cb.InlineMessageID != "" {
+		payload["inline_message_id"] = cb.InlineMessageID
+	} else if cb.Message != nil && cb.Message.Chat != nil {
+		payload["chat_id"] = cb.Message.Chat.ID
+		payload["message_id"] = cb.Message.MessageID
+	} else {
+		return
+	}
+	if response.Markup != nil {
+		payload["reply_markup"] = response.Markup
+	}
+
+	method := "editMessageReplyMarkup"
+	if response.EditText != "" {
+		method = "editMessageText"
+		payload["text"] = response.EditText
+		if response.ParseMode != "" {
+			payload["parse_mode"] = response.ParseMode
+		}
+	}
+
+	if _, err := s.makeRequest(method, payload); err != nil {
+		log.Printf("Erro ao editar mensagem do callback: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallbackRouterRoundTrip(t *testing.T) {
+	router := NewCallbackRouter("segredo", time.Hour)
+
+	tests := []struct {
+		action, payload string
+	}{
+		{"bc_send", "42"},
+		{"st_close", ""},
+		{"st_set", "temperature=creative"},
+		{"fb_rate", "ab:cd=up"}, // O payload pode conter ":"
+	}
+
+	for _, tt := range tests {
+		data, err := router.Encode(tt.action, tt.payload)
+		if err != nil {
+			t.Fatalf("Encode(%q, %q): %v", tt.action, tt.payload, err)
+		}
+		if len(data) > maxCallbackData {
+			t.Errorf("Encode(%q, %q) gerou %d bytes, acima do limite", tt.action, tt.payload, len(data))
+		}
+
+		action, payload, issuedAt, err := router.decode(data)
+		if err != nil {
+			t.Fatalf("decode(%q): %v", data, err)
+		}
+		if action != tt.action || payload != tt.payload {
+			t.Errorf("decode(%q) = (%q, %q), esperado (%q, %q)", data, action, payload, tt.action, tt.payload)
+		}
+		if time.Since(issuedAt) > time.Minute {
+			t.Errorf("decode(%q) retornou emissão %v", data, issuedAt)
+		}
+	}
+}
+
+func TestCallbackRouterEncodeTooLong(t *testing.T) {
+	router := NewCallbackRouter("segredo", time.Hour)
+
+	if _, err := router.Encode("acao", strings.Repeat("x", maxCallbackData)); err == nil {
+		t.Fatal("Encode aceitou callback_data acima de 64 bytes")
+	}
+}
+
+func TestCallbackRouterRejectsForgery(t *testing.T) {
+	router := NewCallbackRouter("segredo", time.Hour)
+	data, err := router.Encode("bc_send", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(data, ":")
+
+	tests := map[string]string{
+		"payload alterado":     strings.Join([]string{parts[0], "43", parts[2], parts[3]}, ":"),
+		"ação alterada":        strings.Join([]string{"bc_cancel", parts[1], parts[2], parts[3]}, ":"),
+		"emissão alterada":     strings.Join([]string{parts[0], parts[1], "zzzzzz", parts[3]}, ":"),
+		"assinatura alterada":  strings.Join([]string{parts[0], parts[1], parts[2], "AAAAAAAAAAA"}, ":"),
+		"sem assinatura":       strings.Join(parts[:3], ":"),
+		"formato antigo":       "bc_send",
+		"vazio":                "",
+		"outro segredo":        mustEncode(t, NewCallbackRouter("outro", time.Hour), "bc_send", "42"),
+		"assinatura duplicada": data + ":" + parts[3],
+	}
+
+	for name, forged := range tests {
+		if _, _, _, err := router.decode(forged); err == nil {
+			t.Errorf("%s: decode(%q) aceitou callback forjado", name, forged)
+		}
+	}
+}
+
+func TestCallbackRouterTTL(t *testing.T) {
+	router := NewCallbackRouter("segredo", time.Hour)
+	data := mustEncode(t, router, "bc_send", "42")
+
+	_, _, issuedAt, err := router.decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if router.expired("bc_send", issuedAt) {
+		t.Error("botão recém-criado já expirado")
+	}
+	if router.expired("bc_send", time.Now().Add(-59*time.Minute)) {
+		t.Error("botão expirado antes do TTL")
+	}
+	if !router.expired("bc_send", time.Now().Add(-61*time.Minute)) {
+		t.Error("botão não expirou depois do TTL")
+	}
+}
+
+func TestCallbackRouterPerActionTTL(t *testing.T) {
+	router := NewCallbackRouter("segredo", time.Hour)
+	noop := func(*CallbackContext) (*CallbackResponse, error) { return nil, nil }
+	router.Handle("padrao", noop)
+	router.HandleWithTTL("curto", time.Minute, noop)
+	router.HandleWithTTL("eterno", 0, noop)
+
+	old := time.Now().Add(-2 * time.Minute)
+	ancient := time.Now().Add(-365 * 24 * time.Hour)
+
+	if router.expired("padrao", old) {
+		t.Error("ação com TTL padrão expirou antes de uma hora")
+	}
+	if !router.expired("curto", old) {
+		t.Error("ação com TTL de um minuto não expirou")
+	}
+	if router.expired("eterno", ancient) {
+		t.Error("ação sem TTL expirou")
+	}
+	if !router.expired("desconhecida", ancient) {
+		t.Error("ação desconhecida deveria usar o TTL padrão")
+	}
+}
+
+func TestWithoutCallbackRow(t *testing.T) {
+	markup := `{"inline_keyboard":[` +
+		`[{"text":"app","web_app":{"url":"https://ex.com/m/1"}}],` +
+		`[{"text":"👍","callback_data":"up"},{"text":"👎","callback_data":"down"}],` +
+		`[{"text":"login","login_url":{"url":"https://ex.com"}}]]}`
+
+	got, ok := withoutCallbackRow(json.RawMessage(markup), "down")
+	if !ok {
+		t.Fatal("linha do botão não removida")
+	}
+	want := `{"inline_keyboard":[` +
+		`[{"text":"app","web_app":{"url":"https://ex.com/m/1"}}],` +
+		`[{"text":"login","login_url":{"url":"https://ex.com"}}]]}`
+	if string(got) != want {
+		t.Errorf("teclado = %s, esperado %s", got, want)
+	}
+
+	if _, ok := withoutCallbackRow(json.RawMessage(markup), "outro"); ok {
+		t.Error("removeu linha sem o botão clicado")
+	}
+	if _, ok := withoutCallbackRow(json.RawMessage(`não é json`), "up"); ok {
+		t.Error("aceitou teclado inválido")
+	}
+
+	got, _ = withoutCallbackRow(json.RawMessage(`{"inline_keyboard":[[{"text":"x","callback_data":"up"}]]}`), "up")
+	if string(got) != `{"inline_keyboard":[]}` {
+		t.Errorf("teclado vazio = %s", got)
+	}
+}
+
+func mustEncode(t *testing.T, router *CallbackRouter, action, payload string) string {
+	t.Helper()
+	data, err := router.Encode(action, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// CommandScope indica onde um comando pode ser usado. Os valores podem ser combinados
+type CommandScope int
+
+const (
+	ScopePrivate CommandScope = 1 << iota // Chats privados com o bot
+	ScopeGroup                            // Grupos e supergrupos
+	ScopeAdmin                            // Restrito aos usuários de ADMIN_USER_IDS
+)
+
+// CommandArgs define como o texto após o comando é tratado
+type CommandArgs int
+
+const (
+	ArgsNone     CommandArgs = iota // Texto após o comando é ignorado
+	ArgsOptional                    // Repassado ao handler, podendo ser vazio
+	ArgsRequired                    // Sem argumentos, o texto de Usage é enviado no lugar do handler
+)
+
+// Command descreve um comando do bot. A descrição exibida no /help e no menu
+// do Telegram vem da chave "command.<Name>" do catálogo de cada idioma
+type Command struct {
+	Name    string // Sem a barra, em minúsculas
+	Scopes  CommandScope
+	Args    CommandArgs
+	Usage   string // Chave do catálogo enviada quando faltam argumentos obrigatórios
+	Hidden  bool   // Fora do /help e do menu de comandos
+	Handler func(msg *models.TelegramMessage, args string)
+}
+
+// CommandRegistry guarda os comandos na ordem em que foram registrados
+type CommandRegistry struct {
+	commands []*Command
+	byName   map[string]*Command
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{byName: make(map[string]*Command)}
+}
+
+func (r *CommandRegistry) Register(cmd *Command) {
+	if _, exists := r.byName[cmd.Name]; exists {
+		panic(fmt.Sprintf("comando /%s registrado mais de uma vez", cmd.Name))
+	}
+	r.commands = append(r.commands, cmd)
+	r.byName[cmd.Name] = cmd
+}
+
+func (r *CommandRegistry) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.byName[name]
+	return cmd, ok
+}
+
+// visible retorna os comandos exibidos no escopo informado. Comandos de
+// administração só entram quando includeAdmin é verdadeiro
+func (r *CommandRegistry) visible(scope CommandScope, includeAdmin bool) []*Command {
+	var commands []*Command
+	for _, cmd := range r.commands {
+		if cmd.Hidden || cmd.Scopes&scope == 0 {
+			continue
+		}
+		if cmd.Scopes&ScopeAdmin != 0 && !includeAdmin {
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// parseCommand separa nome e argumentos de um texto como "/cmd@bot args".
+// Retorna ok=false se o texto não for um comando ou se for destinado a outro
+// bot; addressed indica que o comando trazia o sufixo @ deste bot
+func parseCommand(text, botUserName string) (name, args string, addressed, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false, false
+	}
+
+	head := text[1:]
+	if i := strings.IndexFunc(head, unicode.IsSpace); i >= 0 {
+		head, args = head[:i], strings.TrimSpace(head[i:])
+	}
+
+	name, target, hasTarget := strings.Cut(head, "@")
+	if hasTarget && !strings.EqualFold(target, botUserName) {
+		return "", "", false, false
+	}
+	if name == "" {
+		return "", "", false, false
+	}
+
+	return strings.ToLower(name), args, hasTarget, true
+}
+
+// registerCommands registra os comandos atendidos pelo bot
+func (s *TelegramService) registerCommands() {
+	everywhere := ScopePrivate | ScopeGroup
+
+	s.commands.Register(&Command{Name: "start", Scopes: everywhere, Args: ArgsOptional, Hidden: true, Handler: s.handleStart})
+	s.commands.Register(&Command{Name: "help", Scopes: everywhere, Handler: s.handleHelpCommand})
+	s.commands.Register(&Command{Name: "newchat", Scopes: everywhere, Handler: s.handleNewChatCommand})
+	s.commands.Register(&Command{Name: "language", Scopes: everywhere, Args: ArgsOptional, Handler: s.handleLanguageCommand})
+	s.commands.Register(&Command{Name: "schedule", Scopes: everywhere, Args: ArgsOptional, Handler: s.handleScheduleCommand})
+	s.commands.Register(&Command{Name: "schedules", Scopes: everywhere, Handler: withoutArgs(s.handleListSchedules)})
+	s.commands.Register(&Command{Name: "unschedule", Scopes: everywhere, Args: ArgsRequired, Usage: "schedule.unschedule_usage", Handler: s.handleUnscheduleCommand})
+	s.commands.Register(&Command{Name: "delivery", Scopes: everywhere, Args: ArgsOptional, Handler: s.handleDeliveryCommand})
+	s.commands.Register(&Command{Name: "group", Scopes: ScopeGroup, Args: ArgsOptional, Handler: s.handleGroupCommand})
+	s.commands.Register(&Command{Name: "timezone", Scopes: everywhere, Args: ArgsOptional, Handler: s.handleTimezoneCommand})
+	s.commands.Register(&Command{Name: "settings", Scopes: ScopePrivate, Handler: withoutArgs(s.handleSettingsCommand)})
+
+	// Administração do acesso ao bot
+	s.commands.Register(&Command{Name: "access", Scopes: everywhere | ScopeAdmin, Args: ArgsOptional, Handler: s.handleAccessCommand})
+	s.commands.Register(&Command{Name: "invite", Scopes: everywhere | ScopeAdmin, Args: ArgsOptional, Handler: s.handleInviteCommand})
+	s.commands.Register(&Command{Name: "ban", Scopes: everywhere | ScopeAdmin, Args: ArgsOptional, Handler: s.handleBanCommand})
+	s.commands.Register(&Command{Name: "unban", Scopes: everywhere | ScopeAdmin, Args: ArgsRequired, Usage: "ban.unban_usage", Handler: s.handleUnbanCommand})
+
+	// Operação do bot
+	s.commands.Register(&Command{Name: "stats", Scopes: ScopePrivate | ScopeAdmin, Handler: withoutArgs(s.handleStatsCommand)})
+	s.commands.Register(&Command{Name: "user", Scopes: ScopePrivate | ScopeAdmin, Args: ArgsRequired, Usage: "user.usage", Handler: s.handleUserCommand})
+	s.commands.Register(&Command{Name: "maintenance", Scopes: everywhere | ScopeAdmin, Args: ArgsOptional, Handler: s.handleMaintenanceCommand})
+	s.commands.Register(&Command{Name: "provider", Scopes: ScopePrivate | ScopeAdmin, Handler: withoutArgs(s.handleProviderCommand)})
+	s.commands.Register(&Command{Name: "broadcast", Scopes: ScopePrivate | ScopeAdmin, Args: ArgsOptional, Handler: s.handleBroadcastCommand})
+}
+
+// withoutArgs adapta handlers que não recebem argumentos
+func withoutArgs(handler func(msg *models.TelegramMessage)) func(msg *models.TelegramMessage, args string) {
+	return func(msg *models.TelegramMessage, _ string) {
+		handler(msg)
+	}
+}
+
+// handleCommand valida o escopo e os argumentos e executa o comando. Retorna
+// false se o texto não for um comando destinado a este bot
+func (s *TelegramService) handleCommand(msg *models.TelegramMessage) bool {
+	name, args, addressed, ok := parseCommand(msg.Text, s.botInfo.UserName)
+	if !ok {
+		return false
+	}
+
+	locale := s.userLocale(msg.From)
+	private := msg.Chat.Type == "private"
+
+	cmd, ok := s.commands.Lookup(name)
+	if !ok {
+		// Em grupos, comandos sem o sufixo @bot podem ser de outros bots
+		if private || addressed {
+			s.sendTextMessage(msg, i18n.T(locale, "command.unknown", name))
+		}
+		return true
+	}
+
+	if !s.commandAllowed(cmd, msg) {
+		s.sendTextMessage(msg, i18n.T(locale, "command.not_allowed", name))
+		return true
+	}
+
+	switch cmd.Args {
+	case ArgsNone:
+		args = ""
+	case ArgsRequired:
+		if args == "" {
+			s.sendTextMessage(msg, i18n.T(locale, cmd.Usage))
+			return true
+		}
+	}
+
+	cmd.Handler(msg, args)
+	return true
+}
+
+// commandAllowed verifica o tipo de chat e, para comandos de administração, o usuário
+func (s *TelegramService) commandAllowed(cmd *Command, msg *models.TelegramMessage) bool {
+	scope := ScopeGroup
+	if msg.Chat.Type == "private" {
+		scope = ScopePrivate
+	}
+	if cmd.Scopes&scope == 0 {
+		return false
+	}
+	return cmd.Scopes&ScopeAdmin == 0 || s.isAdmin(msg.From.ID)
+}
+
+func (s *TelegramService) isAdmin(userID int64) bool {
+	for _, id := range s.config.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleStart trata o /start simples e os deeplinks /start msg_<hash> e /start inv_<code>
+func (s *TelegramService) handleStart(msg *models.TelegramMessage, args string) {
+	if code, ok := strings.CutPrefix(args, "inv_"); ok {
+		s.redeemInvite(msg, code)
+		return
+	}
+	if strings.HasPrefix(args, "msg_") {
+		s.handleStartCommand(msg)
+		return
+	}
+	s.sendWelcomeMessage(msg)
+}
+
+// handleHelpCommand lista os comandos disponíveis para o usuário no chat atual
+func (s *TelegramService) handleHelpCommand(msg *models.TelegramMessage, _ string) {
+	locale := s.userLocale(msg.From)
+	scope := ScopeGroup
+	if msg.Chat.Type == "private" {
+		scope = ScopePrivate
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "help.header"))
+	for _, cmd := range s.commands.visible(scope, s.isAdmin(msg.From.ID)) {
+		fmt.Fprintf(&text, "\n/%s - %s", cmd.Name, i18n.T(locale, "command."+cmd.Name))
+	}
+
+	s.sendTextMessage(msg, text.String())
+}
+
+func (s *TelegramService) handleNewChatCommand(msg *models.TelegramMessage, _ string) {
+	err := s.ai.NewChat(s.conversationOwner(msg, s.messagePolicy(msg)), messageTopic(msg))
+	if err != nil {
+		log.Printf("Erro ao criar novo chat: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	// Para o comando /newchat, ainda precisamos salvar a mensagem pois não é processada pelo serviço de IA
+	text := i18n.T(s.userLocale(msg.From), "newchat.started")
+	hash, err := s.db.SaveMessage(text)
+	if err != nil {
+		log.Printf("Erro ao salvar mensagem: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+	s.sendResponseWithHash(msg, text, hash)
+}
+
+// BotCommand é o formato usado por setMyCommands
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// syncCommands publica o menu de comandos no Telegram para cada escopo e idioma
+func (s *TelegramService) syncCommands() {
+	type commandScope struct {
+		scope        map[string]interface{}
+		chatScope    CommandScope
+		includeAdmin bool
+	}
+
+	scopes := []commandScope{
+		{map[string]interface{}{"type": "all_private_chats"}, ScopePrivate, false},
+		{map[string]interface{}{"type": "all_group_chats"}, ScopeGroup, false},
+	}
+
+	// Administradores recebem, no chat privado, um menu com os comandos de administração
+	if len(s.commands.visible(ScopePrivate, true)) > len(s.commands.visible(ScopePrivate, false)) {
+		for _, id := range s.config.AdminUserIDs {
+			scopes = append(scopes, commandScope{map[string]interface{}{"type": "chat", "chat_id": id}, ScopePrivate, true})
+		}
+	}
+
+	for _, scope := range scopes {
+		commands := s.commands.visible(scope.chatScope, scope.includeAdmin)
+
+		// O menu sem language_code usa o idioma padrão e serve aos demais idiomas
+		languages := []string{""}
+		for _, locale := range i18n.Supported {
+			languages = append(languages, locale.Code)
+		}
+
+		for _, language := range languages {
+			locale := language
+			if locale == "" {
+				locale = i18n.DefaultLocale
+			}
+
+			botCommands := make([]BotCommand, 0, len(commands))
+			for _, cmd := range commands {
+				botCommands = append(botCommands, BotCommand{Command: cmd.Name, Description: i18n.T(locale, "command."+cmd.Name)})
+			}
+
+			payload := map[string]interface{}{
+				"commands": botCommands,
+				"scope":    scope.scope,
+			}
+			if language != "" {
+				// O Telegram usa apenas o código ISO 639-1 (ex: "pt")
+				base, _, _ := strings.Cut(language, "-")
+				payload["language_code"] = base
+			}
+
+			if _, err := s.makeRequest("setMyCommands", payload); err != nil {
+				log.Printf("Erro ao publicar comandos (escopo=%v, idioma=%q): %v", scope.scope["type"], language, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"bot-ai/config"
+	"bot-ai/database"
+	"bot-ai/models"
+)
+
+// loadConversation busca o chat pedido em opts ou o chat ativo do usuário, criando
+// um novo se necessário, e retorna o histórico de mensagens que deve ser enviado ao provedor
+func loadConversation(db *database.Database, userID int64, opts models.AskOptions) (*models.ChatHistory, []models.ChatMessage, error) {
+	if opts.ReplaceTurnID != 0 {
+		return loadTurnConversation(db, userID, opts.ReplaceTurnID)
+	}
+
+	var chat *models.ChatHistory
+	var err error
+	if opts.ChatHistoryID != 0 {
+		chat, err = db.GetChat(opts.ChatHistoryID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao buscar chat: %w", err)
+		}
+		// Nunca continua o chat de outro usuário
+		if chat != nil && chat.UserID != userID {
+			chat = nil
+		}
+	}
+
+	if chat == nil {
+		chat, err = db.GetActiveChat(userID, opts.Topic)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao buscar chat ativo: %w", err)
+		}
+		if chat != nil && chatExpired(chat, userID, opts.Preferences) {
+			chat = nil
+		}
+	}
+
+	// Se não houver nenhum chat, cria um novo
+	if chat == nil {
+		chat, err = db.CreateNewChat(userID, opts.Topic)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao criar novo chat: %w", err)
+		}
+	}
+
+	messages, err := db.GetChatMessages(chat.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao recuperar histórico: %w", err)
+	}
+
+	return chat, currentTurns(messages, 0), nil
+}
+
+// chatExpired indica se o chat ativo ficou parado por mais tempo que o limite
+// escolhido pelo usuário no /settings. Só vale para o histórico do próprio
+// usuário, nunca para as conversas compartilhadas de grupo
+func chatExpired(chat *models.ChatHistory, userID int64, prefs *models.UserSettings) bool {
+	if prefs == nil || prefs.AutoNewChatHours <= 0 || prefs.UserID != userID {
+		return false
+	}
+	return time.Since(chat.UpdatedAt) > time.Duration(prefs.AutoNewChatHours)*time.Hour
+}
+
+// loadTurnConversation carrega o chat de uma pergunta editada, com o histórico
+// anterior a ela, para que a resposta seja gerada de novo
+func loadTurnConversation(db *database.Database, userID, turnID int64) (*models.ChatHistory, []models.ChatMessage, error) {
+	turn, err := db.GetChatTurn(turnID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if turn == nil || turn.Role != "user" {
+		return nil, nil, fmt.Errorf("pergunta %d não encontrada no histórico", turnID)
+	}
+
+	chat, err := db.GetChat(turn.ChatHistoryID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao buscar chat: %w", err)
+	}
+	if chat == nil || chat.UserID != userID {
+		return nil, nil, fmt.Errorf("pergunta %d não pertence à conversa %d", turnID, userID)
+	}
+
+	messages, err := db.GetChatMessages(chat.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao recuperar histórico: %w", err)
+	}
+
+	return chat, currentTurns(messages, turnID), nil
+}
+
+// currentTurns remove do histórico as respostas substituídas e, se before for
+// diferente de zero, as mensagens a partir desse turno
+func currentTurns(messages []models.ChatMessage, before int64) []models.ChatMessage {
+	var turns []models.ChatMessage
+	for _, msg := range messages {
+		if before != 0 && msg.ID >= before {
+			break
+		}
+		if !msg.Superseded {
+			turns = append(turns, msg)
+		}
+	}
+	return turns
+}
+
+// saveExchange grava a pergunta e a resposta no histórico do chat e retorna o hash
+// da resposta. É o mesmo caminho de persistência para todos os provedores, e só deve
+// ser chamado após uma resposta bem-sucedida para não duplicar perguntas em novas tentativas
+func saveExchange(ai models.AIService, cfg *config.Config, db *database.Database, chat *models.ChatHistory, history []models.ChatMessage, question, answer string, opts models.AskOptions) (string, error) {
+	// Salva a pergunta no histórico, com o autor nas conversas compartilhadas.
+	// Perguntas editadas reescrevem o turno original
+	if opts.ReplaceTurnID != 0 {
+		if err := db.ReplaceQuestion(opts.ReplaceTurnID, question); err != nil {
+			return "", err
+		}
+	} else if err := db.AddAuthoredMessageToChat(chat.ID, "user", question, opts.AuthorID, opts.AuthorName); err != nil {
+		return "", fmt.Errorf("erro ao salvar pergunta no histórico: %w", err)
+	}
+
+	// Salva a resposta na tabela messages, com a origem usada nas avaliações, e obtém o hash
+	hash, err := db.SaveAnswer(answer, answerSource(cfg, opts.Preferences))
+	if err != nil {
+		return "", fmt.Errorf("erro ao salvar resposta: %w", err)
+	}
+
+	// Adiciona a resposta ao histórico do chat usando o hash já existente
+	if err := db.AddMessageToChatWithExistingHash(chat.ID, "assistant", answer, hash); err != nil {
+		return "", fmt.Errorf("erro ao salvar resposta no histórico: %w", err)
+	}
+
+	// Gera o título do chat em segundo plano após a primeira troca de mensagens
+	if len(history) == 0 {
+		go generateChatTitle(ai, db, chat.ID, question, answer)
+	}
+
+	return hash, nil
+}
+
+// turnContent retorna o conteúdo de uma mensagem do histórico como é enviado ao
+// provedor. Em conversas compartilhadas, identifica quem enviou cada pergunta
+func turnContent(msg models.ChatMessage) string {
+	if msg.Role == "user" && msg.AuthorName != "" {
+		return fmt.Sprintf("[%s]: %s", msg.AuthorName, msg.Content)
+	}
+	return msg.Content
+}
+
+// questionContent aplica à pergunta atual a mesma identificação de autor do histórico
+func questionContent(question string, opts models.AskOptions) string {
+	return turnContent(models.ChatMessage{Role: "user", Content: question, AuthorName: opts.AuthorName})
+}
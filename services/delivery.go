@@ -0,0 +1,277 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"unicode"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// Modos de entrega das respostas
+const (
+	DeliveryPreview = "preview" // Prévia curta com botão para o Mini App
+	DeliveryFull    = "full"    // Texto completo no chat, dividido em várias mensagens se necessário
+	DeliveryAuto    = "auto"    // Texto completo para respostas curtas, prévia para as longas
+)
+
+var deliveryModes = []string{DeliveryPreview, DeliveryFull, DeliveryAuto}
+
+// telegramMaxLength é o limite de caracteres de uma mensagem do Telegram
+const telegramMaxLength = 4096
+
+// deliveryMode retorna o modo escolhido pelo usuário com /delivery ou o padrão configurado
+func (s *TelegramService) deliveryMode(userID int64) string {
+	settings, err := s.db.GetUserSettings(userID)
+	if err != nil {
+		log.Printf("Erro ao buscar modo de entrega do usuário %d: %v", userID, err)
+	} else if settings.DeliveryMode != "" {
+		return settings.DeliveryMode
+	}
+	return s.config.DefaultDeliveryMode
+}
+
+// wantsFullText decide se a resposta deve ser enviada completa no chat
+func (s *TelegramService) wantsFullText(userID int64, answer string) bool {
+	switch s.deliveryMode(userID) {
+	case DeliveryFull:
+		return true
+	case DeliveryAuto:
+		return textLength(answer) <= s.config.DeliveryAutoMaxLength
+	default:
+		return false
+	}
+}
+
+// handleDeliveryCommand mostra ou altera o modo de entrega das respostas
+func (s *TelegramService) handleDeliveryCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	requested := strings.ToLower(strings.TrimSpace(args))
+
+	if requested == "" {
+		current := s.deliveryMode(msg.From.ID)
+		s.sendTextMessage(msg, i18n.T(locale, "delivery.current", i18n.T(locale, "delivery.mode."+current), deliveryOptions(locale)))
+		return
+	}
+
+	valid := false
+	for _, mode := range deliveryModes {
+		valid = valid || mode == requested
+	}
+	if !valid {
+		s.sendTextMessage(msg, i18n.T(locale, "delivery.unknown", requested, deliveryOptions(locale)))
+		return
+	}
+
+	if err := s.db.SetUserDeliveryMode(msg.From.ID, requested); err != nil {
+		log.Printf("Erro ao salvar modo de entrega do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(locale, "delivery.set", i18n.T(locale, "delivery.mode."+requested)))
+}
+
+// deliveryOptions lista os modos disponíveis, um por linha
+func deliveryOptions(locale string) string {
+	var sb strings.Builder
+	for _, mode := range deliveryModes {
+		fmt.Fprintf(&sb, "/delivery %s - %s\n", mode, i18n.T(locale, "delivery.mode."+mode))
+	}
+	return sb.String()
+}
+
+// textLength conta o tamanho do texto como o Telegram: em unidades UTF-16
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// splitMessage divide o texto em partes de até limit caracteres. A divisão
+// acontece de preferência entre parágrafos; blocos de código são mantidos
+// inteiros sempre que cabem em uma parte e, quando não cabem, são fechados e
+// reabertos com a mesma linguagem em cada parte. Nunca divide um caractere
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	var current strings.Builder
+
+	flush := func() {
+		if part := strings.TrimRight(current.String(), "\n"); strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+		current.Reset()
+	}
+
+	for _, block := range splitBlocks(text) {
+		if textLength(current.String())+textLength(block) <= limit {
+			current.WriteString(block)
+			continue
+		}
+
+		flush()
+		if textLength(block) <= limit {
+			current.WriteString(block)
+			continue
+		}
+
+		pieces := splitLargeBlock(block, limit)
+		for _, piece := range pieces[:len(pieces)-1] {
+			current.WriteString(piece)
+			flush()
+		}
+		current.WriteString(pieces[len(pieces)-1])
+	}
+	flush()
+
+	return parts
+}
+
+// splitBlocks separa o texto em parágrafos e blocos de código cercados por ```,
+// preservando as quebras de linha de cada bloco
+func splitBlocks(text string) []string {
+	var blocks []string
+	var current strings.Builder
+	inCode := false
+
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+
+		// Um bloco de código começa sempre em um novo bloco
+		if fence && !inCode && current.Len() > 0 {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+
+		switch {
+		case fence:
+			inCode = !inCode
+			if !inCode {
+				blocks = append(blocks, current.String())
+				current.Reset()
+			}
+		case !inCode && strings.TrimSpace(line) == "" && i > 0:
+			// Linha em branco encerra o parágrafo
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+
+	return blocks
+}
+
+// splitLargeBlock divide um bloco maior que limit linha a linha. Em blocos de
+// código, cada parte recebe a cerca de abertura original e uma de fechamento
+func splitLargeBlock(block string, limit int) []string {
+	lines := strings.SplitAfter(strings.TrimSuffix(block, "\n"), "\n")
+	opener, closer := "", ""
+	if strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		opener = strings.TrimRight(lines[0], "\n") + "\n"
+		closer = "```\n"
+		lines = lines[1:]
+		if last := len(lines) - 1; last >= 0 && strings.HasPrefix(strings.TrimSpace(lines[last]), "```") {
+			lines = lines[:last]
+		}
+	}
+
+	room := limit - textLength(opener) - textLength(closer)
+	var pieces []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			body := current.String()
+			if closer != "" && !strings.HasSuffix(body, "\n") {
+				body += "\n"
+			}
+			pieces = append(pieces, opener+body+closer)
+			current.Reset()
+		}
+	}
+
+	for _, line := range lines {
+		if textLength(current.String())+textLength(line) > room {
+			flush()
+		}
+		for textLength(line) > room {
+			head, rest := cutAtLength(line, room)
+			current.WriteString(head)
+			flush()
+			line = rest
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return pieces
+}
+
+// cutAtLength corta o texto em até limit caracteres, de preferência no último
+// espaço, sem nunca dividir um caractere multibyte
+func cutAtLength(text string, limit int) (string, string) {
+	runes := []rune(text)
+	n, end, lastSpace := 0, 0, -1
+	for i, r := range runes {
+		size := 1
+		if r >= 0x10000 {
+			size = 2
+		}
+		if n+size > limit {
+			break
+		}
+		n += size
+		end = i + 1
+		if unicode.IsSpace(r) {
+			lastSpace = i + 1
+		}
+	}
+
+	if lastSpace > 0 {
+		end = lastSpace
+	}
+	if end == 0 {
+		end = 1
+	}
+	return string(runes[:end]), string(runes[end:])
+}
+
+// fullAnswerMessages monta a resposta completa, dividida em quantas mensagens
+// forem necessárias. Apenas a primeira parte responde à pergunta e apenas a
+// última recebe o botão para o Mini App
+func (s *TelegramService) fullAnswerMessages(msg *models.TelegramMessage, locale, userName, answer string, keyboard *InlineKeyboardMarkup) []renderedMessage {
+	// O cabeçalho vai só na primeira parte, mas o espaço é reservado em todas para simplificar
+	header := i18n.T(locale, "response.header", userName, "")
+	parts := splitMessage(answer, telegramMaxLength-textLength(header))
+
+	messages := make([]renderedMessage, 0, len(parts))
+	for i, part := range parts {
+		// Cada parte é convertida separadamente; o divisor já fecha e reabre os blocos de código
+		text, plain := renderMarkdown(part), part
+		payload := SendMessageRequest{ChatID: msg.Chat.ID, MessageThreadID: messageTopic(msg).ThreadID}
+		if i == 0 {
+			text = i18n.T(locale, "response.header", html.EscapeString(userName), text)
+			plain = i18n.T(locale, "response.header", userName, plain)
+			payload.ReplyToMessageID = msg.MessageID
+		}
+		if i == len(parts)-1 {
+			payload.ReplyMarkup = keyboard
+		}
+		payload.Text = text
+
+		messages = append(messages, renderedMessage{payload, plain})
+	}
+	return messages
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"bot-ai/models"
+)
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"ação", 4},     // Acentos ocupam uma unidade UTF-16
+		{"😀", 2},        // Fora do BMP: par substituto
+		{"a😀b🚀", 6},     // Mistura
+		{"日本語", 3},      // Multibyte, mas dentro do BMP
+		{"👍🏽", 4},       // Emoji com modificador: dois caracteres fora do BMP
+		{"\n\t ", 3},    // Espaços contam normalmente
+		{"```go\n", 6},  // Cercas contam como texto
+		{"<b>x</b>", 8}, // O texto é medido antes da conversão para HTML
+	}
+
+	for _, tt := range tests {
+		if got := textLength(tt.text); got != tt.want {
+			t.Errorf("textLength(%q) = %d, esperado %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCutAtLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		limit      int
+		head, rest string
+	}{
+		{"corta no último espaço", "hello world again", 12, "hello world ", "again"},
+		{"sem espaço corta no limite", "abcdefghij", 4, "abcd", "efghij"},
+		{"não divide emoji", "ab😀cd", 3, "ab", "😀cd"},
+		{"emoji no limite exato", "ab😀cd", 4, "ab😀", "cd"},
+		{"acentos contam como um", "ééééé", 3, "ééé", "éé"},
+		{"limite menor que o primeiro caractere avança ao menos um", "😀😀", 1, "😀", "😀"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, rest := cutAtLength(tt.text, tt.limit)
+			if head != tt.head || rest != tt.rest {
+				t.Errorf("cutAtLength(%q, %d) = (%q, %q), esperado (%q, %q)", tt.text, tt.limit, head, rest, tt.head, tt.rest)
+			}
+			if head+rest != tt.text {
+				t.Errorf("cutAtLength(%q, %d) perdeu texto", tt.text, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSplitBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"parágrafo único", "linha 1\nlinha 2", []string{"linha 1\nlinha 2"}},
+		{"parágrafos", "um\n\ndois\n", []string{"um\n\n", "dois\n"}},
+		{
+			"código separado do texto",
+			"antes\n```go\nx := 1\n\ny := 2\n```\ndepois",
+			[]string{"antes\n", "```go\nx := 1\n\ny := 2\n```\n", "depois"},
+		},
+		{
+			"linha em branco dentro do código não divide",
+			"```\na\n\nb\n```",
+			[]string{"```\na\n\nb\n```"},
+		},
+		{"cerca sem fechamento", "```py\nprint(1)\n", []string{"```py\nprint(1)\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitBlocks(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitBlocks(%q) = %q, esperado %q", tt.text, got, tt.want)
+			}
+			if strings.Join(got, "") != tt.text {
+				t.Errorf("splitBlocks(%q) não preservou o texto", tt.text)
+			}
+		})
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	code := "```go\n" + strings.Repeat("fmt.Println(\"linha de código\")\n", 40) + "```"
+	longParagraph := strings.Repeat("palavra ", 700)
+	emojis := strings.Repeat("😀", 3000)
+
+	tests := []struct {
+		name      string
+		text      string
+		limit     int
+		wantParts int // Zero não verifica a quantidade
+		check     func(t *testing.T, parts []string)
+	}{
+		{
+			name: "texto curto não é dividido", text: "Olá!\n\nTudo bem?", limit: 100, wantParts: 1,
+			check: func(t *testing.T, parts []string) {
+				if parts[0] != "Olá!\n\nTudo bem?" {
+					t.Errorf("parte alterada: %q", parts[0])
+				}
+			},
+		},
+		{
+			name: "divide entre parágrafos", text: "primeiro parágrafo\n\nsegundo parágrafo", limit: 25, wantParts: 2,
+			check: func(t *testing.T, parts []string) {
+				if parts[0] != "primeiro parágrafo" || parts[1] != "segundo parágrafo" {
+					t.Errorf("partes = %q", parts)
+				}
+			},
+		},
+		{
+			name: "bloco de código que cabe fica inteiro", text: "intro\n\n" + code + "\n\nfim", limit: textLength(code) + 2,
+			check: func(t *testing.T, parts []string) {
+				found := false
+				for _, part := range parts {
+					found = found || part == code
+				}
+				if !found {
+					t.Errorf("bloco de código dividido: %q", parts)
+				}
+			},
+		},
+		{
+			name: "bloco de código grande é fechado e reaberto", text: code, limit: 300,
+			check: func(t *testing.T, parts []string) {
+				if len(parts) < 2 {
+					t.Fatalf("esperava várias partes, veio %d", len(parts))
+				}
+				for i, part := range parts {
+					if !strings.HasPrefix(part, "```go\n") || !strings.HasSuffix(part, "```") {
+						t.Errorf("parte %d sem cercas: %q", i, part)
+					}
+				}
+			},
+		},
+		{name: "parágrafo maior que o limite", text: longParagraph, limit: 4096, wantParts: 2},
+		{name: "emojis no limite do Telegram", text: emojis, limit: 4096, wantParts: 2},
+		{name: "limite ímpar com emojis", text: emojis, limit: 101},
+		{name: "texto vazio", text: "\n\n", limit: 10, wantParts: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.text, tt.limit)
+			if tt.wantParts != 0 && len(parts) != tt.wantParts {
+				t.Errorf("splitMessage gerou %d partes, esperado %d", len(parts), tt.wantParts)
+			}
+
+			for i, part := range parts {
+				if n := textLength(part); n > tt.limit {
+					t.Errorf("parte %d tem %d caracteres, acima do limite %d", i, n, tt.limit)
+				}
+				if !utf8.ValidString(part) {
+					t.Errorf("parte %d dividiu um caractere multibyte", i)
+				}
+				if fences := strings.Count(part, "```"); fences%2 != 0 {
+					t.Errorf("parte %d com cerca de código desbalanceada: %q", i, part)
+				}
+			}
+
+			// Nenhum conteúdo se perde, descontando cercas repetidas e espaços
+			normalize := func(s string) string {
+				s = strings.ReplaceAll(s, "```go", "")
+				s = strings.ReplaceAll(s, "```", "")
+				return strings.Join(strings.Fields(s), "")
+			}
+			if got, want := normalize(strings.Join(parts, "")), normalize(tt.text); got != want {
+				t.Errorf("conteúdo alterado na divisão")
+			}
+
+			if tt.check != nil {
+				tt.check(t, parts)
+			}
+		})
+	}
+}
+
+func TestFullAnswerMessagesButtonOnLastPart(t *testing.T) {
+	s := &TelegramService{}
+	chat := &models.TelegramChat{ID: 10, Type: "private"}
+	msg := &models.TelegramMessage{MessageID: 5, Chat: chat}
+	keyboard := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "app", URL: "https://example.com"}}}}
+
+	answer := strings.Repeat("Um parágrafo de resposta bem comprido. ", 60) + "\n\n"
+	answer = strings.Repeat(answer, 5)
+
+	messages := s.fullAnswerMessages(msg, "pt-BR", "ana", answer, keyboard)
+	if len(messages) < 2 {
+		t.Fatalf("esperava várias mensagens, veio %d", len(messages))
+	}
+
+	for i, out := range messages {
+		last := i == len(messages)-1
+		if (out.payload.ReplyMarkup != nil) != last {
+			t.Errorf("mensagem %d: teclado presente = %v, esperado %v", i, out.payload.ReplyMarkup != nil, last)
+		}
+		if (out.payload.ReplyToMessageID != 0) != (i == 0) {
+			t.Errorf("mensagem %d: reply_to = %d", i, out.payload.ReplyToMessageID)
+		}
+		if n := textLength(out.plain); n > telegramMaxLength {
+			t.Errorf("mensagem %d tem %d caracteres", i, n)
+		}
+	}
+}
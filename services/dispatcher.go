@@ -0,0 +1,182 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Dispatcher limita as chamadas simultâneas aos provedores de IA e processa os
+// trabalhos de cada conversa estritamente na ordem de chegada
+type Dispatcher struct {
+	mu        sync.Mutex
+	slots     chan struct{} // Vagas globais para chamadas aos provedores
+	lanes     map[string]*dispatchLane
+	waiting   int // Trabalhos que já saíram da fila da conversa e aguardam uma vaga global
+	backoff   int // Trabalhos esperando para tentar de novo, sem ocupar vaga global
+	running   int
+	processed int64
+}
+
+// StepJob é um trabalho que pode pedir para ser executado de novo. Retorna zero
+// quando termina, ou o intervalo até a próxima execução
+type StepJob func() time.Duration
+
+// dispatchLane é a fila FIFO de uma conversa. Apenas uma goroutine consome cada fila
+type dispatchLane struct {
+	jobs []StepJob
+	busy bool
+}
+
+// QueueStats resume o estado das filas para monitoramento
+type QueueStats struct {
+	MaxConcurrent  int   `json:"max_concurrent"`
+	Running        int   `json:"running"`
+	WaitingForSlot int   `json:"waiting_for_slot"`
+	Backoff        int   `json:"backoff"`
+	Queued         int   `json:"queued"`
+	Conversations  int   `json:"conversations"`
+	LongestQueue   int   `json:"longest_queue"`
+	Processed      int64 `json:"processed"`
+}
+
+func NewDispatcher(maxConcurrent int) *Dispatcher {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	return &Dispatcher{
+		slots: make(chan struct{}, maxConcurrent),
+		lanes: make(map[string]*dispatchLane),
+	}
+}
+
+// Enqueue adiciona um trabalho ao fim da fila da conversa identificada por key.
+// Retorna a posição na fila (1 = próximo a ser atendido) ou 0 se o trabalho
+// será executado imediatamente
+func (d *Dispatcher) Enqueue(key string, job func()) int {
+	return d.EnqueueStep(key, func() time.Duration {
+		job()
+		return 0
+	})
+}
+
+// EnqueueStep adiciona à fila da conversa um trabalho que pode pedir novas
+// execuções. Enquanto espera pela próxima, o trabalho continua à frente da
+// fila da conversa, mas libera a vaga global para as outras conversas
+func (d *Dispatcher) EnqueueStep(key string, job StepJob) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lane, ok := d.lanes[key]
+	if !ok {
+		lane = &dispatchLane{}
+		d.lanes[key] = lane
+	}
+
+	var position int
+	switch {
+	case lane.busy:
+		// Conta o trabalho em andamento e os que já estão esperando na mesma conversa
+		position = len(lane.jobs) + 1
+	case len(d.slots) == cap(d.slots):
+		// A conversa está livre, mas todas as vagas globais estão ocupadas
+		position = d.waiting + 1
+	}
+
+	lane.jobs = append(lane.jobs, job)
+	if !lane.busy {
+		lane.busy = true
+		go d.drain(key, lane)
+	}
+
+	return position
+}
+
+// Stats retorna um retrato atual das filas
+func (d *Dispatcher) Stats() QueueStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := QueueStats{
+		MaxConcurrent:  cap(d.slots),
+		Running:        d.running,
+		WaitingForSlot: d.waiting,
+		Backoff:        d.backoff,
+		Conversations:  len(d.lanes),
+		Processed:      d.processed,
+	}
+	for _, lane := range d.lanes {
+		stats.Queued += len(lane.jobs)
+		if len(lane.jobs) > stats.LongestQueue {
+			stats.LongestQueue = len(lane.jobs)
+		}
+	}
+
+	return stats
+}
+
+// drain consome a fila de uma conversa até esvaziá-la. Um trabalho que pede
+// nova execução é repetido antes dos seguintes da mesma conversa
+func (d *Dispatcher) drain(key string, lane *dispatchLane) {
+	var retry StepJob
+	for {
+		d.mu.Lock()
+		job := retry
+		retry = nil
+		if job == nil {
+			if len(lane.jobs) == 0 {
+				lane.busy = false
+				delete(d.lanes, key)
+				d.mu.Unlock()
+				return
+			}
+			job = lane.jobs[0]
+			lane.jobs = lane.jobs[1:]
+		}
+		d.waiting++
+		d.mu.Unlock()
+
+		d.slots <- struct{}{}
+
+		d.mu.Lock()
+		d.waiting--
+		d.running++
+		d.mu.Unlock()
+
+		delay := d.run(job)
+
+		<-d.slots
+
+		d.mu.Lock()
+		d.running--
+		if delay <= 0 {
+			d.processed++
+			d.mu.Unlock()
+			continue
+		}
+		d.backoff++
+		d.mu.Unlock()
+
+		// A vaga global já foi liberada; só a conversa espera
+		time.Sleep(delay)
+
+		d.mu.Lock()
+		d.backoff--
+		d.mu.Unlock()
+		retry = job
+	}
+}
+
+// run executa o trabalho protegendo a fila contra panics. Um trabalho que
+// entrou em panic não é repetido
+func (d *Dispatcher) run(job StepJob) (delay time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recuperado de panic em trabalho da fila: %v", r)
+			delay = 0
+		}
+	}()
+
+	return job()
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherBackoffReleasesSlot(t *testing.T) {
+	d := NewDispatcher(1)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, event)
+	}
+	done := make(chan struct{}, 3)
+
+	// O primeiro job da conversa "a" falha uma vez e pede nova tentativa
+	attempts := 0
+	d.EnqueueStep("a", func() time.Duration {
+		attempts++
+		record("a1-tentativa")
+		if attempts == 1 {
+			return 200 * time.Millisecond
+		}
+		done <- struct{}{}
+		return 0
+	})
+	d.Enqueue("a", func() {
+		record("a2")
+		done <- struct{}{}
+	})
+	// A conversa "b" usa a única vaga enquanto "a" espera
+	time.Sleep(50 * time.Millisecond)
+	d.Enqueue("b", func() {
+		record("b")
+		done <- struct{}{}
+	})
+
+	for range 3 {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("trabalhos não concluídos: %v", order)
+		}
+	}
+
+	want := []string{"a1-tentativa", "b", "a1-tentativa", "a2"}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(order, want) {
+		t.Errorf("ordem = %v, esperado %v", order, want)
+	}
+
+	// O contador é atualizado logo depois que o último trabalho retorna
+	deadline := time.Now().Add(time.Second)
+	for stats := d.Stats(); stats.Running != 0 || stats.Backoff != 0 || stats.Processed != 3; stats = d.Stats() {
+		if time.Now().After(deadline) {
+			t.Fatalf("estatísticas ao final = %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDispatcherPanicIsNotRetried(t *testing.T) {
+	d := NewDispatcher(1)
+	runs := 0
+	done := make(chan struct{})
+
+	d.EnqueueStep("a", func() time.Duration {
+		runs++
+		panic("falha")
+	})
+	d.Enqueue("a", func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fila travada depois do panic")
+	}
+	if runs != 1 {
+		t.Errorf("job em panic executado %d vezes", runs)
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// handleEditedMessage refaz a resposta de uma pergunta editada pelo usuário.
+// Só perguntas já respondidas e que ainda são a última do chat são refeitas;
+// nas demais, o usuário é avisado de que a edição não muda a resposta. policy é
+// a política do grupo da mensagem (nil em chats privados)
+func (s *TelegramService) handleEditedMessage(msg *models.TelegramMessage, policy *models.GroupSettings) {
+	if msg.From == nil || msg.Chat == nil || msg.Text == "" || strings.HasPrefix(msg.Text, "/") {
+		return
+	}
+
+	turnID, err := s.db.GetQuestionTurn(msg.Chat.ID, msg.MessageID)
+	if err != nil {
+		log.Printf("Erro ao buscar pergunta editada %d do chat %d: %v", msg.MessageID, msg.Chat.ID, err)
+		return
+	}
+	if turnID == 0 {
+		return
+	}
+
+	question := s.extractQuestion(msg, policy)
+	if question == "" {
+		return
+	}
+
+	// Vira um job na fila da conversa, como as perguntas novas: não compete com
+	// perguntas em andamento e tem as mesmas novas tentativas e retomada
+	if _, err := s.enqueueJob(msg, question, s.conversationOwner(msg, policy), turnID); err != nil {
+		log.Printf("Erro ao registrar pergunta editada %d do chat %d: %v", msg.MessageID, msg.Chat.ID, err)
+		s.sendErrorMessage(msg)
+	}
+}
+
+// reanswerQuestion gera de novo a resposta de uma pergunta editada e atualiza
+// as mensagens da resposta anterior. Como em answerQuestion, faz uma única
+// tentativa: as novas tentativas ficam a cargo do job (runJob)
+func (s *TelegramService) reanswerQuestion(msg *models.TelegramMessage, question string, conversationID, turnID int64) error {
+	latest, err := s.db.IsLatestQuestion(turnID)
+	if err != nil {
+		return err
+	}
+	if !latest {
+		log.Printf("Pergunta %d do chat %d editada depois de novas perguntas, ignorando", msg.MessageID, msg.Chat.ID)
+		s.sendTextMessage(msg, i18n.T(s.userLocale(msg.From), "edit.not_latest"))
+		return nil
+	}
+
+	typingDone := make(chan struct{})
+	go s.keepTypingStatus(msg, typingDone)
+	s.sendChatAction(msg, "typing")
+
+	opts := s.askOptions(msg, conversationID)
+	opts.ReplaceTurnID = turnID
+	answer, hash, err := s.ai.Ask(conversationID, question, opts)
+	close(typingDone)
+	if err != nil {
+		return fmt.Errorf("erro ao obter resposta: %w", err)
+	}
+
+	s.editResponse(msg, answer, hash)
+	return nil
+}
+
+// editResponse substitui, no lugar, as mensagens que responderam à pergunta.
+// Se a nova resposta precisar de mais mensagens, as que faltam são enviadas;
+// se precisar de menos, as que sobram são apagadas
+func (s *TelegramService) editResponse(msg *models.TelegramMessage, answer, hash string) {
+	previous, err := s.db.GetAnswerMessages(msg.Chat.ID, msg.MessageID)
+	if err != nil {
+		log.Printf("Erro ao buscar respostas anteriores: %v", err)
+	}
+	if len(previous) == 0 {
+		s.sendResponseWithHash(msg, answer, hash)
+		return
+	}
+
+	messages := s.responseMessages(msg, answer, hash)
+	for i, out := range messages {
+		if i >= len(previous) {
+			sent, err := s.sendRendered(out.payload, out.plain)
+			if err != nil {
+				log.Printf("Erro ao enviar parte %d/%d da resposta: %v", i+1, len(messages), err)
+				s.sendErrorMessage(msg)
+				return
+			}
+			s.rememberAnswer(sent, hash, msg.MessageID)
+			continue
+		}
+
+		if err := s.editRendered(previous[i], out.payload, out.plain); err != nil {
+			log.Printf("Erro ao editar parte %d/%d da resposta: %v", i+1, len(messages), err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		s.rememberAnswer(&models.TelegramMessage{MessageID: previous[i], Chat: msg.Chat}, hash, msg.MessageID)
+	}
+
+	for _, messageID := range previous[min(len(messages), len(previous)):] {
+		s.deleteMessage(msg.Chat.ID, messageID)
+	}
+}
+
+// editRendered edita uma mensagem do bot com o conteúdo do payload em HTML,
+// com o mesmo fallback para texto simples de sendRendered
+func (s *TelegramService) editRendered(messageID int, payload SendMessageRequest, plain string) error {
+	request := map[string]interface{}{
+		"chat_id":    payload.ChatID,
+		"message_id": messageID,
+		"text":       payload.Text,
+		"parse_mode": "HTML",
+	}
+	if payload.ReplyMarkup != nil {
+		request["reply_markup"] = payload.ReplyMarkup
+	}
+
+	_, err := s.makeRequest("editMessageText", request)
+	if err == nil || isNotModified(err) {
+		return nil
+	}
+	if !isEntityParseError(err) {
+		return err
+	}
+
+	log.Printf("Telegram recusou a edição formatada, reenviando como texto simples: %v", err)
+	request["text"] = plain
+	delete(request, "parse_mode")
+	_, err = s.makeRequest("editMessageText", request)
+	if isNotModified(err) {
+		return nil
+	}
+	return err
+}
+
+// isNotModified indica o erro do Telegram para edições sem nenhuma mudança
+func isNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message is not modified")
+}
+
+// deleteMessage apaga uma mensagem do bot e o seu registro
+func (s *TelegramService) deleteMessage(chatID int64, messageID int) {
+	_, err := s.makeRequest("deleteMessage", map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	})
+	if err != nil {
+		log.Printf("Erro ao apagar mensagem %d do chat %d: %v", messageID, chatID, err)
+	}
+	if err := s.db.DeleteTelegramMessage(chatID, messageID); err != nil {
+		log.Printf("Erro ao remover registro da mensagem %d: %v", messageID, err)
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"regexp"
+	"time"
+
+	"bot-ai/config"
+	"bot-ai/database"
+	"bot-ai/models"
+)
+
+// FakeAIService é um provedor de IA local para desenvolvimento e testes. Não faz
+// chamadas externas, mas grava as conversas pelo mesmo caminho dos provedores reais
+type FakeAIService struct {
+	config *config.Config
+	db     *database.Database
+	script *fakeScript
+}
+
+// fakeScript é o formato do arquivo indicado em FAKE_AI_SCRIPT:
+//
+//	{
+//	  "rules": [
+//	    {"match": "(?i)^(oi|olá)", "response": "Olá! Como posso ajudar?"},
+//	    {"match": "(?i)clima em (\\w+)", "response": "Faz sol em $1."},
+//	    {"match": "(?i)lento", "response": "Demorei!", "latency_ms": 5000},
+//	    {"match": "(?i)quebre", "error": "falha simulada"}
+//	  ],
+//	  "default": "Não tenho uma resposta roteirizada para isso."
+//	}
+//
+// As regras são avaliadas em ordem e a primeira que corresponder é usada. As
+// respostas aceitam referências aos grupos da expressão ($1, ${nome}). Sem
+// regra correspondente e sem "default", a pergunta é ecoada
+type fakeScript struct {
+	Rules   []fakeRule `json:"rules"`
+	Default string     `json:"default"`
+}
+
+type fakeRule struct {
+	Match     string `json:"match"`
+	Response  string `json:"response"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int    `json:"latency_ms,omitempty"`
+
+	re *regexp.Regexp
+}
+
+func NewFakeAIService(cfg *config.Config, db *database.Database) models.AIService {
+	service := &FakeAIService{
+		config: cfg,
+		db:     db,
+	}
+
+	if cfg.FakeAIMode == "script" {
+		script, err := loadFakeScript(cfg.FakeAIScript)
+		if err != nil {
+			panic(fmt.Sprintf("Erro ao carregar roteiro do serviço fake: %v", err))
+		}
+		service.script = script
+	}
+
+	return service
+}
+
+// loadFakeScript lê e compila as regras do roteiro
+func loadFakeScript(path string) (*fakeScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler roteiro %s: %w", path, err)
+	}
+
+	var script fakeScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar roteiro %s: %w", path, err)
+	}
+
+	for i := range script.Rules {
+		re, err := regexp.Compile(script.Rules[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("expressão inválida na regra %d (%q): %w", i+1, script.Rules[i].Match, err)
+		}
+		script.Rules[i].re = re
+	}
+
+	return &script, nil
+}
+
+func (s *FakeAIService) AskWithRetry(userID int64, question string, opts models.AskOptions) (string, string, error) {
+	var lastErr error
+	for attempt := 1; attempt <= s.config.MaxRetries; attempt++ {
+		answer, hash, err := s.Ask(userID, question, opts)
+		if err == nil {
+			return answer, hash, nil
+		}
+
+		lastErr = err
+		if attempt < s.config.MaxRetries {
+			time.Sleep(s.config.RetryDelay)
+		}
+	}
+	return "", "", fmt.Errorf("todas as tentativas falharam: %v", lastErr)
+}
+
+func (s *FakeAIService) Ask(userID int64, question string, opts models.AskOptions) (string, string, error) {
+	// Busca o chat ativo do usuário e o histórico de mensagens
+	chat, messages, err := loadConversation(s.db, userID, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	answer, err := s.respond(question)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Salva a pergunta e a resposta no histórico
+	hash, err := saveExchange(s, s.config, s.db, chat, messages, question, answer, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	return answer, hash, nil
+}
+
+// Complete responde a um prompt isolado usando as mesmas regras de Ask
+func (s *FakeAIService) Complete(prompt string) (string, error) {
+	return s.respond(prompt)
+}
+
+func (s *FakeAIService) NewChat(userID int64, topic models.Topic) error {
+	if err := s.db.NewChat(userID, topic); err != nil {
+		return fmt.Errorf("erro ao criar novo chat: %w", err)
+	}
+	return nil
+}
+
+// respond aplica a latência e as falhas configuradas e gera a resposta conforme o modo
+func (s *FakeAIService) respond(question string) (string, error) {
+	latency := s.config.FakeAILatency
+	answer := "echo: " + question
+	var ruleErr string
+
+	if s.script != nil {
+		answer = s.script.Default
+		if answer == "" {
+			answer = "echo: " + question
+		}
+
+		for _, rule := range s.script.Rules {
+			match := rule.re.FindStringSubmatchIndex(question)
+			if match == nil {
+				continue
+			}
+
+			answer = string(rule.re.ExpandString(nil, rule.Response, question, match))
+			ruleErr = rule.Error
+			if rule.LatencyMS > 0 {
+				latency = time.Duration(rule.LatencyMS) * time.Millisecond
+			}
+			break
+		}
+	}
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if ruleErr != "" {
+		return "", fmt.Errorf("erro simulado pelo roteiro: %s", ruleErr)
+	}
+
+	if s.config.FakeAIFailureRate > 0 && rand.Float64() < s.config.FakeAIFailureRate {
+		log.Printf("Serviço fake: injetando falha (taxa=%.2f)", s.config.FakeAIFailureRate)
+		return "", fmt.Errorf("falha simulada pelo serviço fake")
+	}
+
+	return answer, nil
+}
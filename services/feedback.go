@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"bot-ai/config"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// maxFeedbackComment limita, em caracteres, o comentário de uma avaliação
+const maxFeedbackComment = 1000
+
+// answerSource descreve o provedor, o modelo e a persona que geram uma
+// resposta agora, para ser gravado junto com ela
+func answerSource(cfg *config.Config, prefs *models.UserSettings) models.AnswerSource {
+	persona := personas[0]
+	if prefs != nil && prefs.Persona != "" {
+		persona = prefs.Persona
+	}
+	return models.AnswerSource{Provider: cfg.AIService, Model: providerModel(cfg), Persona: persona}
+}
+
+// trimComment limpa o comentário e o corta em maxFeedbackComment caracteres
+func trimComment(comment string) string {
+	comment = strings.TrimSpace(comment)
+	if runes := []rune(comment); len(runes) > maxFeedbackComment {
+		comment = string(runes[:maxFeedbackComment])
+	}
+	return comment
+}
+
+// feedbackRow monta os botões de avaliação de uma resposta
+func (s *TelegramService) feedbackRow(hash string) ([]InlineKeyboardButton, error) {
+	up, err := s.callbacks.Button("👍", "fb_rate", hash+"=up")
+	if err != nil {
+		return nil, err
+	}
+	down, err := s.callbacks.Button("👎", "fb_rate", hash+"=down")
+	if err != nil {
+		return nil, err
+	}
+	return []InlineKeyboardButton{up, down}, nil
+}
+
+// handleFeedbackRate registra a avaliação da resposta. Na primeira avaliação
+// do usuário, o bot pede também um comentário opcional
+func (s *TelegramService) handleFeedbackRate(ctx *CallbackContext) (*CallbackResponse, error) {
+	hash, value, _ := strings.Cut(ctx.Payload, "=")
+	var rating int
+	switch value {
+	case "up":
+		rating = models.RatingUp
+	case "down":
+		rating = models.RatingDown
+	default:
+		return nil, errInvalidCallback
+	}
+
+	userID := ctx.Query.From.ID
+	previous, err := s.db.GetFeedback(hash, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.SaveFeedback(&models.AnswerFeedback{Hash: hash, UserID: userID, Rating: rating}); err != nil {
+		return nil, err
+	}
+
+	// Trocar de avaliação não repete o pedido de comentário
+	if previous == nil && ctx.Query.Message != nil && ctx.Query.Message.Chat != nil {
+		go s.askFeedbackComment(ctx.Query, hash, ctx.Locale)
+	}
+
+	return &CallbackResponse{Text: i18n.T(ctx.Locale, "feedback.thanks")}, nil
+}
+
+// askFeedbackComment responde à mensagem avaliada pedindo um comentário. A
+// resposta do usuário a esse pedido é salva por handleFeedbackComment
+func (s *TelegramService) askFeedbackComment(cb *CallbackQuery, hash, locale string) {
+	msg := cb.Message
+	mention := fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, cb.From.ID, html.EscapeString(cb.From.FirstName))
+
+	payload := map[string]interface{}{
+		"chat_id":             msg.Chat.ID,
+		"text":                i18n.T(locale, "feedback.comment_prompt", mention),
+		"parse_mode":          "HTML",
+		"reply_to_message_id": msg.MessageID,
+		// Em grupos, o campo de resposta abre só para quem foi mencionado
+		"reply_markup": map[string]interface{}{
+			"force_reply":             true,
+			"selective":               true,
+			"input_field_placeholder": i18n.T(locale, "feedback.comment_placeholder"),
+		},
+	}
+	if threadID := messageTopic(msg).ThreadID; threadID != 0 {
+		payload["message_thread_id"] = threadID
+	}
+
+	resp, err := s.makeRequest("sendMessage", payload)
+	if err != nil {
+		log.Printf("Erro ao pedir comentário da avaliação: %v", err)
+		return
+	}
+
+	var sent models.TelegramMessage
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		log.Printf("Erro ao ler pedido de comentário enviado: %v", err)
+		return
+	}
+	if err := s.db.SetFeedbackPrompt(hash, cb.From.ID, msg.Chat.ID, sent.MessageID); err != nil {
+		log.Printf("Erro ao registrar pedido de comentário: %v", err)
+	}
+}
+
+// handleFeedbackComment salva como comentário da avaliação a resposta do
+// usuário ao pedido de comentário. Retorna false se a mensagem não for uma
+// dessas respostas, para que siga o fluxo normal
+func (s *TelegramService) handleFeedbackComment(msg *models.TelegramMessage) bool {
+	reply := msg.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.ID != s.botInfo.ID || strings.HasPrefix(msg.Text, "/") {
+		return false
+	}
+
+	feedback, err := s.db.FeedbackForPrompt(msg.Chat.ID, reply.MessageID)
+	if err != nil {
+		log.Printf("Erro ao buscar pedido de comentário: %v", err)
+		return false
+	}
+	if feedback == nil || feedback.UserID != msg.From.ID {
+		return false
+	}
+
+	comment := trimComment(msg.Text)
+	if comment == "" {
+		return true
+	}
+
+	go func() {
+		if err := s.db.SetFeedbackComment(feedback.Hash, feedback.UserID, comment); err != nil {
+			log.Printf("Erro ao salvar comentário da avaliação: %v", err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		s.sendTextMessage(msg, i18n.T(s.userLocale(msg.From), "feedback.comment_saved"))
+	}()
+	return true
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TelegramFile descreve um arquivo pronto para download, retornado por getFile
+type TelegramFile struct {
+	FileID   string `json:"file_id"`
+	FileSize int64  `json:"file_size,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// getFile obtém o caminho de download de um arquivo enviado ao bot
+func (s *TelegramService) getFile(fileID string) (*TelegramFile, error) {
+	resp, err := s.makeRequest("getFile", map[string]interface{}{"file_id": fileID})
+	if err != nil {
+		return nil, err
+	}
+
+	var file TelegramFile
+	if err := json.Unmarshal(resp.Result, &file); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo %s: %w", fileID, err)
+	}
+	if file.FilePath == "" {
+		return nil, fmt.Errorf("arquivo %s indisponível para download", fileID)
+	}
+
+	return &file, nil
+}
+
+// openFile abre o conteúdo de um arquivo enviado ao bot. Com o servidor da Bot
+// API em modo local, o caminho retornado por getFile é lido direto do disco;
+// caso contrário, o arquivo é baixado do mesmo servidor usado pela API
+func (s *TelegramService) openFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	file, err := s.getFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.TelegramAPILocal && filepath.IsAbs(file.FilePath) {
+		f, err := os.Open(file.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir arquivo local %s: %w", file.FilePath, err)
+		}
+		return f, nil
+	}
+
+	url := fmt.Sprintf("%s/file/bot%s/%s", s.config.TelegramAPIURL, s.token, strings.TrimPrefix(file.FilePath, "/"))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao baixar arquivo %s: %w", fileID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("erro ao baixar arquivo %s: %s", fileID, resp.Status)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFile(t *testing.T) {
+	onDisk := filepath.Join(t.TempDir(), "file_1.txt")
+	if err := os.WriteFile(onDisk, []byte("do disco"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		local string
+		path  string
+		want  string
+	}{
+		{"servidor oficial", "false", "documents/file_1.txt", "do servidor"},
+		{"modo local lê o disco", "true", onDisk, "do disco"},
+		{"modo local com caminho relativo", "true", "documents/file_1.txt", "do servidor"},
+		{"caminho absoluto sem modo local", "false", onDisk, "do servidor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, bot, _ := startTestBot(t, map[string]string{"TELEGRAM_API_LOCAL": tt.local})
+			srv.AddFile("doc1", tt.path, []byte("do servidor"))
+
+			file, err := bot.openFile(context.Background(), "doc1")
+			if err != nil {
+				t.Fatalf("openFile: %v", err)
+			}
+			defer file.Close()
+
+			content, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("conteúdo = %q, esperado %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenFileUnknown(t *testing.T) {
+	_, bot, _ := startTestBot(t, nil)
+
+	if _, err := bot.openFile(context.Background(), "inexistente"); err == nil {
+		t.Error("openFile aceitou um file_id desconhecido")
+	}
+}
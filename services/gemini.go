@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/generative-ai-go/genai"
@@ -14,38 +15,46 @@ import (
 )
 
 type GeminiService struct {
-	client *genai.Client
-	config *config.Config
-	model  *genai.GenerativeModel
-	db     *database.Database
+	clients []*genai.Client          // Um cliente por chave de API, na mesma ordem do pool
+	models  []*genai.GenerativeModel // Modelo configurado de cada cliente
+	keys    *KeyPool
+	config  *config.Config
+	db      *database.Database
 }
 
 func NewGeminiService(cfg *config.Config, db *database.Database) models.AIService {
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.GeminiApiKey))
-	if err != nil {
-		panic(fmt.Sprintf("Erro ao criar cliente Gemini: %v", err))
-	}
-
-	model := client.GenerativeModel(cfg.GeminiModel)
-	model.SetTemperature(float32(cfg.GeminiTemperature))
-	model.SetTopK(int32(cfg.GeminiTopK))
-	model.SetTopP(float32(cfg.GeminiTopP))
-	model.SetMaxOutputTokens(int32(cfg.GeminiMaxOutputTokens))
-	model.ResponseMIMEType = "text/plain"
 
-	return &GeminiService{
-		client: client,
+	service := &GeminiService{
+		keys:   NewKeyPool("gemini", cfg.GeminiApiKeys, cfg.KeyPoolStrategy, cfg.KeyCooldown),
 		config: cfg,
-		model:  model,
 		db:     db,
 	}
+
+	for _, apiKey := range cfg.GeminiApiKeys {
+		client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+		if err != nil {
+			panic(fmt.Sprintf("Erro ao criar cliente Gemini: %v", err))
+		}
+
+		model := client.GenerativeModel(cfg.GeminiModel)
+		model.SetTemperature(float32(cfg.GeminiTemperature))
+		model.SetTopK(int32(cfg.GeminiTopK))
+		model.SetTopP(float32(cfg.GeminiTopP))
+		model.SetMaxOutputTokens(int32(cfg.GeminiMaxOutputTokens))
+		model.ResponseMIMEType = "text/plain"
+
+		service.clients = append(service.clients, client)
+		service.models = append(service.models, model)
+	}
+
+	return service
 }
 
-func (s *GeminiService) AskWithRetry(userID int64, question string) (string, string, error) {
+func (s *GeminiService) AskWithRetry(userID int64, question string, opts models.AskOptions) (string, string, error) {
 	var lastErr error
 	for attempt := 1; attempt <= s.config.MaxRetries; attempt++ {
-		answer, hash, err := s.Ask(userID, question)
+		answer, hash, err := s.Ask(userID, question, opts)
 		if err == nil {
 			return answer, hash, nil
 		}
@@ -58,31 +67,29 @@ func (s *GeminiService) AskWithRetry(userID int64, question string) (string, str
 	return "", "", fmt.Errorf("todas as tentativas falharam: %v", lastErr)
 }
 
-func (s *GeminiService) Ask(userID int64, question string) (string, string, error) {
+func (s *GeminiService) Ask(userID int64, question string, opts models.AskOptions) (string, string, error) {
 	ctx := context.Background()
 
-	// Busca o chat ativo do usuário
-	chat, err := s.db.GetActiveChat(userID)
+	// Busca o chat ativo do usuário e o histórico de mensagens
+	chat, messages, err := loadConversation(s.db, userID, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao buscar chat ativo: %w", err)
+		return "", "", err
 	}
 
-	// Se não houver nenhum chat, cria um novo
-	if chat == nil {
-		chat, err = s.db.CreateNewChat(userID)
-		if err != nil {
-			return "", "", fmt.Errorf("erro ao criar novo chat: %w", err)
-		}
-	}
-
-	// Recupera o histórico de mensagens
-	messages, err := s.db.GetChatMessages(chat.ID)
+	// Escolhe a chave de API da vez no pool
+	keyIndex, _, err := s.keys.Acquire()
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao recuperar histórico: %w", err)
+		return "", "", err
 	}
 
-	// Prepara o histórico para o Gemini
-	cs := s.model.StartChat()
+	// Prepara o histórico para o Gemini, usando uma cópia do modelo com o prompt de persona
+	// no idioma do usuário e a temperatura das preferências dele
+	model := *s.models[keyIndex]
+	model.SetTemperature(float32(requestTemperature(opts.Preferences, s.config.GeminiTemperature)))
+	model.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{genai.Text(personaPrompt(opts.Locale, opts.Preferences))},
+	}
+	cs := model.StartChat()
 	for _, msg := range messages {
 		// Mapeia os roles do nosso sistema para os roles aceitos pelo Gemini
 		role := "user"
@@ -92,39 +99,78 @@ func (s *GeminiService) Ask(userID int64, question string) (string, string, erro
 
 		cs.History = append(cs.History, &genai.Content{
 			Role:  role,
-			Parts: []genai.Part{genai.Text(msg.Content)},
+			Parts: []genai.Part{genai.Text(turnContent(msg))},
 		})
 	}
 
-	// Adiciona a nova pergunta ao histórico
-	if err := s.db.AddMessageToChat(chat.ID, "user", question); err != nil {
-		return "", "", fmt.Errorf("erro ao adicionar pergunta ao histórico: %w", err)
-	}
-
 	// Envia a pergunta para o Gemini
-	resp, err := cs.SendMessage(ctx, genai.Text(question))
+	resp, err := cs.SendMessage(ctx, genai.Text(questionContent(question, opts)))
 	if err != nil {
+		s.keys.ReportFailure(keyIndex, err)
 		return "", "", fmt.Errorf("erro ao obter resposta: %w", err)
 	}
+	s.keys.ReportSuccess(keyIndex)
+
+	answer, err := responseText(resp)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Salva a pergunta e a resposta no histórico
+	hash, err := saveExchange(s, s.config, s.db, chat, messages, question, answer, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	return answer, hash, nil
+}
 
-	answer := resp.Candidates[0].Content.Parts[0].(genai.Text)
+// Complete envia um prompt isolado ao Gemini, sem histórico e sem salvar no banco
+func (s *GeminiService) Complete(prompt string) (string, error) {
+	ctx := context.Background()
 
-	// Salva a resposta no banco
-	hash, err := s.db.SaveMessage(string(answer))
+	keyIndex, _, err := s.keys.Acquire()
 	if err != nil {
-		return "", "", fmt.Errorf("erro ao salvar resposta: %w", err)
+		return "", err
 	}
 
-	// Adiciona a resposta ao histórico do chat
-	if err := s.db.AddMessageToChatWithExistingHash(chat.ID, "assistant", string(answer), hash); err != nil {
-		return "", "", fmt.Errorf("erro ao adicionar resposta ao histórico: %w", err)
+	resp, err := s.models[keyIndex].GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		s.keys.ReportFailure(keyIndex, err)
+		return "", fmt.Errorf("erro ao obter resposta: %w", err)
 	}
+	s.keys.ReportSuccess(keyIndex)
+
+	return responseText(resp)
+}
+
+// responseText extrai o texto do primeiro candidato da resposta do Gemini
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", fmt.Errorf("resposta vazia do Gemini")
+	}
+
+	var sb strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			sb.WriteString(string(text))
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("resposta do Gemini sem texto")
+	}
+
+	return sb.String(), nil
+}
 
-	return string(answer), hash, nil
+// KeyHealth retorna o estado das chaves de API do Gemini
+func (s *GeminiService) KeyHealth() []models.KeyHealth {
+	return s.keys.Health()
 }
 
-func (s *GeminiService) NewChat(userID int64) error {
-	if err := s.db.NewChat(userID); err != nil {
+func (s *GeminiService) NewChat(userID int64, topic models.Topic) error {
+	if err := s.db.NewChat(userID, topic); err != nil {
 		return fmt.Errorf("erro ao criar novo chat: %w", err)
 	}
 	return nil
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// Modos de conversa em grupos
+const (
+	GroupConversationUser   = "user"   // Cada participante tem o próprio histórico
+	GroupConversationShared = "shared" // O histórico pertence ao grupo e registra o autor de cada pergunta
+)
+
+// Gatilhos que fazem o bot responder em grupos
+const (
+	TriggerMention = "mention" // Menção a @bot na mensagem
+	TriggerReply   = "reply"   // Resposta a uma mensagem do bot
+	TriggerPrefix  = "prefix"  // Mensagem iniciada pelo prefixo configurado (ex: "!ai")
+	TriggerKeyword = "keyword" // Mensagem contendo uma das palavras-chave
+)
+
+var groupTriggers = []string{TriggerMention, TriggerReply, TriggerPrefix, TriggerKeyword}
+
+// groupPolicy retorna a política do grupo, completando com o padrão do bot o que não foi configurado
+func (s *TelegramService) groupPolicy(chatID int64) *models.GroupSettings {
+	settings, err := s.db.GetGroupSettings(chatID)
+	if err != nil {
+		log.Printf("Erro ao buscar configurações do grupo %d: %v", chatID, err)
+		settings = &models.GroupSettings{ChatID: chatID}
+	}
+
+	if settings.ConversationMode == "" {
+		settings.ConversationMode = s.config.GroupConversationMode
+	}
+	if settings.ConversationMode != GroupConversationShared {
+		settings.ConversationMode = GroupConversationUser
+	}
+	if settings.Triggers == nil {
+		settings.Triggers = s.config.GroupTriggers
+	}
+	if settings.CommandPrefix == "" {
+		settings.CommandPrefix = s.config.GroupCommandPrefix
+	}
+	if settings.Keywords == nil {
+		settings.Keywords = s.config.GroupKeywords
+	}
+
+	return settings
+}
+
+// messagePolicy retorna a política do grupo da mensagem, ou nil em chats
+// privados. É carregada uma vez por atualização e repassada a quem a usa
+func (s *TelegramService) messagePolicy(msg *models.TelegramMessage) *models.GroupSettings {
+	if msg == nil || msg.Chat == nil || msg.Chat.Type == "private" {
+		return nil
+	}
+	return s.groupPolicy(msg.Chat.ID)
+}
+
+// mentionPattern reconhece a menção ao bot como palavra inteira, sem
+// diferenciar maiúsculas de minúsculas: "@MeuBotFalso" não menciona "@MeuBot"
+func mentionPattern(userName string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)@` + regexp.QuoteMeta(userName) + `\b`)
+}
+
+// hasCommandPrefix indica se o texto, ignorando espaços iniciais, começa com o prefixo
+func hasCommandPrefix(text, prefix string) bool {
+	text = strings.TrimSpace(text)
+	return prefix != "" && len(text) >= len(prefix) && strings.EqualFold(text[:len(prefix)], prefix)
+}
+
+func hasTrigger(settings *models.GroupSettings, trigger string) bool {
+	for _, t := range settings.Triggers {
+		if t == trigger {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesGroupTrigger verifica se a mensagem do grupo aciona algum dos gatilhos
+// configurados em settings
+func (s *TelegramService) matchesGroupTrigger(msg *models.TelegramMessage, settings *models.GroupSettings) bool {
+	text := strings.ToLower(msg.Text)
+
+	if hasTrigger(settings, TriggerMention) && s.mention.MatchString(text) {
+		return true
+	}
+
+	if hasTrigger(settings, TriggerReply) && msg.ReplyToMessage != nil &&
+		msg.ReplyToMessage.From != nil && msg.ReplyToMessage.From.ID == s.botInfo.ID {
+		return true
+	}
+
+	if hasTrigger(settings, TriggerPrefix) && hasCommandPrefix(text, settings.CommandPrefix) {
+		return true
+	}
+
+	if hasTrigger(settings, TriggerKeyword) {
+		for _, keyword := range settings.Keywords {
+			if containsKeyword(text, strings.ToLower(keyword)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isKeywordRune indica se o caractere faz parte de uma palavra ao procurar palavras-chave
+func isKeywordRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// containsKeyword verifica se text contém keyword como palavras inteiras. A
+// palavra-chave pode ter várias palavras, como "bom dia"; ambos já chegam em
+// minúsculas
+func containsKeyword(text, keyword string) bool {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return false
+	}
+
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], keyword)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(keyword)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !isKeywordRune(before)) && (end == len(text) || !isKeywordRune(after)) {
+			return true
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return false
+}
+
+// stripGroupTrigger remove da pergunta a menção ao bot e o prefixo de comando
+// de settings, sem diferenciar maiúsculas de minúsculas
+func (s *TelegramService) stripGroupTrigger(question string, settings *models.GroupSettings) string {
+	question = s.mention.ReplaceAllString(question, "")
+
+	if hasCommandPrefix(question, settings.CommandPrefix) {
+		question = strings.TrimSpace(question)[len(settings.CommandPrefix):]
+	}
+
+	return question
+}
+
+// conversationOwner retorna o dono do histórico da mensagem: o grupo, nas
+// conversas compartilhadas, ou o próprio usuário. policy é a política do grupo
+// (nil em chats privados)
+func (s *TelegramService) conversationOwner(msg *models.TelegramMessage, policy *models.GroupSettings) int64 {
+	if policy != nil && policy.ConversationMode == GroupConversationShared {
+		return msg.Chat.ID
+	}
+	return msg.From.ID
+}
+
+// askOptions monta as opções da pergunta. O autor só é registrado quando o
+// histórico pertence ao grupo
+func (s *TelegramService) askOptions(msg *models.TelegramMessage, conversationID int64) models.AskOptions {
+	opts := models.AskOptions{Locale: s.userLocale(msg.From), Topic: messageTopic(msg)}
+	if settings, err := s.db.GetUserSettings(msg.From.ID); err != nil {
+		log.Printf("Erro ao buscar preferências do usuário %d: %v", msg.From.ID, err)
+	} else {
+		opts.Preferences = settings
+	}
+	if conversationID != msg.From.ID {
+		opts.AuthorID = msg.From.ID
+		opts.AuthorName = strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+		if opts.AuthorName == "" {
+			opts.AuthorName = msg.From.UserName
+		}
+	}
+	return opts
+}
+
+// handleGroupCommand mostra ou altera a política do grupo. Apenas
+// administradores do grupo (ou do bot) podem alterá-la
+func (s *TelegramService) handleGroupCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	settings := s.groupPolicy(msg.Chat.ID)
+
+	option, value, _ := strings.Cut(strings.TrimSpace(args), " ")
+	value = strings.TrimSpace(value)
+	if option == "" {
+		s.sendTextMessage(msg, s.describeGroupPolicy(locale, settings))
+		return
+	}
+
+	if !s.isAdmin(msg.From.ID) && !s.isChatAdmin(msg.Chat.ID, msg.From.ID) {
+		s.sendTextMessage(msg, i18n.T(locale, "group.not_admin"))
+		return
+	}
+
+	switch strings.ToLower(option) {
+	case "mode":
+		value = strings.ToLower(value)
+		if value != GroupConversationUser && value != GroupConversationShared {
+			s.sendTextMessage(msg, i18n.T(locale, "group.invalid_mode", value))
+			return
+		}
+		settings.ConversationMode = value
+
+	case "triggers":
+		triggers := []string{}
+		for _, trigger := range strings.Split(strings.ToLower(value), ",") {
+			trigger = strings.TrimSpace(trigger)
+			if trigger == "" {
+				continue
+			}
+			valid := false
+			for _, known := range groupTriggers {
+				valid = valid || known == trigger
+			}
+			if !valid {
+				s.sendTextMessage(msg, i18n.T(locale, "group.invalid_trigger", trigger, strings.Join(groupTriggers, ", ")))
+				return
+			}
+			triggers = append(triggers, trigger)
+		}
+		settings.Triggers = triggers
+
+	case "prefix":
+		if value == "" || strings.HasPrefix(value, "/") {
+			s.sendTextMessage(msg, i18n.T(locale, "group.usage"))
+			return
+		}
+		settings.CommandPrefix = value
+
+	case "keywords":
+		keywords := []string{}
+		for _, keyword := range strings.Split(value, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords = append(keywords, keyword)
+			}
+		}
+		settings.Keywords = keywords
+
+	default:
+		s.sendTextMessage(msg, i18n.T(locale, "group.usage"))
+		return
+	}
+
+	if err := s.db.SaveGroupSettings(settings); err != nil {
+		log.Printf("Erro ao salvar configurações do grupo %d: %v", msg.Chat.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(locale, "group.updated")+"\n\n"+s.describeGroupPolicy(locale, settings))
+}
+
+func (s *TelegramService) describeGroupPolicy(locale string, settings *models.GroupSettings) string {
+	none := i18n.T(locale, "group.none")
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return none
+		}
+		return strings.Join(items, ", ")
+	}
+
+	prefix := settings.CommandPrefix
+	if prefix == "" {
+		prefix = none
+	}
+
+	return i18n.T(locale, "group.settings",
+		i18n.T(locale, "group.mode."+settings.ConversationMode),
+		list(settings.Triggers), prefix, list(settings.Keywords),
+	) + "\n\n" + i18n.T(locale, "group.usage")
+}
+
+// isChatAdmin consulta no Telegram se o usuário administra o chat
+func (s *TelegramService) isChatAdmin(chatID, userID int64) bool {
+	resp, err := s.makeRequest("getChatMember", map[string]interface{}{
+		"chat_id": chatID,
+		"user_id": userID,
+	})
+	if err != nil {
+		log.Printf("Erro ao consultar membro %d do chat %d: %v", userID, chatID, err)
+		return false
+	}
+
+	var member struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(resp.Result, &member); err != nil {
+		log.Printf("Erro ao ler membro %d do chat %d: %v", userID, chatID, err)
+		return false
+	}
+
+	return member.Status == "creator" || member.Status == "administrator"
+}
+
+// migrateChat acompanha a conversão de um grupo em supergrupo, que muda o ID do chat
+func (s *TelegramService) migrateChat(oldID, newID int64) {
+	if err := s.db.MigrateChat(oldID, newID); err != nil {
+		log.Printf("Erro ao migrar o grupo %d para o supergrupo %d: %v", oldID, newID, err)
+		return
+	}
+	log.Printf("Grupo %d migrado para o supergrupo %d", oldID, newID)
+}
+
+// conversationKey identifica a fila de processamento de uma conversa. Tópicos
+// de fórum têm filas próprias e são respondidos em paralelo
+func conversationKey(conversationID int64, topic models.Topic) string {
+	if topic.ThreadID != 0 {
+		return fmt.Sprintf("conversation:%d:topic:%d:%d", conversationID, topic.ChatID, topic.ThreadID)
+	}
+	return fmt.Sprintf("conversation:%d", conversationID)
+}
+
+// messageTopic retorna o tópico de fórum da mensagem. Mensagens fora de tópicos
+// (ou no tópico "General") retornam o valor zero
+func messageTopic(msg *models.TelegramMessage) models.Topic {
+	if !msg.IsTopicMessage || msg.MessageThreadID == 0 || msg.Chat == nil {
+		return models.Topic{}
+	}
+	return models.Topic{ChatID: msg.Chat.ID, ThreadID: msg.MessageThreadID}
+}
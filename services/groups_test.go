@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"bot-ai/models"
+)
+
+func TestContainsKeyword(t *testing.T) {
+	tests := []struct {
+		text, keyword string
+		want          bool
+	}{
+		{"alguém sabe sobre go?", "go", true},
+		{"vamos de golang", "go", false},
+		{"bom dia, pessoal", "bom dia", true},
+		{"bom  dia", "bom dia", false},
+		{"um bom dia!", "bom dia", true},
+		{"bom diazinho", "bom dia", false},
+		{"abom dia", "bom dia", false},
+		{"ajuda com o bot-ai", "bot-ai", true},
+		{"ação rápida", "ação", true},
+		{"reação", "ação", false},
+		{"go go", "go", true},
+		{"gogo go", "go", true}, // A primeira ocorrência falha, a última casa
+		{"qualquer", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := containsKeyword(tt.text, tt.keyword); got != tt.want {
+			t.Errorf("containsKeyword(%q, %q) = %v, esperado %v", tt.text, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestStripGroupTrigger(t *testing.T) {
+	s := &TelegramService{mention: mentionPattern("MeuBot")}
+	settings := &models.GroupSettings{CommandPrefix: "!ai"}
+
+	tests := []struct {
+		question, want string
+	}{
+		{"@MeuBot qual a capital?", " qual a capital?"},
+		{"@meubot qual a capital?", " qual a capital?"},
+		{"oi @MEUBOT, tudo bem?", "oi , tudo bem?"},
+		{"@MeuBotFalso oi", "@MeuBotFalso oi"},
+		{"!ai quanto é 2+2", " quanto é 2+2"},
+		{"!AI quanto é 2+2", " quanto é 2+2"},
+		{"  !Ai teste", " teste"},
+		{"!a", "!a"},
+		{"pergunta normal", "pergunta normal"},
+	}
+
+	for _, tt := range tests {
+		if got := s.stripGroupTrigger(tt.question, settings); got != tt.want {
+			t.Errorf("stripGroupTrigger(%q) = %q, esperado %q", tt.question, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesGroupTrigger(t *testing.T) {
+	s := &TelegramService{
+		botInfo: &models.TelegramUser{ID: 1, UserName: "MeuBot"},
+		mention: mentionPattern("MeuBot"),
+	}
+	settings := &models.GroupSettings{
+		Triggers:      []string{TriggerMention, TriggerReply, TriggerPrefix, TriggerKeyword},
+		CommandPrefix: "!ai",
+		Keywords:      []string{"bom dia"},
+	}
+	fromBot := &models.TelegramMessage{From: &models.TelegramUser{ID: 1}}
+
+	tests := []struct {
+		text    string
+		replyTo *models.TelegramMessage
+		want    bool
+	}{
+		{"@MeuBot qual a capital?", nil, true},
+		{"oi @meubot, tudo bem?", nil, true},
+		{"@MeuBotFalso oi", nil, false},
+		{"!ai quanto é 2+2", nil, true},
+		{"  !Ai teste", nil, true},
+		{"!a", nil, false},
+		{"bom dia, pessoal", nil, true},
+		{"obrigado", fromBot, true},
+		{"pergunta normal", nil, false},
+	}
+
+	for _, tt := range tests {
+		msg := &models.TelegramMessage{Text: tt.text, ReplyToMessage: tt.replyTo, Chat: &models.TelegramChat{ID: -100, Type: "group"}}
+		if got := s.matchesGroupTrigger(msg, settings); got != tt.want {
+			t.Errorf("matchesGroupTrigger(%q) = %v, esperado %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -19,24 +19,37 @@ import (
 type HTTPServer struct {
 	config         *config.Config
 	db             *database.Database
+	ai             models.AIService
+	dispatcher     *Dispatcher
 	authMiddleware *TelegramAuthMiddleware
+
+	// webhook recebe as atualizações do Telegram no modo webhook (nil no modo polling)
+	webhook http.HandlerFunc
 }
 
-func NewHTTPServer(cfg *config.Config, db *database.Database) *HTTPServer {
+func NewHTTPServer(cfg *config.Config, db *database.Database, ai models.AIService, dispatcher *Dispatcher) *HTTPServer {
 	return &HTTPServer{
 		config:         cfg,
 		db:             db,
+		ai:             ai,
+		dispatcher:     dispatcher,
 		authMiddleware: NewTelegramAuthMiddleware(cfg.TelegramToken),
 	}
 }
 
+// SetWebhookHandler registra o handler das atualizações do Telegram em
+// TELEGRAM_WEBHOOK_PATH. Deve ser chamado antes de Start
+func (s *HTTPServer) SetWebhookHandler(handler http.HandlerFunc) {
+	s.webhook = handler
+}
+
 // Middleware CORS para lidar com requisições cross-origin
 func (s *HTTPServer) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Configura cabeçalhos CORS
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Telegram-Init-Data")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Telegram-Init-Data, Authorization")
 		w.Header().Set("Access-Control-Max-Age", "86400") // 24 horas
 
 		// Trata requisições OPTIONS (preflight)
@@ -54,8 +67,25 @@ func (s *HTTPServer) Start() {
 	http.HandleFunc("/api/messages/", s.corsMiddleware(s.handleGetMessage))
 	http.HandleFunc("/api/messages", s.corsMiddleware(s.handleMessages))
 	http.HandleFunc("/api/chat/new", s.corsMiddleware(s.handleNewChat))
-	http.HandleFunc("/api/chat/", s.corsMiddleware(s.handleGetChatMessages))
+	http.HandleFunc("/api/chat/", s.corsMiddleware(s.handleChat))
 	http.HandleFunc("/api/chats", s.corsMiddleware(s.handleGetChats))
+	http.HandleFunc("/api/feedback/", s.corsMiddleware(s.handleFeedback))
+
+	// Rotas administrativas
+	http.HandleFunc("/api/admin/keys", s.corsMiddleware(s.adminMiddleware(s.handleAdminKeys)))
+	http.HandleFunc("/api/admin/queue", s.corsMiddleware(s.adminMiddleware(s.handleAdminQueue)))
+	http.HandleFunc("/api/admin/jobs", s.corsMiddleware(s.adminMiddleware(s.handleAdminJobs)))
+	http.HandleFunc("/api/admin/inline", s.corsMiddleware(s.adminMiddleware(s.handleAdminInline)))
+	http.HandleFunc("/api/admin/access", s.corsMiddleware(s.adminMiddleware(s.handleAdminAccess)))
+	http.HandleFunc("/api/admin/invites", s.corsMiddleware(s.adminMiddleware(s.handleAdminInvites)))
+	http.HandleFunc("/api/admin/audit", s.corsMiddleware(s.adminMiddleware(s.handleAdminAudit)))
+	http.HandleFunc("/api/admin/broadcasts", s.corsMiddleware(s.adminMiddleware(s.handleAdminBroadcasts)))
+	http.HandleFunc("/api/admin/feedback", s.corsMiddleware(s.adminMiddleware(s.handleAdminFeedback)))
+
+	// Webhook do Telegram
+	if s.webhook != nil {
+		http.HandleFunc(s.config.TelegramWebhookPath, s.webhook)
+	}
 
 	// Frontend static files handler
 	http.HandleFunc("/", s.corsMiddleware(s.handleFrontend))
@@ -117,15 +147,85 @@ func (s *HTTPServer) handleGetMessage(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(msg)
 }
 
-func extractUserID(initData string) (int64, error) {
-	// Decodifica o initData URL encoded
-	data, err := url.QueryUnescape(initData)
+// handleFeedback mostra (GET) ou registra (POST {"rating", "comment"}) a
+// avaliação do usuário do Mini App para a resposta em /api/feedback/{hash}
+func (s *HTTPServer) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hash := strings.TrimPrefix(r.URL.Path, "/api/feedback/")
+	if hash == "" {
+		http.Error(w, "Hash da resposta não fornecido", http.StatusBadRequest)
+		return
+	}
+
+	// Valida autenticação do Telegram
+	initData := r.Header.Get("X-Telegram-Init-Data")
+	if initData == "" {
+		http.Error(w, "Unauthorized: Missing init data", http.StatusUnauthorized)
+		return
+	}
+
+	if !s.authMiddleware.ValidateInitData(initData) {
+		http.Error(w, "Unauthorized: Invalid init data", http.StatusUnauthorized)
+		return
+	}
+
+	// Extrai o user_id do initData
+	userID, err := extractUserID(initData)
+	if err != nil {
+		log.Printf("Erro ao extrair user_id: %v", err)
+		http.Error(w, "Erro ao identificar usuário", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var body struct {
+			Rating  int     `json:"rating"`
+			Comment *string `json:"comment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil ||
+			(body.Rating != models.RatingUp && body.Rating != models.RatingDown) {
+			http.Error(w, "Corpo da requisição inválido: rating deve ser 1 ou -1", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := s.db.GetMessage(hash); err != nil {
+			http.Error(w, "Mensagem não encontrada", http.StatusNotFound)
+			return
+		}
+
+		if err := s.db.SaveFeedback(&models.AnswerFeedback{Hash: hash, UserID: userID, Rating: body.Rating}); err != nil {
+			log.Printf("Erro ao salvar avaliação da resposta %s: %v", hash, err)
+			http.Error(w, "Erro ao salvar avaliação", http.StatusInternalServerError)
+			return
+		}
+		if body.Comment != nil {
+			if err := s.db.SetFeedbackComment(hash, userID, trimComment(*body.Comment)); err != nil {
+				log.Printf("Erro ao salvar comentário da resposta %s: %v", hash, err)
+				http.Error(w, "Erro ao salvar comentário", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	feedback, err := s.db.GetFeedback(hash, userID)
 	if err != nil {
-		return 0, fmt.Errorf("erro ao decodificar initData: %w", err)
+		log.Printf("Erro ao buscar avaliação da resposta %s: %v", hash, err)
+		http.Error(w, "Erro ao buscar avaliação", http.StatusInternalServerError)
+		return
 	}
 
-	// Parse os parâmetros
-	params, err := url.ParseQuery(data)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feedback)
+}
+
+func extractUserID(initData string) (int64, error) {
+	// Parse os parâmetros. ParseQuery já decodifica os valores; decodificar antes
+	// quebraria nomes com "&" ou "="
+	params, err := url.ParseQuery(initData)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao parsear initData: %w", err)
 	}
@@ -220,7 +320,7 @@ func (s *HTTPServer) handleNewChat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Cria novo chat para o usuário
-	if err := s.db.NewChat(userID); err != nil {
+	if err := s.db.NewChat(userID, models.Topic{}); err != nil {
 		log.Printf("Erro ao criar novo chat para usuário %d: %v", userID, err)
 		http.Error(w, "Erro ao criar novo chat", http.StatusInternalServerError)
 		return
@@ -232,6 +332,18 @@ func (s *HTTPServer) handleNewChat(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleChat direciona as requisições de /api/chat/{id} conforme o método
+func (s *HTTPServer) handleChat(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetChatMessages(w, r)
+	case http.MethodPatch:
+		s.handleUpdateChat(w, r)
+	default:
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
 func (s *HTTPServer) handleGetChatMessages(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
@@ -324,3 +436,73 @@ func (s *HTTPServer) handleGetChats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(chats)
 }
+
+// handleUpdateChat permite que o usuário edite o título de um dos seus chats
+func (s *HTTPServer) handleUpdateChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chatID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/chat/"), 10, 64)
+	if err != nil {
+		http.Error(w, "ID do chat inválido", http.StatusBadRequest)
+		return
+	}
+
+	// Valida autenticação do Telegram
+	initData := r.Header.Get("X-Telegram-Init-Data")
+	if initData == "" {
+		http.Error(w, "Unauthorized: Missing init data", http.StatusUnauthorized)
+		return
+	}
+
+	if !s.authMiddleware.ValidateInitData(initData) {
+		http.Error(w, "Unauthorized: Invalid init data", http.StatusUnauthorized)
+		return
+	}
+
+	// Extrai o user_id do initData
+	userID, err := extractUserID(initData)
+	if err != nil {
+		log.Printf("Erro ao extrair user_id: %v", err)
+		http.Error(w, "Erro ao identificar usuário", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Title *string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Title == nil {
+		http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+		return
+	}
+
+	// Garante que o chat pertence ao usuário autenticado
+	chat, err := s.db.GetChat(chatID)
+	if err != nil {
+		log.Printf("Erro ao buscar chat %d: %v", chatID, err)
+		http.Error(w, "Erro ao buscar chat", http.StatusInternalServerError)
+		return
+	}
+	if chat == nil || chat.UserID != userID {
+		http.Error(w, "Chat não encontrado", http.StatusNotFound)
+		return
+	}
+
+	if err := s.db.SetChatTitle(chatID, strings.TrimSpace(*body.Title)); err != nil {
+		log.Printf("Erro ao atualizar título do chat %d: %v", chatID, err)
+		http.Error(w, "Erro ao atualizar chat", http.StatusInternalServerError)
+		return
+	}
+
+	chat, err = s.db.GetChat(chatID)
+	if err != nil {
+		log.Printf("Erro ao buscar chat %d: %v", chatID, err)
+		http.Error(w, "Erro ao buscar chat", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chat)
+}
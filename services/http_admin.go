@@ -0,0 +1,430 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-ai/models"
+)
+
+// adminMiddleware protege as rotas /api/admin com o token configurado em ADMIN_API_TOKEN
+func (s *HTTPServer) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AdminAPIToken == "" {
+			http.Error(w, "API administrativa desabilitada", http.StatusNotFound)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminAPIToken)) != 1 {
+			http.Error(w, "Unauthorized: Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleAdminKeys expõe a saúde das chaves de API do provedor ativo
+func (s *HTTPServer) handleAdminKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys := []models.KeyHealth{}
+	if reporter, ok := s.ai.(models.KeyHealthReporter); ok {
+		keys = reporter.KeyHealth()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"service": s.config.AIService,
+		"keys":    keys,
+	})
+}
+
+// handleAdminQueue expõe as métricas da fila de perguntas
+func (s *HTTPServer) handleAdminQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.dispatcher.Stats())
+}
+
+// handleAdminJobs lista os jobs de perguntas. Sem parâmetros, retorna os jobs
+// travados: não concluídos e sem atualização há mais de stuck_after segundos
+// (padrão: 300). Com ?status=dead (ou outro status), lista os jobs nesse estado
+func (s *HTTPServer) handleAdminJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var jobs []models.Job
+	var err error
+	if status := r.URL.Query().Get("status"); status != "" {
+		jobs, err = s.db.ListJobs([]string{status}, time.Time{})
+	} else {
+		stuckAfter := 300
+		if value := r.URL.Query().Get("stuck_after"); value != "" {
+			stuckAfter, err = strconv.Atoi(value)
+			if err != nil || stuckAfter < 0 {
+				http.Error(w, "Parâmetro stuck_after inválido", http.StatusBadRequest)
+				return
+			}
+		}
+		statuses := []string{models.JobPending, models.JobRunning, models.JobFailed}
+		jobs, err = s.db.ListJobs(statuses, time.Now().Add(-time.Duration(stuckAfter)*time.Second))
+	}
+	if err != nil {
+		http.Error(w, "Erro ao listar jobs", http.StatusInternalServerError)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// handleAdminInline resume o uso do modo inline nos últimos ?days dias (padrão: 7)
+func (s *HTTPServer) handleAdminInline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 7
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 {
+			http.Error(w, "Parâmetro days inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	usage, err := s.db.GetInlineUsage(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		http.Error(w, "Erro ao buscar uso do modo inline", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// handleAdminAccess expõe a política de acesso (GET) e aplica uma alteração (POST).
+// O corpo do POST segue AccessChange, ex: {"action": "ban", "id": 123, "note": "spam"}
+func (s *HTTPServer) handleAdminAccess(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		lists := map[string][]models.AccessEntry{}
+		for _, kind := range []string{models.AccessUser, models.AccessGroup, models.AccessBanned} {
+			entries, err := s.db.ListAccessEntries(kind)
+			if err != nil {
+				http.Error(w, "Erro ao listar acesso", http.StatusInternalServerError)
+				return
+			}
+			lists[kind] = entries
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"mode":   currentAccessMode(s.db, s.config),
+			"users":  lists[models.AccessUser],
+			"groups": lists[models.AccessGroup],
+			"banned": lists[models.AccessBanned],
+		})
+
+	case http.MethodPost:
+		var change AccessChange
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+
+		changed, err := applyAccessChange(s.db, change, 0, AuditAPI)
+		if errors.Is(err, errInvalidAccessMode) || errors.Is(err, errInvalidAccessAction) || errors.Is(err, errInvalidAccessTarget) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Erro ao alterar acesso", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"changed": changed})
+
+	default:
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminInvites lista (GET), cria (POST {"max_uses": n}) e revoga
+// (DELETE ?code=) convites. max_uses zero cria um convite ilimitado
+func (s *HTTPServer) handleAdminInvites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		invites, err := s.db.ListInvites()
+		if err != nil {
+			http.Error(w, "Erro ao listar convites", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invites)
+
+	case http.MethodPost:
+		request := struct {
+			MaxUses *int `json:"max_uses"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		maxUses := 1
+		if request.MaxUses != nil {
+			maxUses = *request.MaxUses
+		}
+		if maxUses < 0 {
+			http.Error(w, "Parâmetro max_uses inválido", http.StatusBadRequest)
+			return
+		}
+
+		code, err := createInvite(s.db, maxUses, 0, AuditAPI)
+		if err != nil {
+			http.Error(w, "Erro ao criar convite", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":     code,
+			"max_uses": maxUses,
+			"start":    "inv_" + code,
+		})
+
+	case http.MethodDelete:
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "Parâmetro code obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		revoked, err := revokeInvite(s.db, code, 0, AuditAPI)
+		if err != nil {
+			http.Error(w, "Erro ao revogar convite", http.StatusInternalServerError)
+			return
+		}
+		if !revoked {
+			http.Error(w, "Convite não encontrado", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminAudit lista as ?limit entradas mais recentes do log de auditoria de acesso (padrão: 100)
+func (s *HTTPServer) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Parâmetro limit inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := s.db.ListAudit(limit)
+	if err != nil {
+		http.Error(w, "Erro ao listar auditoria", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleAdminBroadcasts lista os broadcasts (GET), mostra as entregas de um
+// deles (GET ?id=, com ?status= opcional), cria e libera um broadcast para
+// todos os usuários (POST {"text", "button_text", "button_url"}) e cancela
+// um broadcast em andamento (DELETE ?id=)
+func (s *HTTPServer) handleAdminBroadcasts(w http.ResponseWriter, r *http.Request) {
+	var id int64
+	if value := r.URL.Query().Get("id"); value != "" {
+		var err error
+		id, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Parâmetro id inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if id == 0 {
+			broadcasts, err := s.db.ListBroadcasts(50)
+			if err != nil {
+				http.Error(w, "Erro ao listar broadcasts", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(broadcasts)
+			return
+		}
+
+		broadcast, err := s.db.GetBroadcast(id)
+		if err != nil {
+			http.Error(w, "Erro ao buscar broadcast", http.StatusInternalServerError)
+			return
+		}
+		if broadcast == nil {
+			http.Error(w, "Broadcast não encontrado", http.StatusNotFound)
+			return
+		}
+
+		deliveries, err := s.db.ListDeliveries(id, r.URL.Query().Get("status"))
+		if err != nil {
+			http.Error(w, "Erro ao listar entregas", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"broadcast":  broadcast,
+			"deliveries": deliveries,
+		})
+
+	case http.MethodPost:
+		var broadcast models.Broadcast
+		if err := json.NewDecoder(r.Body).Decode(&broadcast); err != nil {
+			http.Error(w, "Corpo da requisição inválido", http.StatusBadRequest)
+			return
+		}
+		broadcast.Text = strings.TrimSpace(broadcast.Text)
+		if broadcast.Text == "" {
+			http.Error(w, "Texto do broadcast obrigatório", http.StatusBadRequest)
+			return
+		}
+		if (broadcast.ButtonText == "") != (broadcast.ButtonURL == "") ||
+			(broadcast.ButtonURL != "" && !broadcastButtonPattern.MatchString("["+broadcast.ButtonText+"]("+broadcast.ButtonURL+")")) {
+			http.Error(w, "Botão inválido: informe button_text e uma button_url http(s)", http.StatusBadRequest)
+			return
+		}
+		broadcast.CreatedBy = 0
+
+		id, err := s.db.CreateBroadcast(&broadcast)
+		if err == nil {
+			_, err = s.db.QueueBroadcast(id)
+		}
+		if err != nil {
+			http.Error(w, "Erro ao criar broadcast", http.StatusInternalServerError)
+			return
+		}
+
+		created, err := s.db.GetBroadcast(id)
+		if err != nil || created == nil {
+			http.Error(w, "Erro ao buscar broadcast", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+
+	case http.MethodDelete:
+		if id == 0 {
+			http.Error(w, "Parâmetro id obrigatório", http.StatusBadRequest)
+			return
+		}
+
+		cancelled, err := s.db.CancelBroadcast(id)
+		if err != nil {
+			http.Error(w, "Erro ao cancelar broadcast", http.StatusInternalServerError)
+			return
+		}
+		if !cancelled {
+			http.Error(w, "Broadcast não encontrado ou já concluído", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminFeedback resume a satisfação com as respostas, no geral, por
+// modelo e por persona, e lista as respostas mais mal avaliadas para revisão
+// (GET, com ?limit= opcional para a lista)
+func (s *HTTPServer) handleAdminFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 20
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			http.Error(w, "Parâmetro limit inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	overall, err := s.db.FeedbackOverall()
+	if err != nil {
+		http.Error(w, "Erro ao agregar avaliações", http.StatusInternalServerError)
+		return
+	}
+	byModel, err := s.db.FeedbackByModel()
+	if err != nil {
+		http.Error(w, "Erro ao agregar avaliações", http.StatusInternalServerError)
+		return
+	}
+	byPersona, err := s.db.FeedbackByPersona()
+	if err != nil {
+		http.Error(w, "Erro ao agregar avaliações", http.StatusInternalServerError)
+		return
+	}
+	worst, err := s.db.WorstRatedAnswers(limit)
+	if err != nil {
+		http.Error(w, "Erro ao listar respostas mal avaliadas", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"overall":    overall,
+		"by_model":   byModel,
+		"by_persona": byPersona,
+		"worst":      worst,
+	})
+}
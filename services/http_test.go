@@ -0,0 +1,89 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-ai/config"
+	"bot-ai/database"
+	"bot-ai/models"
+)
+
+// newTestHTTPServer cria o servidor HTTP com um banco em memória
+func newTestHTTPServer(t *testing.T) (*HTTPServer, *database.Database) {
+	t.Helper()
+	db, err := database.NewDatabase(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewHTTPServer(&config.Config{TelegramToken: testBotToken}, db, nil, nil), db
+}
+
+func TestUpdateChatRequiresSignedInitData(t *testing.T) {
+	s, db := newTestHTTPServer(t)
+	chat, err := db.CreateNewChat(42, models.Topic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/chat/" + strconv.FormatInt(chat.ID, 10)
+
+	rename := func(initData, title string) int {
+		req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(`{"title":"`+title+`"}`))
+		req.Header.Set("X-Telegram-Init-Data", initData)
+		rec := httptest.NewRecorder()
+		s.handleUpdateChat(rec, req)
+		return rec.Code
+	}
+
+	forged := "user=" + `%7B%22id%22%3A42%7D` + "&auth_date=" + strconv.FormatInt(time.Now().Unix(), 10) + "&hash=abc"
+	if code := rename(forged, "forjado"); code != http.StatusUnauthorized {
+		t.Errorf("initData forjado: status %d", code)
+	}
+	if code := rename(signInitData(testBotToken, `{"id":43}`, time.Now()), "alheio"); code != http.StatusNotFound {
+		t.Errorf("chat de outro usuário: status %d", code)
+	}
+	if code := rename(signInitData(testBotToken, `{"id":42}`, time.Now()), "meu"); code != http.StatusOK {
+		t.Errorf("dono do chat: status %d", code)
+	}
+
+	if chat, err := db.GetChat(chat.ID); err != nil || chat.Title != "meu" {
+		t.Errorf("título = %+v, erro %v", chat, err)
+	}
+}
+
+func TestFeedbackRequiresSignedInitData(t *testing.T) {
+	s, db := newTestHTTPServer(t)
+	hash, err := db.SaveAnswer("resposta", models.AnswerSource{Provider: "fake", Model: "echo", Persona: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rate := func(initData string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/feedback/"+hash, strings.NewReader(`{"rating":-1,"comment":"ruim"}`))
+		req.Header.Set("X-Telegram-Init-Data", initData)
+		rec := httptest.NewRecorder()
+		s.handleFeedback(rec, req)
+		return rec.Code
+	}
+
+	forged := "user=" + `%7B%22id%22%3A42%7D` + "&auth_date=" + strconv.FormatInt(time.Now().Unix(), 10) + "&hash=abc"
+	if code := rate(forged); code != http.StatusUnauthorized {
+		t.Errorf("initData forjado: status %d", code)
+	}
+	if feedback, err := db.GetFeedback(hash, 42); err != nil || feedback != nil {
+		t.Fatalf("avaliação forjada gravada: %+v, erro %v", feedback, err)
+	}
+
+	if code := rate(signInitData(testBotToken, `{"id":42}`, time.Now())); code != http.StatusOK {
+		t.Errorf("initData válido: status %d", code)
+	}
+	feedback, err := db.GetFeedback(hash, 42)
+	if err != nil || feedback == nil || feedback.Rating != models.RatingDown || feedback.Comment != "ruim" {
+		t.Errorf("avaliação = %+v, erro %v", feedback, err)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-ai/database"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// InlineQuery é uma consulta feita com @bot em qualquer chat
+type InlineQuery struct {
+	ID    string               `json:"id"`
+	From  *models.TelegramUser `json:"from"`
+	Query string               `json:"query"`
+}
+
+// ChosenInlineResult informa qual resultado inline o usuário enviou no chat.
+// Só é recebido com o feedback inline ativado no BotFather (/setinlinefeedback)
+type ChosenInlineResult struct {
+	ResultID string               `json:"result_id"`
+	From     *models.TelegramUser `json:"from"`
+	Query    string               `json:"query"`
+}
+
+type InlineQueryResultArticle struct {
+	Type                string                  `json:"type"`
+	ID                  string                  `json:"id"`
+	Title               string                  `json:"title"`
+	Description         string                  `json:"description,omitempty"`
+	InputMessageContent InputTextMessageContent `json:"input_message_content"`
+	ReplyMarkup         *InlineKeyboardMarkup   `json:"reply_markup,omitempty"`
+}
+
+type InputTextMessageContent struct {
+	MessageText string `json:"message_text"`
+}
+
+// inlineDeadline é o prazo para responder a uma consulta inline, contado desde a
+// sua chegada. O Telegram só aceita a resposta por cerca de 10 segundos
+const inlineDeadline = 8 * time.Second
+
+// inlineState controla o debounce das consultas de cada usuário e o cache de
+// respostas por consulta, evitando uma chamada à IA a cada tecla digitada
+type inlineState struct {
+	mu      sync.Mutex
+	pending map[int64]*time.Timer
+	cache   map[string]inlineAnswer
+}
+
+type inlineAnswer struct {
+	answer    string
+	hash      string
+	expiresAt time.Time
+}
+
+func newInlineState() *inlineState {
+	return &inlineState{
+		pending: make(map[int64]*time.Timer),
+		cache:   make(map[string]inlineAnswer),
+	}
+}
+
+// debounce agenda fn para depois de delay, cancelando a consulta anterior
+// ainda não executada do mesmo usuário
+func (c *inlineState) debounce(userID int64, delay time.Duration, fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timer, ok := c.pending[userID]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		c.mu.Lock()
+		if c.pending[userID] != timer {
+			c.mu.Unlock()
+			return
+		}
+		delete(c.pending, userID)
+		c.mu.Unlock()
+
+		fn()
+	})
+	c.pending[userID] = timer
+}
+
+func (c *inlineState) get(key string) (inlineAnswer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return inlineAnswer{}, false
+	}
+	return entry, true
+}
+
+func (c *inlineState) put(key string, entry inlineAnswer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Aproveita a escrita para descartar as respostas expiradas
+	now := time.Now()
+	for k, cached := range c.cache {
+		if now.After(cached.expiresAt) {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = entry
+}
+
+// inlineCacheKey normaliza a consulta para que variações de caixa e espaços usem o mesmo cache
+func inlineCacheKey(locale, query string) string {
+	return locale + ":" + strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// handleInlineQuery responde imediatamente a partir do cache ou agenda a
+// consulta à IA para quando o usuário parar de digitar
+func (s *TelegramService) handleInlineQuery(q *InlineQuery) {
+	query := strings.TrimSpace(q.Query)
+	if query == "" || q.From == nil {
+		return
+	}
+
+	locale := s.userLocale(q.From)
+	key := inlineCacheKey(locale, query)
+	if entry, ok := s.inline.get(key); ok {
+		go s.answerInlineQuery(q, locale, query, entry)
+		return
+	}
+
+	received := time.Now()
+	s.inline.debounce(q.From.ID, s.config.InlineDebounce, func() {
+		done := make(chan *inlineAnswer, 1)
+
+		// Usa a fila para respeitar o limite global de chamadas à IA
+		s.dispatcher.Enqueue(fmt.Sprintf("inline:%d", q.From.ID), func() {
+			done <- s.resolveInlineQuery(locale, query, key)
+		})
+
+		// Se a fila ou o provedor demorarem, responde a tempo com um atalho para o
+		// bot. A resposta continua sendo gerada e fica no cache para a próxima consulta igual
+		select {
+		case entry := <-done:
+			if entry != nil {
+				s.answerInlineQuery(q, locale, query, *entry)
+				return
+			}
+		case <-time.After(time.Until(received.Add(inlineDeadline))):
+		}
+		s.answerInlinePending(q, locale, query)
+	})
+}
+
+// resolveInlineQuery gera a resposta da consulta com a IA e a guarda no cache.
+// Retorna nil se a IA falhar
+func (s *TelegramService) resolveInlineQuery(locale, query, key string) *inlineAnswer {
+	// Outra consulta igual pode ter sido respondida enquanto esta aguardava na fila
+	if entry, ok := s.inline.get(key); ok {
+		return &entry
+	}
+
+	answer, err := s.ai.Complete(personaPrompt(locale, nil) + "\n\n" + query)
+	if err != nil {
+		log.Printf("Erro ao responder consulta inline: %v", err)
+		return nil
+	}
+
+	// Salva a resposta para que o link "ver resposta completa" funcione
+	hash, err := s.db.SaveAnswer(answer, answerSource(s.config, nil))
+	if err != nil {
+		log.Printf("Erro ao salvar resposta inline: %v", err)
+		return nil
+	}
+
+	entry := inlineAnswer{answer: answer, hash: hash, expiresAt: time.Now().Add(s.config.InlineCacheTTL)}
+	s.inline.put(key, entry)
+	return &entry
+}
+
+// answerInlinePending responde à consulta que não ficou pronta no prazo com um
+// resultado que leva a pergunta para o chat com o bot. Sem cache no Telegram,
+// para que a mesma consulta mostre a resposta assim que ela estiver pronta
+func (s *TelegramService) answerInlinePending(q *InlineQuery, locale, query string) {
+	result := InlineQueryResultArticle{
+		Type:        "article",
+		ID:          "pending",
+		Title:       i18n.T(locale, "inline.pending_title"),
+		Description: i18n.T(locale, "inline.pending_description"),
+		InputMessageContent: InputTextMessageContent{
+			MessageText: i18n.T(locale, "inline.pending_message", query),
+		},
+		ReplyMarkup: &InlineKeyboardMarkup{
+			InlineKeyboard: [][]InlineKeyboardButton{
+				{{Text: i18n.T(locale, "inline.open_bot"), URL: fmt.Sprintf("https://t.me/%s", s.botInfo.UserName)}},
+			},
+		},
+	}
+
+	payload := map[string]interface{}{
+		"inline_query_id": q.ID,
+		"results":         []InlineQueryResultArticle{result},
+		"cache_time":      0,
+	}
+
+	if _, err := s.makeRequest("answerInlineQuery", payload); err != nil {
+		log.Printf("Erro ao responder consulta inline pendente: %v", err)
+	}
+}
+
+// answerInlineQuery envia a resposta como um artigo com prévia e link para a resposta completa
+func (s *TelegramService) answerInlineQuery(q *InlineQuery, locale, query string, entry inlineAnswer) {
+	preview := s.formatPreview(entry.answer, 200)
+	link := fmt.Sprintf("https://t.me/%s?start=msg_%s", s.botInfo.UserName, entry.hash)
+
+	result := InlineQueryResultArticle{
+		Type:        "article",
+		ID:          entry.hash,
+		Title:       i18n.T(locale, "inline.title"),
+		Description: database.TruncateText(entry.answer, 100),
+		InputMessageContent: InputTextMessageContent{
+			MessageText: i18n.T(locale, "inline.message", query, preview),
+		},
+		ReplyMarkup: &InlineKeyboardMarkup{
+			InlineKeyboard: [][]InlineKeyboardButton{
+				{{Text: i18n.T(locale, "button.full_answer"), URL: link}},
+			},
+		},
+	}
+
+	payload := map[string]interface{}{
+		"inline_query_id": q.ID,
+		"results":         []InlineQueryResultArticle{result},
+		"cache_time":      int(s.config.InlineCacheTTL.Seconds()),
+	}
+
+	if _, err := s.makeRequest("answerInlineQuery", payload); err != nil {
+		log.Printf("Erro ao responder consulta inline: %v", err)
+	}
+}
+
+// handleChosenInlineResult registra o resultado enviado pelo usuário para acompanhar o uso do modo inline
+func (s *TelegramService) handleChosenInlineResult(result *ChosenInlineResult) {
+	if result.From == nil {
+		return
+	}
+
+	if err := s.db.RecordInlineResult(result.From.ID, result.ResultID, result.Query); err != nil {
+		log.Printf("Erro ao registrar resultado inline: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"bot-ai/database"
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// enqueueQuestion registra a pergunta como job antes de qualquer chamada ao
+// provedor e a coloca na fila da conversa. Retorna a posição na fila. policy é
+// a política do grupo da mensagem (nil em chats privados)
+func (s *TelegramService) enqueueQuestion(msg *models.TelegramMessage, question string, policy *models.GroupSettings) (int, error) {
+	return s.enqueueJob(msg, question, s.conversationOwner(msg, policy), 0)
+}
+
+// enqueueJob registra e enfileira o job da pergunta. replaceTurnID identifica
+// o turno a refazer quando a pergunta foi editada (zero em perguntas novas)
+func (s *TelegramService) enqueueJob(msg *models.TelegramMessage, question string, conversationID, replaceTurnID int64) (int, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao serializar mensagem: %w", err)
+	}
+
+	job := models.Job{
+		UserID:         msg.From.ID,
+		ChatID:         msg.Chat.ID,
+		ConversationID: conversationID,
+		ThreadID:       messageTopic(msg).ThreadID,
+		ReplaceTurnID:  replaceTurnID,
+		Message:        string(data),
+		Question:       question,
+		Status:         models.JobPending,
+	}
+	job.ID, err = s.db.CreateJob(&job)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.submitJob(job), nil
+}
+
+// submitJob coloca um job já registrado na fila da sua conversa
+func (s *TelegramService) submitJob(job models.Job) int {
+	topic := models.Topic{ChatID: job.ChatID, ThreadID: job.ThreadID}
+	return s.dispatcher.EnqueueStep(conversationKey(job.ConversationID, topic), func() time.Duration {
+		return s.runJob(&job)
+	})
+}
+
+// runJob faz uma tentativa do job, com uma única chamada ao provedor. Em caso
+// de falha, retorna o intervalo até a próxima tentativa: o dispatcher mantém o
+// job à frente da fila da conversa, para que as perguntas seguintes esperem por
+// ele, mas libera a vaga global durante a espera. Ao esgotar JOB_MAX_ATTEMPTS,
+// o job vai para o estado dead e o usuário é avisado
+func (s *TelegramService) runJob(job *models.Job) time.Duration {
+	var msg models.TelegramMessage
+	if err := json.Unmarshal([]byte(job.Message), &msg); err != nil {
+		log.Printf("Job %d com mensagem inválida, descartando: %v", job.ID, err)
+		s.db.MarkJobFailed(job.ID, models.JobDead, err.Error())
+		return 0
+	}
+
+	if err := s.db.MarkJobRunning(job.ID); err != nil {
+		log.Printf("Erro ao iniciar job %d: %v", job.ID, err)
+		return 0
+	}
+	job.Attempts++
+
+	var err error
+	if job.ReplaceTurnID != 0 {
+		err = s.reanswerQuestion(&msg, job.Question, job.ConversationID, job.ReplaceTurnID)
+	} else {
+		err = s.answerQuestion(&msg, job.Question, job.ConversationID)
+	}
+	if err == nil {
+		if err := s.db.MarkJobDone(job.ID); err != nil {
+			log.Printf("Erro ao concluir job %d: %v", job.ID, err)
+		}
+		return 0
+	}
+
+	log.Printf("Erro ao processar job %d (tentativa %d/%d): %v", job.ID, job.Attempts, s.config.JobMaxAttempts, err)
+
+	if job.Attempts >= s.config.JobMaxAttempts {
+		if err := s.db.MarkJobFailed(job.ID, models.JobDead, err.Error()); err != nil {
+			log.Printf("Erro ao descartar job %d: %v", job.ID, err)
+		}
+		s.sendErrorMessage(&msg)
+		return 0
+	}
+
+	if err := s.db.MarkJobFailed(job.ID, models.JobFailed, err.Error()); err != nil {
+		log.Printf("Erro ao registrar falha do job %d: %v", job.ID, err)
+	}
+
+	// Espera mais a cada tentativa. Zero encerraria o job, então há um mínimo
+	return max(s.config.RetryDelay*time.Duration(job.Attempts), time.Millisecond)
+}
+
+// ResumeJobs retoma os jobs deixados pendentes por uma execução anterior. Jobs
+// que já esgotaram as tentativas são descartados e o usuário é avisado
+func (s *TelegramService) ResumeJobs() {
+	jobs, err := s.db.GetUnfinishedJobs()
+	if err != nil {
+		log.Printf("Erro ao buscar jobs pendentes: %v", err)
+		return
+	}
+
+	resumed := 0
+	for _, job := range jobs {
+		if job.Attempts < s.config.JobMaxAttempts {
+			if s.resumeDenied(job) {
+				continue
+			}
+			s.submitJob(job)
+			resumed++
+			continue
+		}
+
+		if err := s.db.MarkJobFailed(job.ID, models.JobDead, "interrompido por reinicialização"); err != nil {
+			log.Printf("Erro ao descartar job %d: %v", job.ID, err)
+			continue
+		}
+
+		var msg models.TelegramMessage
+		if err := json.Unmarshal([]byte(job.Message), &msg); err != nil {
+			log.Printf("Job %d com mensagem inválida: %v", job.ID, err)
+			continue
+		}
+		locale := s.userLocale(msg.From)
+		s.sendTextMessage(&msg, i18n.T(locale, "job.lost", database.TruncateText(job.Question, 100)))
+	}
+
+	if len(jobs) > 0 {
+		log.Printf("Jobs pendentes: %d retomados, %d descartados", resumed, len(jobs)-resumed)
+	}
+}
+
+// resumeDenied verifica se o autor do job ainda tem acesso ao chat. Jobs
+// bloqueados são descartados sem chamar o provedor
+func (s *TelegramService) resumeDenied(job models.Job) bool {
+	var msg models.TelegramMessage
+	if err := json.Unmarshal([]byte(job.Message), &msg); err != nil || msg.From == nil || msg.Chat == nil {
+		// runJob descarta jobs com mensagem inválida
+		return false
+	}
+
+	denial := s.backgroundDenial(msg.From, msg.Chat, fmt.Sprintf("job=%d", job.ID))
+	if denial == "" {
+		return false
+	}
+
+	log.Printf("Job %d de %d bloqueado (%s), descartando", job.ID, job.UserID, denial)
+	if err := s.db.MarkJobFailed(job.ID, models.JobDead, "acesso negado: "+denial); err != nil {
+		log.Printf("Erro ao descartar job %d: %v", job.ID, err)
+	}
+	return true
+}
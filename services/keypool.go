@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-ai/models"
+)
+
+// KeyPool distribui as requisições de um provedor entre várias chaves de API,
+// afastando temporariamente as chaves que atingiram o limite de cota
+type KeyPool struct {
+	mu       sync.Mutex
+	provider string
+	keys     []*poolKey
+	next     int
+	strategy string
+	cooldown time.Duration
+}
+
+type poolKey struct {
+	value         string
+	benchedUntil  time.Time
+	lastLimitedAt time.Time
+	lastUsedAt    time.Time
+	requests      int64
+	failures      int64
+	rateLimits    int64
+	lastError     string
+}
+
+func NewKeyPool(provider string, keys []string, strategy string, cooldown time.Duration) *KeyPool {
+	pool := &KeyPool{
+		provider: provider,
+		strategy: strategy,
+		cooldown: cooldown,
+	}
+	for _, key := range keys {
+		pool.keys = append(pool.keys, &poolKey{value: key})
+	}
+	return pool
+}
+
+// Acquire escolhe a próxima chave disponível e retorna seu índice e valor.
+// Retorna erro se todas as chaves estiverem em cooldown
+func (p *KeyPool) Acquire() (int, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	chosen := -1
+
+	// Percorre as chaves a partir da próxima posição do round robin
+	for offset := 0; offset < len(p.keys); offset++ {
+		index := (p.next + offset) % len(p.keys)
+		key := p.keys[index]
+		if now.Before(key.benchedUntil) {
+			continue
+		}
+
+		if p.strategy != "least_limited" {
+			chosen = index
+			break
+		}
+
+		// least_limited: prefere a chave limitada há mais tempo (ou nunca limitada)
+		if chosen == -1 || key.lastLimitedAt.Before(p.keys[chosen].lastLimitedAt) {
+			chosen = index
+		}
+	}
+
+	if chosen == -1 {
+		return -1, "", fmt.Errorf("todas as %d chaves de %s estão em cooldown por limite de cota", len(p.keys), p.provider)
+	}
+
+	p.next = (chosen + 1) % len(p.keys)
+	key := p.keys[chosen]
+	key.requests++
+	key.lastUsedAt = now
+
+	return chosen, key.value, nil
+}
+
+// ReportSuccess limpa o último erro registrado para a chave
+func (p *KeyPool) ReportSuccess(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keys[index].lastError = ""
+}
+
+// ReportFailure registra uma falha da chave. Erros de cota (429) afastam a
+// chave pelo período de cooldown configurado
+func (p *KeyPool) ReportFailure(index int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := p.keys[index]
+	key.failures++
+	key.lastError = err.Error()
+
+	if isQuotaError(err) {
+		now := time.Now()
+		key.rateLimits++
+		key.lastLimitedAt = now
+		key.benchedUntil = now.Add(p.cooldown)
+	}
+}
+
+// Health retorna o estado atual de cada chave, com os valores mascarados
+func (p *KeyPool) Health() []models.KeyHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	health := make([]models.KeyHealth, 0, len(p.keys))
+	for _, key := range p.keys {
+		h := models.KeyHealth{
+			Provider:   p.provider,
+			Key:        maskKey(key.value),
+			Available:  !now.Before(key.benchedUntil),
+			Requests:   key.requests,
+			Failures:   key.failures,
+			RateLimits: key.rateLimits,
+			LastError:  key.lastError,
+		}
+		if !h.Available {
+			benchedUntil := key.benchedUntil
+			h.BenchedUntil = &benchedUntil
+		}
+		if !key.lastUsedAt.IsZero() {
+			lastUsedAt := key.lastUsedAt
+			h.LastUsedAt = &lastUsedAt
+		}
+		health = append(health, h)
+	}
+
+	return health
+}
+
+// isQuotaError identifica erros de limite de requisições ou de cota dos provedores
+func isQuotaError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "429") ||
+		strings.Contains(msg, "resource_exhausted") ||
+		strings.Contains(msg, "quota") ||
+		strings.Contains(msg, "rate limit")
+}
+
+// maskKey mantém apenas os últimos caracteres da chave para identificação
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// handleLanguageCommand mostra ou altera o idioma usado pelo bot com o usuário
+func (s *TelegramService) handleLanguageCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	requested := strings.TrimSpace(args)
+
+	if requested == "" {
+		s.sendTextMessage(msg, i18n.T(locale, "language.current", languageLabel(locale), supportedLanguages()))
+		return
+	}
+
+	// Aceita variações como "pt", "pt-br" ou "EN", desde que correspondam a um idioma suportado
+	resolved := i18n.Resolve(requested)
+	requestedBase, _, _ := strings.Cut(strings.ToLower(requested), "-")
+	resolvedBase, _, _ := strings.Cut(strings.ToLower(resolved), "-")
+	if requestedBase != resolvedBase {
+		s.sendTextMessage(msg, i18n.T(locale, "language.unknown", requested, supportedLanguages()))
+		return
+	}
+
+	if err := s.db.SetUserLanguage(msg.From.ID, resolved); err != nil {
+		log.Printf("Erro ao salvar idioma do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(resolved, "language.set", languageLabel(resolved)))
+}
+
+// languageLabel formata o nome do idioma para exibição
+func languageLabel(code string) string {
+	for _, locale := range i18n.Supported {
+		if locale.Code == code {
+			return fmt.Sprintf("%s %s", locale.Flag, locale.Name)
+		}
+	}
+	return code
+}
+
+// supportedLanguages lista os idiomas disponíveis, um por linha
+func supportedLanguages() string {
+	var sb strings.Builder
+	for _, locale := range i18n.Supported {
+		fmt.Fprintf(&sb, "%s %s - /language %s\n", locale.Flag, locale.Name, locale.Code)
+	}
+	return sb.String()
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	headingRe      = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe       = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRe      = regexp.MustCompile(`^(\s*)(\d{1,9})[.)]\s+(.*)$`)
+	ruleRe         = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	tableDividerRe = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+)
+
+// renderMarkdown converte o Markdown gerado pelos modelos para o subconjunto de
+// HTML aceito pelo Telegram (parse_mode HTML). Títulos viram negrito, listas
+// usam marcadores, tabelas são exibidas como bloco monoespaçado e blocos de
+// código mantêm a linguagem. Marcações sem fechamento são mantidas como texto
+func renderMarkdown(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence := trimmed[:3]
+			language := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			out = append(out, renderCodeBlock(strings.Join(code, "\n"), language))
+
+		case isTableStart(lines, i):
+			var rows []string
+			for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, lines[i])
+			}
+			i--
+			out = append(out, renderTable(rows))
+
+		case headingRe.MatchString(line):
+			out = append(out, "<b>"+renderInline(headingRe.FindStringSubmatch(line)[2])+"</b>")
+
+		case ruleRe.MatchString(line):
+			out = append(out, "──────────")
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, renderInline(strings.TrimPrefix(text, " ")))
+			}
+			i--
+			out = append(out, "<blockquote>"+strings.Join(quote, "\n")+"</blockquote>")
+
+		case bulletRe.MatchString(line):
+			m := bulletRe.FindStringSubmatch(line)
+			out = append(out, listIndent(m[1])+"• "+renderInline(m[2]))
+
+		case orderedRe.MatchString(line):
+			m := orderedRe.FindStringSubmatch(line)
+			out = append(out, listIndent(m[1])+m[2]+". "+renderInline(m[3]))
+
+		default:
+			out = append(out, renderInline(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func renderCodeBlock(code, language string) string {
+	if language == "" {
+		return "<pre>" + html.EscapeString(code) + "</pre>"
+	}
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`, html.EscapeString(language), html.EscapeString(code))
+}
+
+// listIndent preserva o aninhamento das listas usando dois espaços por nível
+func listIndent(spaces string) string {
+	width := len(strings.ReplaceAll(spaces, "\t", "    "))
+	return strings.Repeat("  ", width/2)
+}
+
+// isTableStart detecta uma tabela: uma linha com "|" seguida da linha divisória
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) &&
+		strings.Contains(lines[i], "|") &&
+		tableDividerRe.MatchString(lines[i+1])
+}
+
+// renderTable alinha as colunas da tabela em um bloco monoespaçado, já que o
+// Telegram não suporta tabelas
+func renderTable(rows []string) string {
+	var cells [][]string
+	var widths []int
+	for i, row := range rows {
+		if i == 1 && tableDividerRe.MatchString(row) {
+			continue
+		}
+
+		row = strings.TrimSpace(row)
+		row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+		var rowCells []string
+		for j, cell := range strings.Split(row, "|") {
+			cell = stripInline(strings.TrimSpace(cell))
+			rowCells = append(rowCells, cell)
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+		cells = append(cells, rowCells)
+	}
+
+	var sb strings.Builder
+	for i, row := range cells {
+		var padded []string
+		for j, cell := range row {
+			padded = append(padded, cell+strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(padded, " | "), " "))
+		sb.WriteString("\n")
+
+		// Separa o cabeçalho do corpo
+		if i == 0 && len(cells) > 1 {
+			var divider []string
+			for _, width := range widths[:len(row)] {
+				divider = append(divider, strings.Repeat("-", width))
+			}
+			sb.WriteString(strings.Join(divider, "-+-"))
+			sb.WriteString("\n")
+		}
+	}
+
+	return "<pre>" + html.EscapeString(strings.TrimRight(sb.String(), "\n")) + "</pre>"
+}
+
+// stripInline remove as marcações mais comuns de ênfase e código
+func stripInline(text string) string {
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+}
+
+// renderInline converte as marcações de uma linha: código, negrito, itálico,
+// tachado e links. O restante do texto é escapado para HTML. O texto é
+// percorrido por posição em bytes, sem copiar o restante da linha a cada caractere
+func renderInline(text string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		rest := text[i:]
+
+		switch {
+		case r == '\\' && i+size < len(text):
+			next, nextSize := utf8.DecodeRuneInString(text[i+size:])
+			if unicode.IsPunct(next) || unicode.IsSymbol(next) {
+				sb.WriteString(html.EscapeString(string(next)))
+				i += size + nextSize
+				continue
+			}
+
+		case r == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := strings.Repeat("`", ticks)
+			if end := strings.Index(rest[ticks:], fence); end > 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				sb.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += ticks + end + ticks
+				continue
+			}
+
+		case r == '[':
+			if label, url, n, ok := parseLink(rest); ok {
+				fmt.Fprintf(&sb, `<a href="%s">%s</a>`, html.EscapeString(url), renderInline(label))
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if inner, n, ok := delimited(text, i, rest[:2]); ok {
+				sb.WriteString("<b>" + renderInline(inner) + "</b>")
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(rest, "~~"):
+			if inner, n, ok := delimited(text, i, "~~"); ok {
+				sb.WriteString("<s>" + renderInline(inner) + "</s>")
+				i += n
+				continue
+			}
+
+		case r == '*' || r == '_':
+			if inner, n, ok := delimited(text, i, string(r)); ok {
+				sb.WriteString("<i>" + renderInline(inner) + "</i>")
+				i += n
+				continue
+			}
+		}
+
+		sb.WriteString(html.EscapeString(string(r)))
+		i += size
+	}
+
+	return sb.String()
+}
+
+// delimited procura o fechamento de uma marcação de ênfase que começa no byte
+// start de text. Retorna o conteúdo e quantos bytes foram consumidos. Para "_",
+// exige limites de palavra, evitando formatar nomes como snake_case
+func delimited(text string, start int, marker string) (string, int, bool) {
+	open := start + len(marker)
+	if open >= len(text) {
+		return "", 0, false
+	}
+	if first, _ := utf8.DecodeRuneInString(text[open:]); unicode.IsSpace(first) {
+		return "", 0, false
+	}
+	if marker[0] == '_' && start > 0 {
+		if prev, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(prev) {
+			return "", 0, false
+		}
+	}
+
+	// As marcações são ASCII, então nunca casam no meio de um caractere multibyte
+	for j := open + 1; j+len(marker) <= len(text); j++ {
+		if !strings.HasPrefix(text[j:], marker) {
+			continue
+		}
+		if prev, _ := utf8.DecodeLastRuneInString(text[:j]); unicode.IsSpace(prev) {
+			continue
+		}
+		// "*" isolado não pode fechar dentro de "**"
+		if len(marker) == 1 && j+1 < len(text) && text[j+1] == text[j] {
+			j++
+			continue
+		}
+		if marker[0] == '_' && j+len(marker) < len(text) {
+			if next, _ := utf8.DecodeRuneInString(text[j+len(marker):]); isWordRune(next) {
+				continue
+			}
+		}
+		return text[open:j], j + len(marker) - start, true
+	}
+
+	return "", 0, false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// parseLink reconhece [texto](url) no início de text e retorna o número de bytes consumidos
+func parseLink(text string) (string, string, int, bool) {
+	closeLabel := strings.Index(text, "](")
+	if closeLabel < 0 {
+		return "", "", 0, false
+	}
+	closeURL := strings.Index(text[closeLabel:], ")")
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+
+	label := text[1:closeLabel]
+	url := strings.TrimSpace(text[closeLabel+2 : closeLabel+closeURL])
+	if label == "" || url == "" || strings.ContainsAny(url, " \n") || strings.Contains(label, "[") {
+		return "", "", 0, false
+	}
+
+	// Apenas links absolutos são aceitos pelo Telegram
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "tg://") && !strings.HasPrefix(url, "mailto:") {
+		return "", "", 0, false
+	}
+
+	return label, url, closeLabel + closeURL + 1, true
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, md, want string
+	}{
+		// Escape
+		{"escapa HTML", "a < b && c > d", "a &lt; b &amp;&amp; c &gt; d"},
+		{"marcação escapada com barra", `\*não\*`, "*não*"},
+		{"código inline escapado", "`<tag>` e ``a`b``", "<code>&lt;tag&gt;</code> e <code>a`b</code>"},
+
+		// Ênfase
+		{"negrito, itálico e tachado", "**negrito** e *itálico* e ~~x~~", "<b>negrito</b> e <i>itálico</i> e <s>x</s>"},
+		{"snake_case não vira itálico", "use snake_case_name aqui", "use snake_case_name aqui"},
+		{"multibyte ao redor da ênfase", "ação **ênfase** 😀 _it_", "ação <b>ênfase</b> 😀 <i>it</i>"},
+		{"marcação sem fechamento", "2 * 3 = **6", "2 * 3 = **6"},
+
+		// Links
+		{"link https com & na URL", "[site](https://ex.com/?a=1&b=2)", `<a href="https://ex.com/?a=1&amp;b=2">site</a>`},
+		{"link com formatação no texto", "[**forte**](http://ex.com)", `<a href="http://ex.com"><b>forte</b></a>`},
+		{"link relativo fica como texto", "[rel](/path)", "[rel](/path)"},
+		{"esquema javascript recusado", "[js](javascript:alert(1))", "[js](javascript:alert(1))"},
+
+		// Blocos de código
+		{"cerca fechada", "```go\nx := 1\n```", `<pre><code class="language-go">x := 1</code></pre>`},
+		{"cerca sem fechamento vai até o fim", "```go\nx := \"<a>\"", `<pre><code class="language-go">x := &#34;&lt;a&gt;&#34;</code></pre>`},
+		{"marcação dentro do código é literal", "~~~\n**a** _b_\n~~~", "<pre>**a** _b_</pre>"},
+
+		// Tabelas e outros blocos
+		{"tabela vira pre alinhado", "| a | b |\n|---|---|\n| 1 | 22 |", "<pre>a | b\n--+---\n1 | 22</pre>"},
+		{"título, lista e citação", "# Título\n- item\n> cita", "<b>Título</b>\n• item\n<blockquote>cita</blockquote>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.md); got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, esperado %q", tt.md, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInlineLongLine(t *testing.T) {
+	// Uma linha longa sem quebras precisa ser processada de uma vez
+	line := strings.Repeat("texto comum com ação e <sinais> ", 20000)
+	got := renderInline(line)
+	if want := strings.Repeat("texto comum com ação e &lt;sinais&gt; ", 20000); got != want {
+		t.Errorf("renderInline alterou a linha longa")
+	}
+}
@@ -1,8 +1,16 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type TelegramAuthMiddleware struct {
@@ -15,70 +23,59 @@ func NewTelegramAuthMiddleware(botToken string) *TelegramAuthMiddleware {
 	}
 }
 
-// Desabilitar temporariamente a validação para desenvolvimento
-func (m *TelegramAuthMiddleware) ValidateInitData(initData string) bool {
-	// Log para debug
-	// log.Printf("Validando initData: %s", initData)
-
-	// Para desenvolvimento, vamos aceitar todas as requisições
-	// Atenção: REMOVER em produção!
-	return true
-
-	/* Implementação correta:
-	// Divide a string em pares chave-valor mantendo o formato original
-	params := make(map[string]string)
-	pairs := strings.Split(initData, "&")
-	hash := ""
-
-	for _, pair := range pairs {
-		parts := strings.SplitN(pair, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
+// initDataMaxAge limita a idade do initData aceito, para que dados copiados de
+// uma sessão antiga não sirvam indefinidamente
+const initDataMaxAge = 24 * time.Hour
 
-		key := parts[0]
-		value := parts[1]
-
-		if key == "hash" {
-			hash = value
-			continue
-		}
-		params[key] = value
+// ValidateInitData verifica a assinatura do initData enviado pelo Mini App. A
+// chave é o HMAC-SHA256 do token do bot com "WebAppData", e o hash cobre todos
+// os campos recebidos, menos o próprio hash, em ordem alfabética
+func (m *TelegramAuthMiddleware) ValidateInitData(initData string) bool {
+	params, err := url.ParseQuery(initData)
+	if err != nil {
+		return false
 	}
 
+	hash := params.Get("hash")
 	if hash == "" {
-		log.Printf("Hash não encontrado no initData")
 		return false
 	}
 
-	// Ordena as chaves
 	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
+	for k, values := range params {
+		// Campos repetidos não existem no initData do Telegram
+		if len(values) != 1 {
+			return false
+		}
+		if k != "hash" {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 
-	// Cria a string de verificação mantendo os valores exatamente como recebidos
-	var lines []string
-	for _, k := range keys {
-		lines = append(lines, fmt.Sprintf("%s=%s", k, params[k]))
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = k + "=" + params.Get(k)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(m.botToken))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(strings.Join(lines, "\n")))
+	expected := hex.EncodeToString(h.Sum(nil))
+
+	if !hmac.Equal([]byte(hash), []byte(expected)) {
+		log.Printf("Assinatura do initData inválida")
+		return false
+	}
+
+	authDate, err := strconv.ParseInt(params.Get("auth_date"), 10, 64)
+	if err != nil || time.Since(time.Unix(authDate, 0)) > initDataMaxAge {
+		log.Printf("initData expirado ou sem auth_date")
+		return false
 	}
-	checkString := strings.Join(lines, "\n")
-	log.Printf("String de verificação: %s", checkString)
-
-	// Calcula o HMAC-SHA256
-	secret := sha256.Sum256([]byte(m.botToken))
-	h := hmac.New(sha256.New, secret[:])
-	h.Write([]byte(checkString))
-	expectedHash := hex.EncodeToString(h.Sum(nil))
-
-	// Log do resultado da validação
-	log.Printf("Hash recebido: %s", hash)
-	log.Printf("Hash esperado: %s", expectedHash)
-	log.Printf("Validação: %v", hash == expectedHash)
-
-	return hash == expectedHash
-	*/
+
+	return true
 }
 
 func (m *TelegramAuthMiddleware) Middleware(next http.HandlerFunc) http.HandlerFunc {
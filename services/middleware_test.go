@@ -0,0 +1,72 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-TOKEN"
+
+// signInitData monta um initData assinado como o Telegram faz ao abrir o Mini App
+func signInitData(token string, userJSON string, authDate time.Time) string {
+	values := url.Values{}
+	values.Set("query_id", "AAHdF6IQAAAAAN0XohDhrOrc")
+	values.Set("user", userJSON)
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = k + "=" + values.Get(k)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(token))
+	h := hmac.New(sha256.New, secret.Sum(nil))
+	h.Write([]byte(strings.Join(lines, "\n")))
+	values.Set("hash", hex.EncodeToString(h.Sum(nil)))
+	return values.Encode()
+}
+
+func TestValidateInitData(t *testing.T) {
+	m := NewTelegramAuthMiddleware(testBotToken)
+	user := `{"id":42,"first_name":"Ana & Bia"}`
+	valid := signInitData(testBotToken, user, time.Now())
+
+	if !m.ValidateInitData(valid) {
+		t.Fatal("initData válido recusado")
+	}
+
+	tests := map[string]string{
+		"outro usuário":   strings.Replace(valid, "%22id%22%3A42", "%22id%22%3A43", 1),
+		"outro token":     signInitData("654321:OUTRO", user, time.Now()),
+		"sem hash":        strings.Split(valid, "&hash=")[0],
+		"hash inválido":   strings.Split(valid, "&hash=")[0] + "&hash=" + strings.Repeat("0", 64),
+		"expirado":        signInitData(testBotToken, user, time.Now().Add(-25*time.Hour)),
+		"vazio":           "",
+		"campo duplicado": valid + "&user=" + url.QueryEscape(`{"id":43}`),
+	}
+	for name, initData := range tests {
+		if m.ValidateInitData(initData) {
+			t.Errorf("%s: initData aceito", name)
+		}
+	}
+}
+
+func TestExtractUserID(t *testing.T) {
+	initData := signInitData(testBotToken, `{"id":42,"first_name":"Ana & Bia = 2"}`, time.Now())
+	if id, err := extractUserID(initData); err != nil || id != 42 {
+		t.Errorf("extractUserID = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"strings"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// Valores aceitos pelas preferências de geração do /settings. O primeiro de
+// cada lista é o padrão, usado quando o usuário não escolheu nada
+var (
+	answerLengths      = []string{"normal", "short", "detailed"}
+	temperaturePresets = []string{"default", "precise", "balanced", "creative"}
+	personas           = []string{"default", "teacher", "developer", "casual"}
+	autoNewChatHours   = []int{0, 1, 6, 24}
+)
+
+// presetTemperatures traduz as predefinições de temperatura para o valor enviado aos provedores
+var presetTemperatures = map[string]float64{
+	"precise":  0.2,
+	"balanced": 0.7,
+	"creative": 1.2,
+}
+
+// personaPrompt monta a instrução de sistema enviada aos provedores de IA,
+// pedindo que o modelo responda no idioma do usuário. As preferências do
+// usuário, quando houver, acrescentam o estilo da persona e o tamanho da resposta
+func personaPrompt(locale string, prefs *models.UserSettings) string {
+	locale = i18n.Resolve(locale)
+	parts := []string{i18n.T(locale, "persona.default")}
+	if prefs != nil {
+		if prefs.Persona != "" && prefs.Persona != personas[0] {
+			parts = append(parts, i18n.T(locale, "persona.style."+prefs.Persona))
+		}
+		if prefs.AnswerLength != "" && prefs.AnswerLength != answerLengths[0] {
+			parts = append(parts, i18n.T(locale, "persona.length."+prefs.AnswerLength))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// requestTemperature retorna a temperatura da predefinição escolhida pelo
+// usuário ou, sem predefinição, a temperatura configurada no provedor
+func requestTemperature(prefs *models.UserSettings, fallback float64) float64 {
+	if prefs != nil {
+		if temperature, ok := presetTemperatures[prefs.Temperature]; ok {
+			return temperature
+		}
+	}
+	return fallback
+}
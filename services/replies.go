@@ -0,0 +1,50 @@
+package services
+
+import (
+	"log"
+
+	"bot-ai/models"
+)
+
+// rememberAnswer registra qual resposta foi enviada em uma mensagem do bot e a
+// pergunta respondida, para que responder a ela continue o chat de onde a
+// resposta veio e editar a pergunta atualize a mensagem
+func (s *TelegramService) rememberAnswer(sent *models.TelegramMessage, hash string, questionID int) {
+	if sent == nil || sent.Chat == nil || hash == "" {
+		return
+	}
+	if err := s.db.SaveTelegramMessage(sent.Chat.ID, sent.MessageID, hash, questionID); err != nil {
+		log.Printf("Erro ao registrar mensagem %d do chat %d: %v", sent.MessageID, sent.Chat.ID, err)
+	}
+}
+
+// repliedChat retorna o chat do histórico da resposta do bot à qual a mensagem
+// responde, ou zero se ela não responder ao bot ou o chat não pertencer à conversa
+func (s *TelegramService) repliedChat(msg *models.TelegramMessage, conversationID int64) int64 {
+	reply := msg.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.ID != s.botInfo.ID {
+		return 0
+	}
+
+	hash, err := s.db.GetTelegramMessageHash(msg.Chat.ID, reply.MessageID)
+	if err != nil {
+		log.Printf("Erro ao buscar resposta %d do chat %d: %v", reply.MessageID, msg.Chat.ID, err)
+		return 0
+	}
+	if hash == "" {
+		return 0
+	}
+
+	chat, err := s.db.GetChatByMessageHash(hash)
+	if err != nil {
+		log.Printf("Erro ao buscar chat da resposta %s: %v", hash, err)
+		return 0
+	}
+
+	// Em grupos com histórico individual, responder à resposta dada a outro
+	// participante segue no chat de quem pergunta
+	if chat.UserID != conversationID {
+		return 0
+	}
+	return chat.ID
+}
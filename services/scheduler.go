@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// scheduleError guarda a chave do catálogo para que erros de validação do
+// /schedule sejam exibidos no idioma do usuário
+type scheduleError struct {
+	key  string
+	args []interface{}
+}
+
+func newScheduleError(key string, args ...interface{}) error {
+	return &scheduleError{key: key, args: args}
+}
+
+func (e *scheduleError) Error() string {
+	return i18n.T(i18n.DefaultLocale, e.key, e.args...)
+}
+
+// StartScheduler inicia uma rotina em segundo plano que executa os prompts agendados
+func (s *TelegramService) StartScheduler(checkInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		// Executa imediatamente para recuperar agendamentos perdidos durante uma reinicialização
+		s.runDueSchedules(time.Now())
+
+		for range ticker.C {
+			s.runDueSchedules(time.Now())
+		}
+	}()
+
+	log.Printf("Agendador de prompts iniciado: intervalo=%s", checkInterval)
+}
+
+// runDueSchedules executa todos os agendamentos cuja hora já chegou
+func (s *TelegramService) runDueSchedules(now time.Time) {
+	schedules, err := s.db.GetDueSchedules(now)
+	if err != nil {
+		log.Printf("Erro ao buscar agendamentos pendentes: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		// Avança o agendamento antes de executar, para que uma execução lenta
+		// não seja disparada novamente no próximo ciclo
+		var nextRunAt *time.Time
+		if schedule.Kind == "daily" {
+			next, err := nextDailyRun(schedule.TimeOfDay, loadLocation(schedule.Timezone), now)
+			if err != nil {
+				log.Printf("Agendamento %d com horário inválido (%s), desativando: %v", schedule.ID, schedule.TimeOfDay, err)
+			} else {
+				nextRunAt = &next
+			}
+		}
+
+		if err := s.db.MarkScheduleRun(schedule.ID, now, nextRunAt); err != nil {
+			log.Printf("Erro ao atualizar agendamento %d: %v", schedule.ID, err)
+			continue
+		}
+
+		// Usa a mesma fila das mensagens do usuário, respeitando a ordem e o limite de chamadas à IA
+		s.executeSchedule(schedule)
+	}
+}
+
+// executeSchedule registra o prompt agendado como job, para que a resposta seja
+// entregue no chat de origem pelo mesmo fluxo das perguntas normais
+func (s *TelegramService) executeSchedule(schedule models.Schedule) {
+	// Mensagem sintética para reaproveitar o mesmo fluxo de envio das respostas normais
+	msg := &models.TelegramMessage{
+		From: &models.TelegramUser{ID: schedule.UserID, FirstName: schedule.UserName, LanguageCode: schedule.Locale},
+		Chat: &models.TelegramChat{ID: schedule.ChatID, Type: schedule.ChatType},
+		Text: schedule.Prompt,
+
+		// Agendamentos criados em um tópico respondem no mesmo tópico
+		MessageThreadID: schedule.ThreadID,
+		IsTopicMessage:  schedule.ThreadID != 0,
+	}
+
+	// O autor pode ter perdido o acesso depois de criar o agendamento. Na
+	// manutenção, só esta execução é pulada
+	if denial := s.backgroundDenial(msg.From, msg.Chat, fmt.Sprintf("schedule=%d", schedule.ID)); denial != "" {
+		if denial == denyMaintenance {
+			log.Printf("Agendamento %d não executado durante a manutenção", schedule.ID)
+			return
+		}
+		log.Printf("Agendamento %d de %d bloqueado (%s), desativando", schedule.ID, schedule.UserID, denial)
+		if _, err := s.db.DeleteSchedule(schedule.UserID, schedule.ID); err != nil {
+			log.Printf("Erro ao desativar agendamento %d: %v", schedule.ID, err)
+		}
+		return
+	}
+
+	if _, err := s.enqueueQuestion(msg, schedule.Prompt, s.messagePolicy(msg)); err != nil {
+		log.Printf("Erro ao executar agendamento %d: %v", schedule.ID, err)
+		s.sendErrorMessage(msg)
+	}
+}
+
+// handleScheduleCommand cria um novo agendamento a partir do comando /schedule
+func (s *TelegramService) handleScheduleCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	timezone, err := s.userTimezone(msg.From.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar fuso horário do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	schedule, err := parseSchedule(args, loadLocation(timezone), time.Now())
+	if err != nil {
+		var scheduleErr *scheduleError
+		if errors.As(err, &scheduleErr) {
+			err = errors.New(i18n.T(locale, scheduleErr.key, scheduleErr.args...))
+		}
+		s.sendTextMessage(msg, fmt.Sprintf("⚠️ %s\n\n%s", err, i18n.T(locale, "schedule.usage")))
+		return
+	}
+
+	schedule.UserID = msg.From.ID
+	schedule.UserName = msg.From.FirstName
+	schedule.ChatID = msg.Chat.ID
+	schedule.ChatType = msg.Chat.Type
+	schedule.ThreadID = messageTopic(msg).ThreadID
+	schedule.Timezone = timezone
+	schedule.Locale = locale
+
+	id, err := s.db.CreateSchedule(schedule)
+	if err != nil {
+		log.Printf("Erro ao criar agendamento: %v", err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(locale, "schedule.created",
+		id, formatScheduleTime(schedule.NextRunAt, timezone, locale), timezone))
+}
+
+// handleListSchedules lista os agendamentos ativos do usuário
+func (s *TelegramService) handleListSchedules(msg *models.TelegramMessage) {
+	locale := s.userLocale(msg.From)
+	schedules, err := s.db.ListUserSchedules(msg.From.ID)
+	if err != nil {
+		log.Printf("Erro ao listar agendamentos do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	if len(schedules) == 0 {
+		s.sendTextMessage(msg, i18n.T(locale, "schedule.none")+"\n\n"+i18n.T(locale, "schedule.usage"))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(locale, "schedule.list_header"))
+	for _, schedule := range schedules {
+		when := i18n.T(locale, "schedule.once")
+		if schedule.Kind == "daily" {
+			when = i18n.T(locale, "schedule.daily", schedule.TimeOfDay)
+		}
+		sb.WriteString(i18n.T(locale, "schedule.list_item",
+			schedule.ID, when, formatScheduleTime(schedule.NextRunAt, schedule.Timezone, locale),
+			s.formatPreview(schedule.Prompt, 100)))
+	}
+
+	s.sendTextMessage(msg, sb.String())
+}
+
+// handleUnscheduleCommand remove um agendamento do usuário
+func (s *TelegramService) handleUnscheduleCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(args), "#"), 10, 64)
+	if err != nil {
+		s.sendTextMessage(msg, i18n.T(locale, "schedule.unschedule_usage"))
+		return
+	}
+
+	removed, err := s.db.DeleteSchedule(msg.From.ID, id)
+	if err != nil {
+		log.Printf("Erro ao remover agendamento %d: %v", id, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	if !removed {
+		s.sendTextMessage(msg, i18n.T(locale, "schedule.not_found", id))
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(locale, "schedule.removed", id))
+}
+
+// handleTimezoneCommand mostra ou altera o fuso horário usado nos agendamentos
+func (s *TelegramService) handleTimezoneCommand(msg *models.TelegramMessage, args string) {
+	locale := s.userLocale(msg.From)
+	timezone := strings.TrimSpace(args)
+	if timezone == "" {
+		current, err := s.userTimezone(msg.From.ID)
+		if err != nil {
+			log.Printf("Erro ao buscar fuso horário do usuário %d: %v", msg.From.ID, err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		s.sendTextMessage(msg, i18n.T(locale, "timezone.current", current))
+		return
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		s.sendTextMessage(msg, i18n.T(locale, "timezone.unknown", timezone))
+		return
+	}
+
+	if err := s.db.SetUserTimezone(msg.From.ID, timezone); err != nil {
+		log.Printf("Erro ao salvar fuso horário do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	s.sendTextMessage(msg, i18n.T(locale, "timezone.set", timezone))
+}
+
+// userTimezone retorna o fuso do usuário ou o fuso padrão da configuração
+func (s *TelegramService) userTimezone(userID int64) (string, error) {
+	settings, err := s.db.GetUserSettings(userID)
+	if err != nil {
+		return "", err
+	}
+	timezone := settings.Timezone
+	if timezone == "" {
+		timezone = s.config.DefaultTimezone
+	}
+	return timezone, nil
+}
+
+// parseSchedule interpreta os argumentos do comando /schedule
+func parseSchedule(args string, loc *time.Location, now time.Time) (*models.Schedule, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 {
+		return nil, newScheduleError("schedule.incomplete")
+	}
+
+	kind := strings.ToLower(fields[0])
+	schedule := &models.Schedule{Kind: "once"}
+
+	var promptStart int
+	switch kind {
+	case "daily":
+		next, err := nextDailyRun(fields[1], loc, now)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Kind = "daily"
+		schedule.TimeOfDay = fields[1]
+		schedule.NextRunAt = next
+		promptStart = 2
+
+	case "once":
+		// Aceita tanto "once HH:MM" quanto "once AAAA-MM-DD HH:MM"
+		if date, err := time.ParseInLocation("2006-01-02 15:04", fields[1]+" "+fields[2], loc); err == nil {
+			if !date.After(now) {
+				return nil, newScheduleError("schedule.date_past")
+			}
+			schedule.NextRunAt = date
+			promptStart = 3
+		} else {
+			next, err := nextDailyRun(fields[1], loc, now)
+			if err != nil {
+				return nil, err
+			}
+			schedule.NextRunAt = next
+			promptStart = 2
+		}
+
+	case "in":
+		delay, err := time.ParseDuration(fields[1])
+		if err != nil || delay < time.Minute {
+			return nil, newScheduleError("schedule.invalid_interval", fields[1])
+		}
+		schedule.NextRunAt = now.Add(delay)
+		promptStart = 2
+
+	default:
+		return nil, newScheduleError("schedule.unknown_kind", fields[0])
+	}
+
+	schedule.Prompt = strings.Join(fields[promptStart:], " ")
+	if schedule.Prompt == "" {
+		return nil, newScheduleError("schedule.missing_prompt")
+	}
+
+	return schedule, nil
+}
+
+// nextDailyRun calcula a próxima ocorrência do horário HH:MM no fuso indicado, estritamente após "after"
+func nextDailyRun(timeOfDay string, loc *time.Location, after time.Time) (time.Time, error) {
+	clock, err := time.Parse("15:04", timeOfDay)
+	if err != nil {
+		return time.Time{}, newScheduleError("schedule.invalid_time", timeOfDay)
+	}
+
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if !next.After(after) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	return next, nil
+}
+
+// loadLocation carrega o fuso horário, usando UTC caso o nome seja inválido
+func loadLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Printf("Fuso horário inválido %q, usando UTC: %v", timezone, err)
+		return time.UTC
+	}
+	return loc
+}
+
+func formatScheduleTime(t time.Time, timezone, locale string) string {
+	return t.In(loadLocation(timezone)).Format(i18n.T(locale, "format.datetime"))
+}
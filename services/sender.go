@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limites de envio do Telegram: uma mensagem por segundo em cada chat e 20
+// mensagens por minuto em cada grupo, além do limite global configurado
+const (
+	chatSendInterval  = time.Second
+	groupSendInterval = time.Minute / 20
+	groupSendBurst    = 20
+
+	// Espera máxima entre tentativas após erros 5xx
+	maxSendBackoff = 30 * time.Second
+)
+
+// rateLimitedMethods lista os métodos da API que contam nos limites de envio
+var rateLimitedMethods = map[string]bool{
+	"sendMessage":            true,
+	"editMessageText":        true,
+	"editMessageReplyMarkup": true,
+	"deleteMessage":          true,
+}
+
+// ResponseParameters traz as instruções do Telegram para repetir uma requisição recusada
+type ResponseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
+	RetryAfter      int   `json:"retry_after,omitempty"`
+}
+
+// TelegramError é uma resposta de erro da API do Telegram
+type TelegramError struct {
+	Code        int
+	Description string
+	Parameters  ResponseParameters
+}
+
+func (e *TelegramError) Error() string {
+	return fmt.Sprintf("erro na API do Telegram: %s", e.Description)
+}
+
+// sendQueue ordena os envios ao Telegram para respeitar os limites de cada
+// chat e o limite global. Cada envio espera a sua vez nos limitadores, que
+// atendem as reservas na ordem em que foram feitas
+type sendQueue struct {
+	global *rate.Limiter
+
+	mu     sync.Mutex
+	chats  map[int64]*rate.Limiter
+	groups map[int64]*rate.Limiter
+}
+
+func newSendQueue(globalRate int) *sendQueue {
+	if globalRate < 1 {
+		globalRate = 1
+	}
+	return &sendQueue{
+		global: rate.NewLimiter(rate.Limit(globalRate), globalRate),
+		chats:  make(map[int64]*rate.Limiter),
+		groups: make(map[int64]*rate.Limiter),
+	}
+}
+
+// wait bloqueia até que um envio para o chat seja permitido. IDs negativos
+// são grupos, supergrupos e canais, que também têm o limite por minuto
+func (q *sendQueue) wait(ctx context.Context, chatID int64) error {
+	if chatID != 0 {
+		q.mu.Lock()
+		chat := limiterFor(q.chats, chatID, rate.Every(chatSendInterval), 1)
+		var group *rate.Limiter
+		if chatID < 0 {
+			group = limiterFor(q.groups, chatID, rate.Every(groupSendInterval), groupSendBurst)
+		}
+		q.mu.Unlock()
+
+		if group != nil {
+			if err := group.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		if err := chat.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	return q.global.Wait(ctx)
+}
+
+// limiterFor retorna o limitador do chat, criando-o se necessário. Quando o
+// mapa cresce, descarta os limitadores ociosos (com todos os tokens disponíveis)
+func limiterFor(limiters map[int64]*rate.Limiter, chatID int64, every rate.Limit, burst int) *rate.Limiter {
+	if limiter, ok := limiters[chatID]; ok {
+		return limiter
+	}
+
+	if len(limiters) >= 10000 {
+		for id, limiter := range limiters {
+			if limiter.Tokens() >= float64(limiter.Burst()) {
+				delete(limiters, id)
+			}
+		}
+	}
+
+	limiter := rate.NewLimiter(every, burst)
+	limiters[chatID] = limiter
+	return limiter
+}
+
+// sendBackoff calcula a espera antes da próxima tentativa após um erro 5xx
+func sendBackoff(attempt int) time.Duration {
+	delay := time.Second << attempt
+	if delay <= 0 || delay > maxSendBackoff {
+		return maxSendBackoff
+	}
+	return delay
+}
+
+// payloadChatID extrai o chat_id do corpo de uma requisição, se houver
+func payloadChatID(body []byte) int64 {
+	var payload struct {
+		ChatID json.RawMessage `json:"chat_id"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &payload) != nil {
+		return 0
+	}
+	chatID, _ := strconv.ParseInt(string(payload.ChatID), 10, 64)
+	return chatID
+}
+
+// withChatID troca o chat_id do corpo de uma requisição
+func withChatID(body []byte, chatID int64) ([]byte, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	payload["chat_id"] = json.RawMessage(strconv.FormatInt(chatID, 10))
+	return json.Marshal(payload)
+}
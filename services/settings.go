@@ -0,0 +1,281 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bot-ai/i18n"
+	"bot-ai/models"
+)
+
+// settingsOption é uma preferência editável no menu do /settings: os valores
+// aceitos, como ler o valor atual, como exibi-lo e como salvá-lo
+type settingsOption struct {
+	Name    string
+	Values  []string
+	Current func(settings *models.UserSettings, locale string) string
+	Label   func(locale, value string) string
+	Save    func(userID int64, value string) error
+}
+
+// settingsOptions lista as preferências do menu, na ordem em que aparecem
+func (s *TelegramService) settingsOptions() []settingsOption {
+	hours := make([]string, len(autoNewChatHours))
+	for i, h := range autoNewChatHours {
+		hours[i] = strconv.Itoa(h)
+	}
+	languages := make([]string, len(i18n.Supported))
+	for i, locale := range i18n.Supported {
+		languages[i] = locale.Code
+	}
+
+	return []settingsOption{
+		{
+			Name:   "delivery",
+			Values: deliveryModes,
+			Current: func(settings *models.UserSettings, _ string) string {
+				return orDefault(settings.DeliveryMode, s.config.DefaultDeliveryMode)
+			},
+			Label: prefixedLabel("delivery.mode."),
+			Save:  s.db.SetUserDeliveryMode,
+		},
+		{
+			Name:   "length",
+			Values: answerLengths,
+			Current: func(settings *models.UserSettings, _ string) string {
+				return orDefault(settings.AnswerLength, answerLengths[0])
+			},
+			Label: prefixedLabel("settings.length."),
+			Save:  s.db.SetUserAnswerLength,
+		},
+		{
+			Name:   "temperature",
+			Values: temperaturePresets,
+			Current: func(settings *models.UserSettings, _ string) string {
+				return orDefault(settings.Temperature, temperaturePresets[0])
+			},
+			Label: prefixedLabel("settings.temperature."),
+			Save:  s.db.SetUserTemperature,
+		},
+		{
+			Name:   "language",
+			Values: languages,
+			Current: func(_ *models.UserSettings, locale string) string {
+				return locale
+			},
+			Label: func(_, value string) string { return languageLabel(value) },
+			Save:  s.db.SetUserLanguage,
+		},
+		{
+			Name:   "persona",
+			Values: personas,
+			Current: func(settings *models.UserSettings, _ string) string {
+				return orDefault(settings.Persona, personas[0])
+			},
+			Label: prefixedLabel("settings.persona."),
+			Save:  s.db.SetUserPersona,
+		},
+		{
+			Name:   "autonew",
+			Values: hours,
+			Current: func(settings *models.UserSettings, _ string) string {
+				return strconv.Itoa(settings.AutoNewChatHours)
+			},
+			Label: func(locale, value string) string {
+				if value == "0" {
+					return i18n.T(locale, "settings.autonew.off")
+				}
+				return i18n.T(locale, "settings.autonew.hours", value)
+			},
+			Save: func(userID int64, value string) error {
+				hours, err := strconv.Atoi(value)
+				if err != nil {
+					return err
+				}
+				return s.db.SetUserAutoNewChat(userID, hours)
+			},
+		},
+	}
+}
+
+// settingsOption busca uma preferência do menu pelo nome
+func (s *TelegramService) settingsOption(name string) (settingsOption, bool) {
+	for _, option := range s.settingsOptions() {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return settingsOption{}, false
+}
+
+func prefixedLabel(prefix string) func(locale, value string) string {
+	return func(locale, value string) string {
+		return i18n.T(locale, prefix+value)
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// handleSettingsCommand abre o menu de preferências do usuário
+func (s *TelegramService) handleSettingsCommand(msg *models.TelegramMessage) {
+	locale := s.userLocale(msg.From)
+
+	text, markup, err := s.settingsMenu(msg.From.ID, locale)
+	if err != nil {
+		log.Printf("Erro ao montar menu de preferências do usuário %d: %v", msg.From.ID, err)
+		s.sendErrorMessage(msg)
+		return
+	}
+
+	_, err = s.makeRequest("sendMessage", SendMessageRequest{
+		ChatID:      msg.Chat.ID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		log.Printf("Erro ao enviar menu de preferências: %v", err)
+	}
+}
+
+// settingsSummary descreve o valor atual de cada preferência, uma por linha
+func (s *TelegramService) settingsSummary(userID int64, locale string) (string, error) {
+	settings, err := s.db.GetUserSettings(userID)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	text.WriteString(i18n.T(locale, "settings.title"))
+	for _, option := range s.settingsOptions() {
+		current := option.Current(settings, locale)
+		fmt.Fprintf(&text, "\n%s", i18n.T(locale, "settings.line",
+			i18n.T(locale, "settings.option."+option.Name), option.Label(locale, current)))
+	}
+	return text.String(), nil
+}
+
+// settingsMenu monta o menu principal, com um botão por preferência
+func (s *TelegramService) settingsMenu(userID int64, locale string) (string, *InlineKeyboardMarkup, error) {
+	text, err := s.settingsSummary(userID, locale)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rows [][]InlineKeyboardButton
+	var row []InlineKeyboardButton
+	for _, option := range s.settingsOptions() {
+		button, err := s.callbacks.Button(i18n.T(locale, "settings.option."+option.Name), "st_open", option.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		row = append(row, button)
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	closeButton, err := s.callbacks.Button(i18n.T(locale, "settings.close"), "st_close", "")
+	if err != nil {
+		return "", nil, err
+	}
+	rows = append(rows, []InlineKeyboardButton{closeButton})
+
+	return text, &InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// settingsSubmenu monta a lista de valores de uma preferência, marcando o atual
+func (s *TelegramService) settingsSubmenu(option settingsOption, userID int64, locale string) (string, *InlineKeyboardMarkup, error) {
+	settings, err := s.db.GetUserSettings(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	current := option.Current(settings, locale)
+
+	var rows [][]InlineKeyboardButton
+	for _, value := range option.Values {
+		label := option.Label(locale, value)
+		if value == current {
+			label = "✅ " + label
+		}
+		button, err := s.callbacks.Button(label, "st_set", option.Name+"="+value)
+		if err != nil {
+			return "", nil, err
+		}
+		rows = append(rows, []InlineKeyboardButton{button})
+	}
+
+	back, err := s.callbacks.Button(i18n.T(locale, "settings.back"), "st_menu", "")
+	if err != nil {
+		return "", nil, err
+	}
+	rows = append(rows, []InlineKeyboardButton{back})
+
+	text := i18n.T(locale, "settings.choose", i18n.T(locale, "settings.option."+option.Name))
+	return text, &InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// handleSettingsMenu volta ao menu principal do /settings
+func (s *TelegramService) handleSettingsMenu(ctx *CallbackContext) (*CallbackResponse, error) {
+	text, markup, err := s.settingsMenu(ctx.Query.From.ID, ctx.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackResponse{EditText: text, Markup: markup}, nil
+}
+
+// handleSettingsOpen abre a lista de valores da preferência escolhida
+func (s *TelegramService) handleSettingsOpen(ctx *CallbackContext) (*CallbackResponse, error) {
+	option, ok := s.settingsOption(ctx.Payload)
+	if !ok {
+		return nil, errInvalidCallback
+	}
+
+	text, markup, err := s.settingsSubmenu(option, ctx.Query.From.ID, ctx.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackResponse{EditText: text, Markup: markup}, nil
+}
+
+// handleSettingsSet salva o valor escolhido e volta ao menu principal
+func (s *TelegramService) handleSettingsSet(ctx *CallbackContext) (*CallbackResponse, error) {
+	name, value, _ := strings.Cut(ctx.Payload, "=")
+	option, ok := s.settingsOption(name)
+	if !ok || !slices.Contains(option.Values, value) {
+		return nil, errInvalidCallback
+	}
+
+	userID := ctx.Query.From.ID
+	if err := option.Save(userID, value); err != nil {
+		return nil, err
+	}
+
+	// O idioma pode ter acabado de mudar, então o menu é montado com o idioma salvo
+	locale := s.userLocale(ctx.Query.From)
+	text, markup, err := s.settingsMenu(userID, locale)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackResponse{Text: i18n.T(locale, "settings.saved"), EditText: text, Markup: markup}, nil
+}
+
+// handleSettingsClose fecha o menu, deixando na mensagem o resumo das preferências
+func (s *TelegramService) handleSettingsClose(ctx *CallbackContext) (*CallbackResponse, error) {
+	text, err := s.settingsSummary(ctx.Query.From.ID, ctx.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackResponse{EditText: text, Markup: &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}}}, nil
+}
@@ -2,34 +2,46 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
-
-	"golang.org/x/time/rate"
+	"unicode/utf8"
 
 	"bot-ai/config"
 	"bot-ai/database"
+	"bot-ai/i18n"
 	"bot-ai/models"
 )
 
 type Update struct {
-	UpdateID int                     `json:"update_id"`
-	Message  *models.TelegramMessage `json:"message"`
+	UpdateID           int                     `json:"update_id"`
+	Message            *models.TelegramMessage `json:"message"`
+	EditedMessage      *models.TelegramMessage `json:"edited_message,omitempty"`
+	InlineQuery        *InlineQuery            `json:"inline_query,omitempty"`
+	ChosenInlineResult *ChosenInlineResult     `json:"chosen_inline_result,omitempty"`
+	CallbackQuery      *CallbackQuery          `json:"callback_query,omitempty"`
 }
 
+// allowedUpdates lista os tipos de atualização pedidos ao Telegram, tanto no polling quanto no webhook
+var allowedUpdates = []string{"message", "edited_message", "inline_query", "chosen_inline_result", "callback_query"}
+
 type WebAppInfo struct {
 	URL string `json:"url"`
 }
 
 type InlineKeyboardButton struct {
-	Text   string      `json:"text"`
-	URL    string      `json:"url,omitempty"`
-	WebApp *WebAppInfo `json:"web_app,omitempty"`
+	Text         string      `json:"text"`
+	URL          string      `json:"url,omitempty"`
+	WebApp       *WebAppInfo `json:"web_app,omitempty"`
+	CallbackData string      `json:"callback_data,omitempty"`
 }
 
 type InlineKeyboardMarkup struct {
@@ -37,44 +49,75 @@ type InlineKeyboardMarkup struct {
 }
 
 type SendMessageRequest struct {
-	ChatID           int64                `json:"chat_id"`
-	Text             string               `json:"text"`
-	ParseMode        string               `json:"parse_mode,omitempty"`
-	ReplyToMessageID int                  `json:"reply_to_message_id,omitempty"`
-	ReplyMarkup      InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	ChatID           int64                 `json:"chat_id"`
+	Text             string                `json:"text"`
+	ParseMode        string                `json:"parse_mode,omitempty"`
+	ReplyToMessageID int                   `json:"reply_to_message_id,omitempty"`
+	ReplyMarkup      *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+	MessageThreadID  int                   `json:"message_thread_id,omitempty"`
 }
 
 type TelegramResponse struct {
-	OK          bool            `json:"ok"`
-	Result      json.RawMessage `json:"result"`
-	Description string          `json:"description,omitempty"`
+	OK          bool                `json:"ok"`
+	Result      json.RawMessage     `json:"result"`
+	Description string              `json:"description,omitempty"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
 }
 
 type TelegramService struct {
 	baseURL string
 	token   string
 	client  *http.Client
-	limiter *rate.Limiter
+	sends   *sendQueue
 	config  *config.Config
 	db      *database.Database
 	ai      models.AIService
 	botInfo *models.TelegramUser
+	mention *regexp.Regexp // Menção ao bot em mensagens de grupo
+
+	// dispatcher serializa as perguntas de cada conversa e limita as chamadas simultâneas à IA
+	dispatcher *Dispatcher
+
+	// webhookSecret é o segredo enviado ao Telegram em setWebhook (modo webhook)
+	webhookSecret string
+
+	// inline guarda o debounce e o cache das respostas do modo inline
+	inline *inlineState
+
+	// callbacks encaminha os cliques nos botões inline para os handlers registrados
+	callbacks *CallbackRouter
+
+	// commands é o registro dos comandos atendidos pelo bot
+	commands *CommandRegistry
+
+	// broadcastWake acorda o envio de broadcasts após a confirmação de um administrador
+	broadcastWake chan struct{}
 }
 
-func NewTelegramService(cfg *config.Config, db *database.Database, ai models.AIService) (*TelegramService, error) {
+func NewTelegramService(cfg *config.Config, db *database.Database, ai models.AIService, dispatcher *Dispatcher) (*TelegramService, error) {
 	client := &http.Client{
 		Timeout: time.Second * 60,
 	}
 
 	service := &TelegramService{
-		baseURL: fmt.Sprintf("https://api.telegram.org/bot%s", cfg.TelegramToken),
+		baseURL: fmt.Sprintf("%s/bot%s", cfg.TelegramAPIURL, cfg.TelegramToken),
 		token:   cfg.TelegramToken,
 		client:  client,
-		limiter: rate.NewLimiter(rate.Every(time.Second), 30),
+		sends:   newSendQueue(cfg.TelegramRateLimit),
 		config:  cfg,
 		db:      db,
 		ai:      ai,
+
+		dispatcher: dispatcher,
+		inline:     newInlineState(),
+		callbacks:  NewCallbackRouter(cfg.TelegramToken, cfg.CallbackTTL),
+		commands:   NewCommandRegistry(),
+
+		broadcastWake: make(chan struct{}, 1),
 	}
+	service.registerCommands()
+	service.registerCallbacks()
 
 	// Obtém informações do bot
 	botInfo, err := service.getMe()
@@ -82,6 +125,14 @@ func NewTelegramService(cfg *config.Config, db *database.Database, ai models.AIS
 		return nil, fmt.Errorf("erro ao obter informações do bot: %w", err)
 	}
 	service.botInfo = botInfo
+	service.mention = mentionPattern(botInfo.UserName)
+
+	if cfg.TelegramMode == "webhook" {
+		service.webhookSecret, err = webhookSecretFor(cfg.TelegramWebhookSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return service, nil
 }
@@ -101,6 +152,14 @@ func (s *TelegramService) getMe() (*models.TelegramUser, error) {
 }
 
 func (s *TelegramService) makeRequest(method string, payload interface{}) (*TelegramResponse, error) {
+	return s.makeRequestContext(context.Background(), method, payload)
+}
+
+// makeRequestContext é como makeRequest, mas permite cancelar a requisição.
+// Os envios respeitam os limites do Telegram e são repetidos, até
+// TELEGRAM_MAX_RETRIES vezes, quando o Telegram pede para esperar (429), falha
+// com erro 5xx ou informa que o grupo virou supergrupo
+func (s *TelegramService) makeRequestContext(ctx context.Context, method string, payload interface{}) (*TelegramResponse, error) {
 	var body []byte
 	var err error
 
@@ -111,8 +170,52 @@ func (s *TelegramService) makeRequest(method string, payload interface{}) (*Tele
 		}
 	}
 
+	chatID := payloadChatID(body)
+	for attempt := 0; ; attempt++ {
+		if rateLimitedMethods[method] {
+			if err := s.sends.wait(ctx, chatID); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := s.doRequest(ctx, method, body)
+		var tgErr *TelegramError
+		if err == nil || !errors.As(err, &tgErr) || attempt >= s.config.TelegramMaxRetries {
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case tgErr.Parameters.MigrateToChatID != 0 && chatID != 0:
+			// O grupo virou supergrupo: atualiza os registros e repete no novo chat
+			s.migrateChat(chatID, tgErr.Parameters.MigrateToChatID)
+			chatID = tgErr.Parameters.MigrateToChatID
+			if body, err = withChatID(body, chatID); err != nil {
+				return nil, err
+			}
+			continue
+		case tgErr.Parameters.RetryAfter > 0:
+			delay = time.Duration(tgErr.Parameters.RetryAfter) * time.Second
+		case tgErr.Code >= 500:
+			delay = sendBackoff(attempt)
+		default:
+			return nil, err
+		}
+
+		log.Printf("Telegram recusou %s (%v), nova tentativa em %s", method, err, delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// doRequest faz uma única chamada à API do Telegram. Respostas de erro são
+// retornadas como *TelegramError
+func (s *TelegramService) doRequest(ctx context.Context, method string, body []byte) (*TelegramResponse, error) {
 	url := fmt.Sprintf("%s/%s", s.baseURL, method)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -132,42 +235,69 @@ func (s *TelegramService) makeRequest(method string, payload interface{}) (*Tele
 
 	var tgResp TelegramResponse
 	if err := json.Unmarshal(respBody, &tgResp); err != nil {
+		// Proxies e balanceadores podem responder 5xx sem o JSON da API
+		if resp.StatusCode >= 500 {
+			return nil, &TelegramError{Code: resp.StatusCode, Description: resp.Status}
+		}
 		return nil, err
 	}
 
 	if !tgResp.OK {
-		return nil, fmt.Errorf("erro na API do Telegram: %s", tgResp.Description)
+		tgErr := &TelegramError{Code: tgResp.ErrorCode, Description: tgResp.Description}
+		if tgResp.Parameters != nil {
+			tgErr.Parameters = *tgResp.Parameters
+		}
+		return nil, tgErr
 	}
 
 	return &tgResp, nil
 }
 
-func (s *TelegramService) Start() {
-	log.Printf("Bot iniciado: @%s", s.botInfo.UserName)
+// Start recebe as atualizações no modo configurado até que ctx seja cancelado
+func (s *TelegramService) Start(ctx context.Context) error {
+	// Atualiza o menu de comandos exibido pelo Telegram
+	s.syncCommands()
+
+	if s.config.TelegramMode == "webhook" {
+		return s.startWebhook(ctx)
+	}
+
+	// O Telegram recusa getUpdates enquanto houver um webhook registrado
+	if err := s.deleteWebhook(); err != nil {
+		return fmt.Errorf("erro ao remover webhook antes do polling: %w", err)
+	}
+
+	log.Printf("Bot iniciado: @%s (long polling)", s.botInfo.UserName)
 
 	offset := 0
-	for {
-		updates, err := s.getUpdates(offset)
+	for ctx.Err() == nil {
+		updates, err := s.getUpdates(ctx, offset)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			log.Printf("Erro ao obter atualizações: %v", err)
 			time.Sleep(5 * time.Second)
 			continue
 		}
 
 		for _, update := range updates {
-			go s.handleUpdate(update)
+			s.dispatch(update)
 			offset = update.UpdateID + 1
 		}
 	}
+
+	return nil
 }
 
-func (s *TelegramService) getUpdates(offset int) ([]Update, error) {
+func (s *TelegramService) getUpdates(ctx context.Context, offset int) ([]Update, error) {
 	payload := map[string]interface{}{
-		"offset":  offset,
-		"timeout": 60,
+		"offset":          offset,
+		"timeout":         60,
+		"allowed_updates": allowedUpdates,
 	}
 
-	resp, err := s.makeRequest("getUpdates", payload)
+	resp, err := s.makeRequestContext(ctx, "getUpdates", payload)
 	if err != nil {
 		return nil, err
 	}
@@ -209,6 +339,11 @@ func (s *TelegramService) formatPreview(answer string, maxLength int) string {
 		return answer
 	}
 
+	// Recua até o início de um caractere para não cortar caracteres multibyte
+	for maxLength > 0 && !utf8.RuneStart(answer[maxLength]) {
+		maxLength--
+	}
+
 	lastSpace := strings.LastIndex(answer[:maxLength], " ")
 	if lastSpace == -1 {
 		lastSpace = maxLength
@@ -218,7 +353,7 @@ func (s *TelegramService) formatPreview(answer string, maxLength int) string {
 }
 
 // keepTypingStatus mantém o status de "digitando" ativo até que o canal done seja fechado
-func (s *TelegramService) keepTypingStatus(chatID int64, done chan struct{}) {
+func (s *TelegramService) keepTypingStatus(msg *models.TelegramMessage, done chan struct{}) {
 	ticker := time.NewTicker(4 * time.Second) // Telegram requer atualização a cada 5s, usamos 4s para garantir
 	defer ticker.Stop()
 
@@ -227,93 +362,138 @@ func (s *TelegramService) keepTypingStatus(chatID int64, done chan struct{}) {
 		case <-done:
 			return
 		case <-ticker.C:
-			s.sendChatAction(chatID, "typing")
+			s.sendChatAction(msg, "typing")
 		}
 	}
 }
 
-func (s *TelegramService) handleUpdate(update Update) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("Recuperado de panic ao processar mensagem: %v", r)
+// dispatch encaminha uma atualização. Perguntas para a IA são registradas como
+// jobs e entram, na ordem de chegada, na fila da conversa; comandos e
+// mensagens ignoradas seguem direto
+func (s *TelegramService) dispatch(update Update) {
+	// A política do grupo é consultada uma única vez por atualização
+	policy := s.messagePolicy(update.Message)
+	if update.Message == nil {
+		policy = s.messagePolicy(update.EditedMessage)
+	}
+
+	if !s.checkAccess(update, policy) {
+		return
+	}
+
+	// Quem volta a falar com o bot no privado volta a receber os broadcasts
+	if msg := update.Message; msg != nil && msg.From != nil && msg.Chat != nil && msg.Chat.Type == "private" {
+		if err := s.db.ReactivateUser(msg.From.ID); err != nil {
+			log.Printf("Erro ao reativar usuário %d: %v", msg.From.ID, err)
 		}
-	}()
+	}
 
-	if update.Message == nil {
+	switch {
+	case update.InlineQuery != nil:
+		s.handleInlineQuery(update.InlineQuery)
+		return
+	case update.ChosenInlineResult != nil:
+		go s.handleChosenInlineResult(update.ChosenInlineResult)
+		return
+	case update.CallbackQuery != nil:
+		go s.handleCallbackQuery(update.CallbackQuery)
+		return
+	case update.EditedMessage != nil:
+		go s.handleEditedMessage(update.EditedMessage, policy)
+		return
+	case update.Message != nil && update.Message.MigrateToChatID != 0 && update.Message.Chat != nil:
+		go s.migrateChat(update.Message.Chat.ID, update.Message.MigrateToChatID)
 		return
 	}
 
-	// Verifica se é um comando /start com parâmetro (deeplink)
-	if strings.HasPrefix(update.Message.Text, "/start msg_") {
-		s.handleStartCommand(update.Message)
+	msg := update.Message
+	if msg != nil && msg.From != nil && msg.Chat != nil && s.handleFeedbackComment(msg) {
+		return
+	}
+	if msg == nil || msg.From == nil || msg.Chat == nil || strings.HasPrefix(msg.Text, "/") || !s.shouldProcessMessage(msg, policy) {
+		go s.handleUpdate(update)
 		return
 	}
 
-	// Verifica se é um comando /start simples e trata separadamente
-	if update.Message.Text == "/start" {
-		s.sendWelcomeMessage(update.Message)
+	question := s.extractQuestion(msg, policy)
+	if question == "" {
 		return
 	}
 
-	if !s.shouldProcessMessage(update.Message) {
+	position, err := s.enqueueQuestion(msg, question, policy)
+	if err != nil {
+		log.Printf("Erro ao registrar pergunta: %v", err)
+		go s.sendErrorMessage(msg)
 		return
 	}
 
-	// Processa comando /newchat
-	if update.Message.Text == "/newchat" {
-		err := s.ai.NewChat(update.Message.From.ID)
-		if err != nil {
-			log.Printf("Erro ao criar novo chat: %v", err)
-			s.sendErrorMessage(update.Message)
-			return
-		}
+	// Avisa o usuário quando a mensagem precisa esperar por outras na fila
+	if position > 0 {
+		go s.sendTextMessage(msg, i18n.T(s.userLocale(msg.From), "queue.position", position))
+	}
+}
 
-		// Para o comando /newchat, ainda precisamos salvar a mensagem pois não é processada pelo serviço de IA
-		hash, err := s.db.SaveMessage("✨ Novo chat iniciado! Pode começar a conversar.")
-		if err != nil {
-			log.Printf("Erro ao salvar mensagem: %v", err)
-			s.sendErrorMessage(update.Message)
-			return
+// handleUpdate trata as mensagens que não são perguntas para a IA, ou seja, os comandos
+func (s *TelegramService) handleUpdate(update Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recuperado de panic ao processar mensagem: %v", r)
 		}
-		s.sendResponseWithHash(update.Message, "✨ Novo chat iniciado! Pode começar a conversar.", hash)
-		return
-	}
+	}()
 
-	question := s.extractQuestion(update.Message)
-	if question == "" {
+	if update.Message == nil || update.Message.From == nil || update.Message.Chat == nil {
 		return
 	}
 
+	s.handleCommand(update.Message)
+}
+
+// answerQuestion obtém a resposta da IA e a envia ao usuário. Em caso de erro,
+// nada é enviado: quem chama decide entre tentar novamente ou avisar o usuário
+func (s *TelegramService) answerQuestion(msg *models.TelegramMessage, question string, conversationID int64) error {
 	// Criar canal para controlar o status de digitação
 	typingDone := make(chan struct{})
 
 	// Iniciar goroutine para manter o status de digitação
-	go s.keepTypingStatus(update.Message.Chat.ID, typingDone)
+	go s.keepTypingStatus(msg, typingDone)
 
 	// Enviar ação de "digitando" inicial
-	s.sendChatAction(update.Message.Chat.ID, "typing")
+	s.sendChatAction(msg, "typing")
+
+	// Respostas a mensagens antigas do bot continuam o chat daquela resposta
+	opts := s.askOptions(msg, conversationID)
+	opts.ChatHistoryID = s.repliedChat(msg, conversationID)
 
-	// Obter resposta da IA, agora passando o ID do usuário e recebendo também o hash
-	answer, hash, err := s.ai.AskWithRetry(update.Message.From.ID, question)
+	// Obter resposta da IA no histórico da conversa, recebendo também o hash. Uma
+	// única tentativa: as novas tentativas ficam a cargo do job (runJob)
+	answer, hash, err := s.ai.Ask(conversationID, question, opts)
 
 	// Fechar o canal para parar o status de digitação
 	close(typingDone)
 
 	if err != nil {
-		log.Printf("Erro ao obter resposta: %v", err)
-		s.sendErrorMessage(update.Message)
-		return
+		return fmt.Errorf("erro ao obter resposta: %w", err)
+	}
+
+	// Guarda o turno da pergunta para refazer a resposta se ela for editada
+	if msg.MessageID != 0 {
+		if err := s.db.SaveQuestionTurn(msg.Chat.ID, msg.MessageID, hash); err != nil {
+			log.Printf("Erro ao registrar pergunta %d do chat %d: %v", msg.MessageID, msg.Chat.ID, err)
+		}
 	}
 
-	// Usa o método atualizado que recebe o hash
-	s.sendResponseWithHash(update.Message, answer, hash)
+	s.sendResponseWithHash(msg, answer, hash)
+	return nil
 }
 
-func (s *TelegramService) sendChatAction(chatID int64, action string) {
+func (s *TelegramService) sendChatAction(msg *models.TelegramMessage, action string) {
 	payload := map[string]interface{}{
-		"chat_id": chatID,
+		"chat_id": msg.Chat.ID,
 		"action":  action,
 	}
+	if threadID := messageTopic(msg).ThreadID; threadID != 0 {
+		payload["message_thread_id"] = threadID
+	}
 
 	_, err := s.makeRequest("sendChatAction", payload)
 	if err != nil {
@@ -321,39 +501,124 @@ func (s *TelegramService) sendChatAction(chatID int64, action string) {
 	}
 }
 
-func (s *TelegramService) shouldProcessMessage(msg *models.TelegramMessage) bool {
-	return msg.Chat.Type == "private" ||
-		strings.Contains(msg.Text, "@"+s.botInfo.UserName)
+// shouldProcessMessage decide se a mensagem é uma pergunta para o bot. Em
+// grupos, depende dos gatilhos configurados em policy
+func (s *TelegramService) shouldProcessMessage(msg *models.TelegramMessage, policy *models.GroupSettings) bool {
+	return policy == nil || s.matchesGroupTrigger(msg, policy)
 }
 
-func (s *TelegramService) extractQuestion(msg *models.TelegramMessage) string {
+func (s *TelegramService) extractQuestion(msg *models.TelegramMessage, policy *models.GroupSettings) string {
 	question := msg.Text
-	if msg.Chat.Type != "private" {
-		question = strings.ReplaceAll(question, "@"+s.botInfo.UserName, "")
+	if policy != nil {
+		question = s.stripGroupTrigger(question, policy)
 	}
 	return strings.TrimSpace(question)
 }
 
+// userLocale retorna o idioma do usuário: o escolhido com /language ou, na
+// falta dele, o language_code enviado pelo Telegram
+func (s *TelegramService) userLocale(user *models.TelegramUser) string {
+	if user == nil {
+		return i18n.DefaultLocale
+	}
+
+	settings, err := s.db.GetUserSettings(user.ID)
+	if err != nil {
+		log.Printf("Erro ao buscar idioma do usuário %d: %v", user.ID, err)
+	} else if settings.Language != "" {
+		return settings.Language
+	}
+
+	return i18n.Resolve(user.LanguageCode)
+}
+
 func (s *TelegramService) sendErrorMessage(msg *models.TelegramMessage) {
 	payload := SendMessageRequest{
 		ChatID:           msg.Chat.ID,
-		Text:             "Desculpe, ocorreu um erro ao processar sua mensagem. Tente novamente mais tarde.",
+		MessageThreadID:  messageTopic(msg).ThreadID,
+		Text:             i18n.T(s.userLocale(msg.From), "error.generic"),
 		ReplyToMessageID: msg.MessageID,
 	}
 
 	s.makeRequest("sendMessage", payload)
 }
 
+// sendRendered envia uma mensagem formatada em HTML e retorna a mensagem enviada.
+// Se o Telegram recusar a formatação, a mensagem é reenviada como texto simples
+// com o conteúdo de plain
+func (s *TelegramService) sendRendered(payload SendMessageRequest, plain string) (*models.TelegramMessage, error) {
+	payload.ParseMode = "HTML"
+	resp, err := s.makeRequest("sendMessage", payload)
+	if isEntityParseError(err) {
+		log.Printf("Telegram recusou a mensagem formatada, reenviando como texto simples: %v", err)
+		payload.ParseMode = ""
+		payload.Text = plain
+		resp, err = s.makeRequest("sendMessage", payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sent models.TelegramMessage
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		return nil, fmt.Errorf("erro ao ler mensagem enviada: %w", err)
+	}
+	return &sent, nil
+}
+
+// isEntityParseError indica se o Telegram recusou a mensagem apenas pela
+// formatação. Em outros erros (rede, 5xx, chat bloqueado) a mensagem pode ter
+// chegado ou não adianta reenviá-la, então não há fallback para texto simples
+func isEntityParseError(err error) bool {
+	var tgErr *TelegramError
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusBadRequest &&
+		strings.Contains(tgErr.Description, "can't parse entities")
+}
+
+// sendTextMessage envia um texto simples como resposta à mensagem recebida
+func (s *TelegramService) sendTextMessage(msg *models.TelegramMessage, text string) {
+	payload := SendMessageRequest{
+		ChatID:           msg.Chat.ID,
+		MessageThreadID:  messageTopic(msg).ThreadID,
+		Text:             text,
+		ReplyToMessageID: msg.MessageID,
+	}
+
+	_, err := s.makeRequest("sendMessage", payload)
+	if err != nil {
+		log.Printf("Erro ao enviar mensagem: %v", err)
+	}
+}
+
+// renderedMessage é uma mensagem pronta para envio: o texto em HTML no payload
+// e a versão em texto simples usada se o Telegram recusar a formatação
+type renderedMessage struct {
+	payload SendMessageRequest
+	plain   string
+}
+
 func (s *TelegramService) sendResponseWithHash(msg *models.TelegramMessage, answer string, hash string) {
+	messages := s.responseMessages(msg, answer, hash)
+	for i, out := range messages {
+		sent, err := s.sendRendered(out.payload, out.plain)
+		if err != nil {
+			log.Printf("Erro ao enviar parte %d/%d da resposta: %v", i+1, len(messages), err)
+			s.sendErrorMessage(msg)
+			return
+		}
+		// Qualquer parte pode ser respondida para continuar a conversa
+		s.rememberAnswer(sent, hash, msg.MessageID)
+	}
+}
+
+// responseMessages monta as mensagens da resposta conforme o modo de entrega do usuário
+func (s *TelegramService) responseMessages(msg *models.TelegramMessage, answer string, hash string) []renderedMessage {
 	userName := msg.From.UserName
 	if userName == "" {
 		userName = msg.From.FirstName
 	}
 
-	preview := s.formatPreview(answer, 200)
-	escapedUserName := s.escapeMarkdown(userName)
-	escapedPreview := s.escapeMarkdown(preview)
-	response := fmt.Sprintf("Resposta para %s:\n\n%s", escapedUserName, escapedPreview)
+	locale := s.userLocale(msg.From)
 
 	var keyboard InlineKeyboardMarkup
 
@@ -362,7 +627,7 @@ func (s *TelegramService) sendResponseWithHash(msg *models.TelegramMessage, answ
 		// Em chats privados, usa o WebApp diretamente
 		webAppURL := fmt.Sprintf("%s/message/%s", s.config.WebAppURL, hash)
 		button := InlineKeyboardButton{
-			Text: "📝 Ver Resposta Completa",
+			Text: i18n.T(locale, "button.full_answer"),
 			WebApp: &WebAppInfo{
 				URL: webAppURL,
 			},
@@ -377,7 +642,7 @@ func (s *TelegramService) sendResponseWithHash(msg *models.TelegramMessage, answ
 		startParam := fmt.Sprintf("msg_%s", hash)
 		tgLink := fmt.Sprintf("https://t.me/%s?start=%s", s.botInfo.UserName, startParam)
 		button := InlineKeyboardButton{
-			Text: "📝 Ver Resposta Completa",
+			Text: i18n.T(locale, "button.full_answer"),
 			URL:  tgLink,
 		}
 		keyboard = InlineKeyboardMarkup{
@@ -387,20 +652,27 @@ func (s *TelegramService) sendResponseWithHash(msg *models.TelegramMessage, answ
 		}
 	}
 
+	// Botões de avaliação da resposta, abaixo do botão do Mini App
+	if row, err := s.feedbackRow(hash); err != nil {
+		log.Printf("Erro ao montar botões de avaliação: %v", err)
+	} else {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, row)
+	}
+
+	if s.wantsFullText(msg.From.ID, answer) {
+		return s.fullAnswerMessages(msg, locale, userName, answer, &keyboard)
+	}
+
+	preview := s.formatPreview(answer, 200)
 	payload := SendMessageRequest{
 		ChatID:           msg.Chat.ID,
-		Text:             response,
-		ParseMode:        "MarkdownV2",
+		MessageThreadID:  messageTopic(msg).ThreadID,
+		Text:             i18n.T(locale, "response.header", html.EscapeString(userName), renderMarkdown(preview)),
 		ReplyToMessageID: msg.MessageID,
-		ReplyMarkup:      keyboard,
+		ReplyMarkup:      &keyboard,
 	}
 
-	_, err := s.makeRequest("sendMessage", payload)
-	if err != nil {
-		log.Printf("Erro ao enviar mensagem: %v", err)
-		s.sendErrorMessage(msg)
-		return
-	}
+	return []renderedMessage{{payload, i18n.T(locale, "response.header", userName, preview)}}
 }
 
 func (s *TelegramService) handleStartCommand(msg *models.TelegramMessage) {
@@ -429,10 +701,11 @@ func (s *TelegramService) handleStartCommand(msg *models.TelegramMessage) {
 
 	// Cria a URL para o miniapp
 	webAppURL := fmt.Sprintf("%s/message/%s", s.config.WebAppURL, hash)
+	locale := s.userLocale(msg.From)
 
 	// Em chats privados, usa um menu do webapp mais proeminente
 	button := InlineKeyboardButton{
-		Text: "🔍 Toque aqui para abrir a resposta",
+		Text: i18n.T(locale, "button.open_answer"),
 		WebApp: &WebAppInfo{
 			URL: webAppURL,
 		},
@@ -446,13 +719,14 @@ func (s *TelegramService) handleStartCommand(msg *models.TelegramMessage) {
 
 	// Envia uma prévia da mensagem para incentivar a abertura do mini app
 	preview := s.formatPreview(message.Content, 100)
-	text := fmt.Sprintf("📝 *Resposta encontrada\\!*\n\n%s\n\n _Toque no botão abaixo para ver a resposta completa_", s.escapeMarkdown(preview))
+	text := i18n.T(locale, "start.found", s.escapeMarkdown(preview))
 
 	payload := SendMessageRequest{
-		ChatID:      msg.Chat.ID,
-		Text:        text,
-		ParseMode:   "MarkdownV2",
-		ReplyMarkup: keyboard,
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: messageTopic(msg).ThreadID,
+		Text:            text,
+		ParseMode:       "MarkdownV2",
+		ReplyMarkup:     &keyboard,
 	}
 
 	_, err = s.makeRequest("sendMessage", payload)
@@ -464,18 +738,19 @@ func (s *TelegramService) handleStartCommand(msg *models.TelegramMessage) {
 }
 
 func (s *TelegramService) sendWelcomeMessage(msg *models.TelegramMessage) {
+	locale := s.userLocale(msg.From)
 	userName := msg.From.FirstName
 	if userName == "" {
-		userName = "usuário"
+		userName = i18n.T(locale, "welcome.name")
 	}
 
-	welcomeText := fmt.Sprintf("Olá, %s! 👋\n\nEu sou o Orbi AI, seu assistente virtual. Pode me fazer perguntas sobre qualquer assunto!\n\nComando disponível:\n/newchat - Inicia uma nova conversa", userName)
+	welcomeText := i18n.T(locale, "welcome.text", userName)
 
 	// Botão para iniciar o miniapp
 	webAppURL := s.config.WebAppURL
 
 	button := InlineKeyboardButton{
-		Text: "📱 Abrir historioco",
+		Text: i18n.T(locale, "button.history"),
 		WebApp: &WebAppInfo{
 			URL: webAppURL,
 		},
@@ -488,9 +763,10 @@ func (s *TelegramService) sendWelcomeMessage(msg *models.TelegramMessage) {
 	}
 
 	payload := SendMessageRequest{
-		ChatID:      msg.Chat.ID,
-		Text:        welcomeText,
-		ReplyMarkup: keyboard,
+		ChatID:          msg.Chat.ID,
+		MessageThreadID: messageTopic(msg).ThreadID,
+		Text:            welcomeText,
+		ReplyMarkup:     &keyboard,
 	}
 
 	_, err := s.makeRequest("sendMessage", payload)
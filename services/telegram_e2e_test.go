@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bot-ai/config"
+	"bot-ai/database"
+	"bot-ai/i18n"
+	"bot-ai/models"
+	"bot-ai/services/telegramtest"
+)
+
+// startTestBot sobe o bot com o serviço de IA fake, um banco em memória e o
+// servidor falso do Telegram. env complementa a configuração padrão do teste
+func startTestBot(t *testing.T, env map[string]string) (*telegramtest.Server, *TelegramService, *database.Database) {
+	t.Helper()
+
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	// LoadConfig exige um .env; as variáveis vão pelo ambiente do teste para
+	// serem restauradas ao final
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(".env", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	defaults := map[string]string{
+		"TELEGRAM_BOT_TOKEN":    srv.Token,
+		"TELEGRAM_API_URL":      srv.URL,
+		"TELEGRAM_MODE":         "polling",
+		"AI_SERVICE":            "fake",
+		"FAKE_AI_MODE":          "echo",
+		"DEFAULT_DELIVERY_MODE": "full",
+		"ACCESS_MODE":           "open",
+	}
+	for name, value := range env {
+		defaults[name] = value
+	}
+	for name, value := range defaults {
+		t.Setenv(name, value)
+	}
+	cfg := config.LoadConfig()
+
+	db, err := database.NewDatabase(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bot, err := NewTelegramService(cfg, db, NewFakeAIService(cfg, db), NewDispatcher(cfg.MaxConcurrentAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := bot.Start(ctx); err != nil {
+			t.Errorf("Start: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		db.Close()
+	})
+
+	return srv, bot, db
+}
+
+// replyTo espera a resposta do bot à mensagem informada que contenha text,
+// ignorando outras respostas, como o aviso de fila
+func replyTo(t *testing.T, srv *telegramtest.Server, messageID int, text string) telegramtest.SentMessage {
+	t.Helper()
+
+	var answer telegramtest.SentMessage
+	_, err := srv.WaitFor(10*time.Second, func(messages []telegramtest.SentMessage) bool {
+		for _, m := range messages {
+			if m.ReplyToMessageID == messageID && strings.Contains(m.Text, text) {
+				answer = m
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatalf("sem resposta %q à mensagem %d: %v", text, messageID, err)
+	}
+	return answer
+}
+
+func TestConversationEndToEnd(t *testing.T) {
+	srv, _, db := startTestBot(t, nil)
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+
+	first := srv.SendText(chat, ana, "Olá")
+	answer := replyTo(t, srv, first.MessageID, "echo: Olá")
+	if answer.ChatID != 42 {
+		t.Errorf("resposta enviada ao chat %d", answer.ChatID)
+	}
+	if !strings.Contains(string(answer.ReplyMarkup), "👍") {
+		t.Errorf("resposta sem botões de avaliação: %s", answer.ReplyMarkup)
+	}
+
+	second := srv.SendText(chat, ana, "Tudo bem?")
+	replyTo(t, srv, second.MessageID, "echo: Tudo bem?")
+
+	// As duas trocas ficam no mesmo chat
+	chats, err := db.ListUserChats(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 {
+		t.Fatalf("esperava 1 chat, veio %d", len(chats))
+	}
+	messages, err := db.GetChatMessages(chats[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, m := range messages {
+		contents = append(contents, m.Role+": "+m.Content)
+	}
+	if len(messages) != 4 || messages[0].Content != "Olá" || messages[3].Content != "echo: Tudo bem?" {
+		t.Errorf("histórico = %q", contents)
+	}
+
+	// /newchat começa outro histórico para a próxima pergunta
+	reset := srv.SendText(chat, ana, "/newchat")
+	replyTo(t, srv, reset.MessageID, i18n.T("pt-BR", "newchat.started"))
+	third := srv.SendText(chat, ana, "De novo")
+	replyTo(t, srv, third.MessageID, "echo: De novo")
+
+	if chats, err := db.ListUserChats(42); err != nil || len(chats) != 2 {
+		t.Errorf("depois de /newchat: %d chats, erro %v", len(chats), err)
+	}
+}
+
+func TestEditedQuestionIsReansweredByJob(t *testing.T) {
+	srv, _, db := startTestBot(t, nil)
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+
+	question := srv.SendText(chat, ana, "Olá")
+	answer := replyTo(t, srv, question.MessageID, "echo: Olá")
+
+	srv.EditText(question, "Oi")
+	_, err := srv.WaitFor(10*time.Second, func(messages []telegramtest.SentMessage) bool {
+		for _, m := range messages {
+			if m.MessageID == answer.MessageID && strings.Contains(m.Text, "echo: Oi") {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatalf("resposta não foi refeita: %v", err)
+	}
+
+	// A resposta refeita passa pela tabela de jobs, marcada com o turno
+	// substituído. O job é concluído logo depois da edição
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jobs, err := db.ListJobs([]string{models.JobDone}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, job := range jobs {
+			if job.ReplaceTurnID != 0 && job.Question == "Oi" {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs concluídos = %+v, esperava um job da pergunta editada", jobs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSendRetriesAreDeliveredOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		description string
+		parameters  map[string]interface{}
+	}{
+		{"retry_after", 429, "Too Many Requests: retry after 1", map[string]interface{}{"retry_after": 1}},
+		{"erro do servidor", 502, "Bad Gateway", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := startTestBot(t, nil)
+			srv.FailNext("sendMessage", tt.code, tt.description, tt.parameters)
+
+			question := srv.SendText(telegramtest.PrivateChat(42), telegramtest.User(42, "Ana"), "Olá")
+			replyTo(t, srv, question.MessageID, "echo: Olá")
+
+			// A primeira chamada falhou e a segunda entregou a resposta, uma única vez
+			if calls := srv.Calls("sendMessage"); len(calls) != 2 {
+				t.Errorf("%d chamadas a sendMessage, esperado 2", len(calls))
+			}
+			delivered := 0
+			for _, m := range srv.Messages() {
+				if strings.Contains(m.Text, "echo: Olá") {
+					delivered++
+				}
+			}
+			if delivered != 1 {
+				t.Errorf("resposta entregue %d vezes", delivered)
+			}
+		})
+	}
+}
+
+func TestSendFollowsSupergroupMigration(t *testing.T) {
+	const oldID, newID = -100, -1001234
+	srv, _, db := startTestBot(t, nil)
+	if err := db.SaveGroupSettings(&models.GroupSettings{ChatID: oldID, ConversationMode: GroupConversationShared, Triggers: []string{TriggerMention}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.FailNext("sendMessage", 400, "Bad Request: group chat was upgraded to a supergroup chat",
+		map[string]interface{}{"migrate_to_chat_id": newID})
+
+	question := srv.SendText(telegramtest.GroupChat(oldID), telegramtest.User(42, "Ana"), "@test_bot Olá")
+	answer := replyTo(t, srv, question.MessageID, "echo: Olá")
+	if answer.ChatID != newID {
+		t.Errorf("resposta enviada ao chat %d, esperado %d", answer.ChatID, newID)
+	}
+
+	// MigrateChat levou a política do grupo para o supergrupo
+	settings, err := db.GetGroupSettings(newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.ConversationMode != GroupConversationShared {
+		t.Errorf("configurações do supergrupo = %+v", settings)
+	}
+}
+
+// signedCallback monta o callback_data assinado pelo bot com a emissão informada
+func signedCallback(bot *TelegramService, action, payload string, issuedAt time.Time) string {
+	body := fmt.Sprintf("%s:%s:%s", action, payload, strconv.FormatInt(issuedAt.Unix(), 36))
+	return body + ":" + bot.callbacks.sign(body)
+}
+
+// keyboardOf lê o teclado inline registrado para a mensagem
+func keyboardOf(t *testing.T, msg telegramtest.SentMessage) [][]InlineKeyboardButton {
+	t.Helper()
+	var markup InlineKeyboardMarkup
+	if err := json.Unmarshal(msg.ReplyMarkup, &markup); err != nil {
+		t.Fatalf("teclado inválido %s: %v", msg.ReplyMarkup, err)
+	}
+	return markup.InlineKeyboard
+}
+
+func TestOldRatingButtonStillWorks(t *testing.T) {
+	srv, bot, db := startTestBot(t, map[string]string{"WEBAPP_URL": "https://app.example.com"})
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+
+	question := srv.SendText(chat, ana, "Olá")
+	answer := replyTo(t, srv, question.MessageID, "echo: Olá")
+	_, payload, _, err := bot.callbacks.decode(keyboardOf(t, answer)[1][0].CallbackData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _, _ := strings.Cut(payload, "=")
+
+	// Um 👍 emitido muito além do TTL padrão continua valendo
+	srv.SendCallback(ana, answer.MessageID, signedCallback(bot, "fb_rate", hash+"=up", time.Now().Add(-30*24*time.Hour)))
+	if _, err := srv.WaitFor(5*time.Second, func([]telegramtest.SentMessage) bool {
+		return len(srv.Calls("answerCallbackQuery")) > 0
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	call := srv.Calls("answerCallbackQuery")[0]
+	if text, _ := call.Params["text"].(string); text != i18n.T("pt-BR", "feedback.thanks") {
+		t.Errorf("resposta ao clique = %q", text)
+	}
+	if feedback, err := db.GetFeedback(hash, 42); err != nil || feedback == nil || feedback.Rating != models.RatingUp {
+		t.Errorf("avaliação salva = %+v, erro %v", feedback, err)
+	}
+
+	// O teclado da resposta, com o botão do Mini App, não é alterado
+	for _, m := range srv.Messages() {
+		if m.MessageID != answer.MessageID {
+			continue
+		}
+		if m.Edits != 0 || string(m.ReplyMarkup) != string(answer.ReplyMarkup) {
+			t.Errorf("teclado da resposta alterado: %s", m.ReplyMarkup)
+		}
+		if keyboard := keyboardOf(t, m); keyboard[0][0].WebApp == nil {
+			t.Errorf("botão do Mini App removido: %s", m.ReplyMarkup)
+		}
+	}
+}
+
+func TestExpiredButtonRemovesOnlyItsRow(t *testing.T) {
+	srv, bot, _ := startTestBot(t, nil)
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+
+	// Qualquer botão de st_open já nasce expirado
+	bot.callbacks.HandleWithTTL("st_open", time.Nanosecond, bot.handleSettingsOpen)
+
+	srv.SendText(chat, ana, "/settings")
+	messages, err := srv.WaitForMessages(1, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	menu := messages[0]
+	before := keyboardOf(t, menu)
+	clicked := before[0][0]
+
+	srv.SendCallback(ana, menu.MessageID, clicked.CallbackData)
+	messages, err = srv.WaitFor(5*time.Second, func(messages []telegramtest.SentMessage) bool {
+		return messages[0].Edits > 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := keyboardOf(t, messages[0])
+	if len(after) != len(before)-1 {
+		t.Fatalf("esperava %d linhas, veio %d", len(before)-1, len(after))
+	}
+	for i, row := range after {
+		if !reflect.DeepEqual(row, before[i+1]) {
+			t.Errorf("linha %d alterada: %+v", i, row)
+		}
+	}
+
+	call := srv.Calls("answerCallbackQuery")[0]
+	if text, _ := call.Params["text"].(string); text != i18n.T("pt-BR", "callback.expired") {
+		t.Errorf("resposta ao clique = %q", text)
+	}
+}
+
+func TestFeedbackAggregatesByAnswerSource(t *testing.T) {
+	srv, _, db := startTestBot(t, nil)
+	chat, ana := telegramtest.PrivateChat(42), telegramtest.User(42, "Ana")
+
+	if err := db.SetUserPersona(42, personas[1]); err != nil {
+		t.Fatal(err)
+	}
+	question := srv.SendText(chat, ana, "Olá")
+	answer := replyTo(t, srv, question.MessageID, "echo: Olá")
+
+	// Trocar de persona depois da resposta não muda a origem registrada
+	if err := db.SetUserPersona(42, personas[2]); err != nil {
+		t.Fatal(err)
+	}
+	srv.SendCallback(ana, answer.MessageID, keyboardOf(t, answer)[1][1].CallbackData)
+	if _, err := srv.WaitFor(5*time.Second, func([]telegramtest.SentMessage) bool {
+		return len(srv.Calls("answerCallbackQuery")) > 0
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	byPersona, err := db.FeedbackByPersona()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byPersona) != 1 || byPersona[0].Persona != personas[1] || byPersona[0].Down != 1 {
+		t.Errorf("por persona = %+v", byPersona)
+	}
+
+	byModel, err := db.FeedbackByModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byModel) != 1 || byModel[0].Provider != "fake" || byModel[0].Model != "echo" {
+		t.Errorf("por modelo = %+v", byModel)
+	}
+
+	worst, err := db.WorstRatedAnswers(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worst) != 1 || worst[0].Persona != personas[1] || worst[0].Provider != "fake" {
+		t.Errorf("piores respostas = %+v", worst)
+	}
+}
+
+func TestSendRenderedFallsBackOnlyOnParseErrors(t *testing.T) {
+	srv, bot, _ := startTestBot(t, map[string]string{"TELEGRAM_MAX_RETRIES": "0"})
+	payload := SendMessageRequest{ChatID: 42, Text: "<b>quebrado"}
+
+	tests := []struct {
+		name        string
+		code        int
+		description string
+		wantCalls   int
+		wantErr     bool
+	}{
+		{"formatação recusada", 400, "Bad Request: can't parse entities: unclosed tag", 2, false},
+		{"chat bloqueado", 403, "Forbidden: bot was blocked by the user", 1, true},
+		{"erro do servidor", 502, "Bad Gateway", 1, true},
+		{"outro 400", 400, "Bad Request: chat not found", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(srv.Calls("sendMessage"))
+			srv.FailNext("sendMessage", tt.code, tt.description, nil)
+
+			sent, err := bot.sendRendered(payload, "quebrado")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro = %v, esperava erro: %v", err, tt.wantErr)
+			}
+			calls := srv.Calls("sendMessage")[before:]
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("%d chamadas a sendMessage, esperado %d", len(calls), tt.wantCalls)
+			}
+			if !tt.wantErr {
+				last := calls[len(calls)-1].Params
+				if last["text"] != "quebrado" || last["parse_mode"] != nil {
+					t.Errorf("reenvio = %+v", last)
+				}
+				if sent == nil {
+					t.Error("mensagem reenviada não retornada")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,631 @@
+// Package telegramtest implementa um servidor falso da Bot API do Telegram
+// para testes de ponta a ponta, sem acesso à rede.
+//
+// O servidor atende o subconjunto da API usado pelo bot (getMe, getUpdates,
+// sendMessage, sendChatAction, editMessageText, editMessageReplyMarkup,
+// answerCallbackQuery e alguns
+// métodos auxiliares), recebe atualizações injetadas pelo teste e registra
+// tudo o que o bot enviou. Uso típico:
+//
+//	srv := telegramtest.NewServer()
+//	defer srv.Close()
+//
+//	cfg.TelegramAPIURL = srv.URL
+//	cfg.TelegramToken = srv.Token
+//	db, _ := database.NewDatabase(":memory:")
+//	bot, _ := services.NewTelegramService(cfg, db, ai, services.NewDispatcher(1))
+//	go bot.Start(ctx)
+//
+//	srv.SendText(telegramtest.PrivateChat(42), telegramtest.User(42, "Ana"), "Olá")
+//	sent, err := srv.WaitForMessages(1, 5*time.Second)
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"bot-ai/models"
+)
+
+// DefaultToken é o token aceito pelo servidor quando nenhum outro é informado
+const DefaultToken = "123456:TEST-TOKEN"
+
+// Call é uma chamada recebida pelo servidor
+type Call struct {
+	Method string
+	Params map[string]interface{}
+}
+
+// SentMessage é uma mensagem enviada pelo bot, com as edições já aplicadas
+type SentMessage struct {
+	MessageID        int
+	ChatID           int64
+	ThreadID         int
+	Text             string
+	ParseMode        string
+	ReplyToMessageID int
+	ReplyMarkup      json.RawMessage
+	Edits            int
+	Deleted          bool
+}
+
+// injectedError é uma falha programada com FailNext
+type injectedError struct {
+	code        int
+	description string
+	parameters  map[string]interface{}
+}
+
+// storedFile é um arquivo registrado com AddFile
+type storedFile struct {
+	path    string
+	content []byte
+}
+
+// Server é o servidor falso da Bot API
+type Server struct {
+	// URL base a usar em TELEGRAM_API_URL e token aceito nas requisições
+	URL   string
+	Token string
+
+	// Bot é o usuário retornado por getMe
+	Bot models.TelegramUser
+
+	http   *httptest.Server
+	closed chan struct{}
+
+	mu            sync.Mutex
+	changed       chan struct{} // Fechado e recriado a cada mudança, para acordar quem espera
+	updates       []json.RawMessage
+	nextUpdateID  int
+	nextMessageID int
+	chats         map[int64]models.TelegramChat
+	members       map[[2]int64]string
+	calls         []Call
+	sent          []*SentMessage
+	failures      map[string][]injectedError
+	files         map[string]storedFile
+}
+
+// NewServer inicia um servidor falso com o token DefaultToken
+func NewServer() *Server {
+	s := &Server{
+		Token:         DefaultToken,
+		Bot:           models.TelegramUser{ID: 123456, FirstName: "Test Bot", UserName: "test_bot"},
+		closed:        make(chan struct{}),
+		changed:       make(chan struct{}),
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		chats:         make(map[int64]models.TelegramChat),
+		members:       make(map[[2]int64]string),
+		failures:      make(map[string][]injectedError),
+		files:         make(map[string]storedFile),
+	}
+	s.http = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.http.URL
+	return s
+}
+
+// Close encerra o servidor, liberando as chamadas de getUpdates em espera
+func (s *Server) Close() {
+	close(s.closed)
+	s.http.Close()
+}
+
+// PrivateChat retorna um chat privado com o ID informado
+func PrivateChat(id int64) models.TelegramChat {
+	return models.TelegramChat{ID: id, Type: "private"}
+}
+
+// GroupChat retorna um supergrupo com o ID informado (negativo, como no Telegram)
+func GroupChat(id int64) models.TelegramChat {
+	return models.TelegramChat{ID: id, Type: "supergroup"}
+}
+
+// User retorna um usuário com o ID e o nome informados
+func User(id int64, firstName string) models.TelegramUser {
+	return models.TelegramUser{ID: id, FirstName: firstName, LanguageCode: "pt-br"}
+}
+
+// SendUpdate injeta uma atualização, que será entregue na próxima chamada a
+// getUpdates. O update_id é preenchido pelo servidor e retornado
+func (s *Server) SendUpdate(update interface{}) int {
+	data, err := json.Marshal(update)
+	if err != nil {
+		panic(fmt.Sprintf("telegramtest: atualização inválida: %v", err))
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(fmt.Sprintf("telegramtest: atualização deve ser um objeto: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextUpdateID
+	s.nextUpdateID++
+	fields["update_id"] = json.RawMessage(fmt.Sprint(id))
+	data, _ = json.Marshal(fields)
+	s.updates = append(s.updates, data)
+	s.notifyLocked()
+	return id
+}
+
+// SendMessage injeta uma mensagem recebida pelo bot. O message_id é preenchido
+// pelo servidor quando estiver vazio
+func (s *Server) SendMessage(msg *models.TelegramMessage) *models.TelegramMessage {
+	s.mu.Lock()
+	if msg.MessageID == 0 {
+		msg.MessageID = s.nextMessageID
+		s.nextMessageID++
+	}
+	if msg.Chat != nil {
+		s.chats[msg.Chat.ID] = *msg.Chat
+	}
+	s.mu.Unlock()
+
+	s.SendUpdate(map[string]interface{}{"message": msg})
+	return msg
+}
+
+// SendText injeta uma mensagem de texto enviada por from no chat
+func (s *Server) SendText(chat models.TelegramChat, from models.TelegramUser, text string) *models.TelegramMessage {
+	return s.SendMessage(&models.TelegramMessage{From: &from, Chat: &chat, Text: text})
+}
+
+// EditText injeta a edição de uma mensagem já enviada ao bot
+func (s *Server) EditText(msg *models.TelegramMessage, text string) {
+	edited := *msg
+	edited.Text = text
+	s.SendUpdate(map[string]interface{}{"edited_message": &edited})
+}
+
+// SendCallback injeta o clique em um botão de uma mensagem enviada pelo bot
+func (s *Server) SendCallback(from models.TelegramUser, messageID int, data string) {
+	s.mu.Lock()
+	var message map[string]interface{}
+	for _, sent := range s.sent {
+		if sent.MessageID == messageID {
+			message = s.messageLocked(sent)
+		}
+	}
+	id := fmt.Sprintf("cb%d", s.nextUpdateID)
+	s.mu.Unlock()
+
+	s.SendUpdate(map[string]interface{}{"callback_query": map[string]interface{}{
+		"id":      id,
+		"from":    from,
+		"message": message,
+		"data":    data,
+	}})
+}
+
+// SetChatMemberStatus define o status retornado por getChatMember (padrão: "member")
+func (s *Server) SetChatMemberStatus(chatID, userID int64, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members[[2]int64{chatID, userID}] = status
+}
+
+// FailNext faz a próxima chamada ao método falhar com o código e a descrição
+// informados. parameters, se não for nil, vai no campo "parameters" da resposta
+// (ex: {"retry_after": 1} ou {"migrate_to_chat_id": -100123})
+func (s *Server) FailNext(method string, code int, description string, parameters map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], injectedError{code, description, parameters})
+}
+
+// AddFile registra um arquivo para getFile. O conteúdo é servido em
+// /file/bot<token>/<filePath>, como no servidor oficial; no modo --local do
+// telegram-bot-api, filePath é um caminho absoluto do disco
+func (s *Server) AddFile(fileID, filePath string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileID] = storedFile{path: filePath, content: content}
+}
+
+// Calls retorna as chamadas recebidas para o método, ou todas se method for vazio
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []Call
+	for _, call := range s.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Messages retorna uma cópia das mensagens enviadas pelo bot, em ordem
+func (s *Server) Messages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messagesLocked()
+}
+
+// WaitForMessages espera até que o bot tenha enviado pelo menos n mensagens
+func (s *Server) WaitForMessages(n int, timeout time.Duration) ([]SentMessage, error) {
+	return s.WaitFor(timeout, func(messages []SentMessage) bool { return len(messages) >= n })
+}
+
+// WaitFor espera até que cond seja verdadeira para as mensagens enviadas pelo
+// bot, reavaliando a cada chamada recebida pelo servidor
+func (s *Server) WaitFor(timeout time.Duration, cond func([]SentMessage) bool) ([]SentMessage, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		messages := s.messagesLocked()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if cond(messages) {
+			return messages, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return messages, fmt.Errorf("telegramtest: condição não atingida em %s (%d mensagens enviadas)", timeout, len(messages))
+		}
+	}
+}
+
+func (s *Server) messagesLocked() []SentMessage {
+	messages := make([]SentMessage, len(s.sent))
+	for i, sent := range s.sent {
+		messages[i] = *sent
+	}
+	return messages
+}
+
+// notifyLocked acorda quem espera por mudanças. Deve ser chamado com mu travado
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/file/bot") {
+		s.serveFile(w, r)
+		return
+	}
+
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || !strings.HasPrefix(r.URL.Path, "/bot") {
+		writeError(w, http.StatusNotFound, "Not Found", nil)
+		return
+	}
+	if token != s.Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	params := map[string]interface{}{}
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil && err.Error() != "EOF" {
+			writeError(w, http.StatusBadRequest, "Bad Request: invalid JSON", nil)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	if method != "getUpdates" {
+		s.calls = append(s.calls, Call{Method: method, Params: params})
+		s.notifyLocked()
+	}
+	if failures := s.failures[method]; len(failures) > 0 {
+		s.failures[method] = failures[1:]
+		s.mu.Unlock()
+		writeError(w, failures[0].code, failures[0].description, failures[0].parameters)
+		return
+	}
+	s.mu.Unlock()
+
+	switch method {
+	case "getMe":
+		writeResult(w, s.Bot)
+	case "getUpdates":
+		s.getUpdates(w, r, params)
+	case "sendMessage":
+		s.sendMessage(w, params)
+	case "editMessageText":
+		s.editMessageText(w, params)
+	case "editMessageReplyMarkup":
+		s.editMessageReplyMarkup(w, params)
+	case "deleteMessage":
+		s.deleteMessage(w, params)
+	case "getChatMember":
+		s.getChatMember(w, params)
+	case "getFile":
+		s.getFile(w, params)
+	case "sendChatAction", "answerCallbackQuery", "answerInlineQuery",
+		"setMyCommands", "deleteWebhook", "setWebhook":
+		writeResult(w, true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found", nil)
+	}
+}
+
+// getUpdates entrega as atualizações a partir de offset, esperando por novas
+// até o timeout pedido (long polling)
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]interface{}) {
+	offset := int(intParam(params, "offset"))
+	deadline := time.After(time.Duration(intParam(params, "timeout")) * time.Second)
+
+	for {
+		s.mu.Lock()
+		// Atualizações anteriores ao offset foram confirmadas e são descartadas
+		var pending []json.RawMessage
+		for _, update := range s.updates {
+			var head struct {
+				UpdateID int `json:"update_id"`
+			}
+			json.Unmarshal(update, &head)
+			if head.UpdateID >= offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(pending) > 0 {
+			writeResult(w, pending)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			writeResult(w, []json.RawMessage{})
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			writeResult(w, []json.RawMessage{})
+			return
+		}
+	}
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, params map[string]interface{}) {
+	text, _ := params["text"].(string)
+	if text == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request: message text is empty", nil)
+		return
+	}
+	parseMode, _ := params["parse_mode"].(string)
+	markup, _ := json.Marshal(params["reply_markup"])
+	if params["reply_markup"] == nil {
+		markup = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := &SentMessage{
+		MessageID:        s.nextMessageID,
+		ChatID:           intParam(params, "chat_id"),
+		ThreadID:         int(intParam(params, "message_thread_id")),
+		Text:             text,
+		ParseMode:        parseMode,
+		ReplyToMessageID: int(intParam(params, "reply_to_message_id")),
+		ReplyMarkup:      markup,
+	}
+	s.nextMessageID++
+	s.sent = append(s.sent, sent)
+	s.notifyLocked()
+
+	writeResult(w, s.messageLocked(sent))
+}
+
+func (s *Server) editMessageText(w http.ResponseWriter, params map[string]interface{}) {
+	if _, ok := params["inline_message_id"]; ok {
+		writeResult(w, true)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := s.findLocked(intParam(params, "chat_id"), int(intParam(params, "message_id")))
+	if sent == nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found", nil)
+		return
+	}
+
+	text, _ := params["text"].(string)
+	parseMode, _ := params["parse_mode"].(string)
+	markup, _ := json.Marshal(params["reply_markup"])
+	if params["reply_markup"] == nil {
+		markup = nil
+	}
+	if text == sent.Text && parseMode == sent.ParseMode && string(markup) == string(sent.ReplyMarkup) {
+		writeError(w, http.StatusBadRequest, "Bad Request: message is not modified", nil)
+		return
+	}
+
+	sent.Text = text
+	sent.ParseMode = parseMode
+	sent.ReplyMarkup = markup
+	sent.Edits++
+	s.notifyLocked()
+
+	writeResult(w, s.messageLocked(sent))
+}
+
+// editMessageReplyMarkup troca só o teclado da mensagem. Sem reply_markup, o
+// teclado é removido, como no Telegram
+func (s *Server) editMessageReplyMarkup(w http.ResponseWriter, params map[string]interface{}) {
+	if _, ok := params["inline_message_id"]; ok {
+		writeResult(w, true)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := s.findLocked(intParam(params, "chat_id"), int(intParam(params, "message_id")))
+	if sent == nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found", nil)
+		return
+	}
+
+	markup, _ := json.Marshal(params["reply_markup"])
+	if params["reply_markup"] == nil {
+		markup = nil
+	}
+	if string(markup) == string(sent.ReplyMarkup) {
+		writeError(w, http.StatusBadRequest, "Bad Request: message is not modified", nil)
+		return
+	}
+
+	sent.ReplyMarkup = markup
+	sent.Edits++
+	s.notifyLocked()
+
+	writeResult(w, s.messageLocked(sent))
+}
+
+func (s *Server) deleteMessage(w http.ResponseWriter, params map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := s.findLocked(intParam(params, "chat_id"), int(intParam(params, "message_id")))
+	if sent == nil || sent.Deleted {
+		writeError(w, http.StatusBadRequest, "Bad Request: message to delete not found", nil)
+		return
+	}
+	sent.Deleted = true
+	s.notifyLocked()
+
+	writeResult(w, true)
+}
+
+func (s *Server) getChatMember(w http.ResponseWriter, params map[string]interface{}) {
+	s.mu.Lock()
+	status, ok := s.members[[2]int64{intParam(params, "chat_id"), intParam(params, "user_id")}]
+	s.mu.Unlock()
+	if !ok {
+		status = "member"
+	}
+
+	writeResult(w, map[string]interface{}{
+		"status": status,
+		"user":   map[string]interface{}{"id": intParam(params, "user_id")},
+	})
+}
+
+func (s *Server) getFile(w http.ResponseWriter, params map[string]interface{}) {
+	fileID, _ := params["file_id"].(string)
+
+	s.mu.Lock()
+	file, ok := s.files[fileID]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Bad Request: invalid file_id", nil)
+		return
+	}
+
+	writeResult(w, map[string]interface{}{
+		"file_id":   fileID,
+		"file_size": len(file.content),
+		"file_path": file.path,
+	})
+}
+
+// serveFile atende o download de /file/bot<token>/<file_path>
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	token, filePath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/file/bot"), "/")
+	if token != s.Token {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, file := range s.files {
+		if strings.TrimPrefix(file.path, "/") == filePath {
+			w.Write(file.content)
+			return
+		}
+	}
+	http.Error(w, "Not Found", http.StatusNotFound)
+}
+
+func (s *Server) findLocked(chatID int64, messageID int) *SentMessage {
+	for _, sent := range s.sent {
+		if sent.ChatID == chatID && sent.MessageID == messageID {
+			return sent
+		}
+	}
+	return nil
+}
+
+// messageLocked monta o objeto Message da API para uma mensagem enviada pelo bot
+func (s *Server) messageLocked(sent *SentMessage) map[string]interface{} {
+	chat, ok := s.chats[sent.ChatID]
+	if !ok {
+		chat = PrivateChat(sent.ChatID)
+		if sent.ChatID < 0 {
+			chat = GroupChat(sent.ChatID)
+		}
+	}
+
+	message := map[string]interface{}{
+		"message_id": sent.MessageID,
+		"from":       s.Bot,
+		"chat":       chat,
+		"date":       time.Now().Unix(),
+		"text":       sent.Text,
+	}
+	if sent.ThreadID != 0 {
+		message["message_thread_id"] = sent.ThreadID
+		message["is_topic_message"] = true
+	}
+	if sent.ReplyToMessageID != 0 {
+		message["reply_to_message"] = map[string]interface{}{"message_id": sent.ReplyToMessageID, "chat": chat}
+	}
+	if sent.ReplyMarkup != nil {
+		message["reply_markup"] = sent.ReplyMarkup
+	}
+	return message
+}
+
+// intParam lê um parâmetro numérico, aceitando também números em texto
+func intParam(params map[string]interface{}, name string) int64 {
+	switch value := params[name].(type) {
+	case json.Number:
+		n, _ := value.Int64()
+		return n
+	case string:
+		n, _ := json.Number(value).Int64()
+		return n
+	}
+	return 0
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func writeError(w http.ResponseWriter, code int, description string, parameters map[string]interface{}) {
+	response := map[string]interface{}{"ok": false, "error_code": code, "description": description}
+	if parameters != nil {
+		response["parameters"] = parameters
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
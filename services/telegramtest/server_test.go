@@ -0,0 +1,177 @@
+package telegramtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// apiResponse é a resposta padrão da Bot API
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// call faz uma chamada ao servidor como o bot faria
+func call(t *testing.T, srv *Server, method string, params map[string]interface{}) apiResponse {
+	t.Helper()
+	body, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(srv.URL+"/bot"+srv.Token+"/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("%s: resposta inválida: %v", method, err)
+	}
+	return result
+}
+
+func keyboard(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"inline_keyboard": [][]map[string]interface{}{{{"text": text, "callback_data": "x"}}},
+	}
+}
+
+func TestSendAndEditMessage(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	resp := call(t, srv, "sendMessage", map[string]interface{}{"chat_id": 42, "text": "olá", "reply_to_message_id": 7})
+	if !resp.OK {
+		t.Fatalf("sendMessage falhou: %s", resp.Description)
+	}
+	var sent struct {
+		MessageID int `json:"message_id"`
+	}
+	json.Unmarshal(resp.Result, &sent)
+
+	if resp := call(t, srv, "editMessageText", map[string]interface{}{"chat_id": 42, "message_id": sent.MessageID, "text": "olá!"}); !resp.OK {
+		t.Fatalf("editMessageText falhou: %s", resp.Description)
+	}
+	if resp := call(t, srv, "editMessageText", map[string]interface{}{"chat_id": 42, "message_id": sent.MessageID, "text": "olá!"}); resp.OK || resp.ErrorCode != http.StatusBadRequest {
+		t.Errorf("edição sem mudança deveria falhar com 400, veio %+v", resp)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("esperava 1 mensagem, veio %d", len(messages))
+	}
+	if m := messages[0]; m.Text != "olá!" || m.Edits != 1 || m.ChatID != 42 || m.ReplyToMessageID != 7 {
+		t.Errorf("mensagem registrada = %+v", m)
+	}
+}
+
+func TestEditMessageReplyMarkup(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	resp := call(t, srv, "sendMessage", map[string]interface{}{"chat_id": 42, "text": "menu", "reply_markup": keyboard("a")})
+	var sent struct {
+		MessageID int `json:"message_id"`
+	}
+	json.Unmarshal(resp.Result, &sent)
+
+	// Troca o teclado
+	if resp := call(t, srv, "editMessageReplyMarkup", map[string]interface{}{"chat_id": 42, "message_id": sent.MessageID, "reply_markup": keyboard("b")}); !resp.OK {
+		t.Fatalf("editMessageReplyMarkup falhou: %s", resp.Description)
+	}
+	want, _ := json.Marshal(keyboard("b"))
+	if m := srv.Messages()[0]; string(m.ReplyMarkup) != string(want) || m.Text != "menu" || m.Edits != 1 {
+		t.Errorf("teclado não atualizado: %+v", m)
+	}
+
+	// O mesmo teclado de novo não é uma mudança
+	if resp := call(t, srv, "editMessageReplyMarkup", map[string]interface{}{"chat_id": 42, "message_id": sent.MessageID, "reply_markup": keyboard("b")}); resp.OK {
+		t.Error("edição sem mudança deveria falhar")
+	}
+
+	// Sem reply_markup, o teclado é removido
+	if resp := call(t, srv, "editMessageReplyMarkup", map[string]interface{}{"chat_id": 42, "message_id": sent.MessageID}); !resp.OK {
+		t.Fatalf("remoção do teclado falhou: %s", resp.Description)
+	}
+	if m := srv.Messages()[0]; m.ReplyMarkup != nil {
+		t.Errorf("teclado não removido: %s", m.ReplyMarkup)
+	}
+
+	if resp := call(t, srv, "editMessageReplyMarkup", map[string]interface{}{"chat_id": 42, "message_id": 999, "reply_markup": keyboard("c")}); resp.OK {
+		t.Error("edição de mensagem inexistente deveria falhar")
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	msg := srv.SendText(PrivateChat(42), User(42, "Ana"), "oi")
+
+	resp := call(t, srv, "getUpdates", map[string]interface{}{"offset": 0, "timeout": 1})
+	var updates []struct {
+		UpdateID int `json:"update_id"`
+		Message  struct {
+			MessageID int    `json:"message_id"`
+			Text      string `json:"text"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(resp.Result, &updates); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Message.Text != "oi" || updates[0].Message.MessageID != msg.MessageID {
+		t.Fatalf("atualizações = %+v", updates)
+	}
+
+	// O offset confirma as atualizações entregues; sem novas, a chamada espera o timeout
+	start := time.Now()
+	resp = call(t, srv, "getUpdates", map[string]interface{}{"offset": updates[0].UpdateID + 1, "timeout": 1})
+	if string(resp.Result) != "[]" {
+		t.Errorf("atualização confirmada entregue de novo: %s", resp.Result)
+	}
+	if time.Since(start) < 900*time.Millisecond {
+		t.Error("getUpdates não esperou o timeout")
+	}
+}
+
+func TestFailNextAndCalls(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.FailNext("sendMessage", http.StatusTooManyRequests, "Too Many Requests: retry after 1", map[string]interface{}{"retry_after": 1})
+
+	if resp := call(t, srv, "sendMessage", map[string]interface{}{"chat_id": 1, "text": "a"}); resp.OK || resp.ErrorCode != http.StatusTooManyRequests {
+		t.Errorf("falha programada não aplicada: %+v", resp)
+	}
+	if resp := call(t, srv, "sendMessage", map[string]interface{}{"chat_id": 1, "text": "a"}); !resp.OK {
+		t.Errorf("falha aplicada mais de uma vez: %+v", resp)
+	}
+
+	if calls := srv.Calls("sendMessage"); len(calls) != 2 {
+		t.Errorf("esperava 2 chamadas registradas, veio %d", len(calls))
+	}
+	if messages := srv.Messages(); len(messages) != 1 {
+		t.Errorf("esperava 1 mensagem enviada, veio %d", len(messages))
+	}
+}
+
+func TestRejectsWrongToken(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/botoutro:token/getMe", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("token errado retornou %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"bot-ai/database"
+	"bot-ai/models"
+)
+
+const titlePrompt = `Crie um título curto (no máximo 6 palavras) que resuma a conversa abaixo.
+Responda apenas com o título, sem aspas, sem pontuação final e no mesmo idioma da pergunta.
+
+Pergunta: %s
+
+Resposta: %s`
+
+// generateChatTitle pede ao provedor um título para o chat após a primeira troca de
+// mensagens. Deve ser chamada em uma goroutine: falhas apenas mantêm o preview como título
+func generateChatTitle(ai models.AIService, db *database.Database, chatID int64, question, answer string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recuperado de panic ao gerar título do chat %d: %v", chatID, r)
+		}
+	}()
+
+	prompt := fmt.Sprintf(titlePrompt,
+		database.TruncateText(question, 1000),
+		database.TruncateText(answer, 2000))
+
+	title, err := ai.Complete(prompt)
+	if err != nil {
+		log.Printf("Erro ao gerar título do chat %d: %v", chatID, err)
+		return
+	}
+
+	title = cleanTitle(title)
+	if title == "" {
+		return
+	}
+
+	if err := db.SetChatTitle(chatID, title); err != nil {
+		log.Printf("Erro ao salvar título do chat %d: %v", chatID, err)
+	}
+}
+
+// cleanTitle remove quebras de linha, marcações e aspas que o modelo possa incluir
+func cleanTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if line, _, found := strings.Cut(title, "\n"); found {
+		title = line
+	}
+	title = strings.Trim(title, " \"'`*#.")
+	return database.TruncateText(title, 80)
+}
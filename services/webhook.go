@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// startWebhook registra o webhook no Telegram e aguarda o cancelamento de ctx.
+// As atualizações chegam pelo HTTPServer, na rota devolvida por WebhookHandler
+func (s *TelegramService) startWebhook(ctx context.Context) error {
+	if err := s.setWebhook(); err != nil {
+		return err
+	}
+
+	log.Printf("Bot iniciado: @%s (webhook em %s%s)", s.botInfo.UserName, s.config.TelegramWebhookURL, s.config.TelegramWebhookPath)
+
+	<-ctx.Done()
+	return nil
+}
+
+// Shutdown remove o webhook em uma parada limpa, para que uma próxima
+// execução em modo polling não seja recusada pelo Telegram
+func (s *TelegramService) Shutdown() {
+	if s.config.TelegramMode != "webhook" {
+		return
+	}
+
+	if err := s.deleteWebhook(); err != nil {
+		log.Printf("Erro ao remover webhook: %v", err)
+		return
+	}
+	log.Println("Webhook removido")
+}
+
+// setWebhook registra a URL pública do bot com o segredo que será verificado a cada requisição
+func (s *TelegramService) setWebhook() error {
+	payload := map[string]interface{}{
+		"url":             s.config.TelegramWebhookURL + s.config.TelegramWebhookPath,
+		"secret_token":    s.webhookSecret,
+		"allowed_updates": allowedUpdates,
+	}
+
+	if _, err := s.makeRequest("setWebhook", payload); err != nil {
+		return fmt.Errorf("erro ao registrar webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *TelegramService) deleteWebhook() error {
+	_, err := s.makeRequest("deleteWebhook", map[string]interface{}{
+		"drop_pending_updates": false,
+	})
+	return err
+}
+
+// webhookSecretFor usa o segredo configurado ou gera um aleatório para esta execução
+func webhookSecretFor(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar segredo do webhook: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// WebhookHandler recebe as atualizações enviadas pelo Telegram e as encaminha
+// para o mesmo fluxo usado no long polling
+func (s *TelegramService) WebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if s.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.webhookSecret)) != 1 {
+			http.Error(w, "Unauthorized: Invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		var update Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Atualização inválida", http.StatusBadRequest)
+			return
+		}
+
+		s.dispatch(update)
+		w.WriteHeader(http.StatusOK)
+	}
+}